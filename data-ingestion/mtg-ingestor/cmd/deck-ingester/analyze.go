@@ -0,0 +1,23 @@
+package main
+
+import (
+	"github.com/mtg/mtg-ingestor/internal/deck"
+	"github.com/mtg/mtg-ingestor/internal/fetcher"
+	"github.com/sirupsen/logrus"
+)
+
+// loadCardIndex downloads the MTGJSON atomic cards used to analyze decks, or
+// reads them from cardsFile when set
+func loadCardIndex(logger *logrus.Logger, cardsFile string) (*deck.CardNameIndex, error) {
+	mtgFetcher := fetcher.NewMTGFetcher(logger)
+	if cardsFile != "" {
+		if _, err := mtgFetcher.ReplayFile(cardsFile); err != nil {
+			return nil, err
+		}
+	}
+	cards, err := mtgFetcher.FetchAtomicCards()
+	if err != nil {
+		return nil, err
+	}
+	return deck.NewCardNameIndexFromCards(cards), nil
+}
@@ -0,0 +1,42 @@
+package main
+
+import (
+	"github.com/mtg/mtg-ingestor/internal/deck"
+	"github.com/mtg/mtg-ingestor/internal/models"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/viper"
+)
+
+// legalityCheck checks decks against the constructed formats in decks.legality_formats
+type legalityCheck struct {
+	formats []string
+	banned  deck.BannedList
+	lookup  func(name string) (models.Card, bool)
+}
+
+// newLegalityCheck combines the banned lists from config with those in the card
+// data, when it was loaded
+func newLegalityCheck(cardIndex *deck.CardNameIndex) legalityCheck {
+	check := legalityCheck{
+		formats: viper.GetStringSlice("decks.legality_formats"),
+		banned:  deck.NewBannedList(viper.GetStringMapStringSlice("decks.banned")),
+	}
+	if cardIndex != nil {
+		check.banned.Merge(cardIndex.BannedList())
+		check.lookup = cardIndex.Lookup
+	}
+	return check
+}
+
+// apply records the deck's legality in each format, logging violations
+func (c legalityCheck) apply(d *deck.Deck, logger *logrus.Logger) {
+	if len(c.formats) == 0 {
+		return
+	}
+	d.CheckLegality(c.formats, c.banned, c.lookup)
+	for _, result := range d.Legality {
+		if !result.Legal {
+			logger.WithField("problems", result.Problems).Infof("Deck %s is not legal in %s", d.Name, result.Format)
+		}
+	}
+}
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"time"
+
+	"github.com/mtg/mtg-ingestor/internal/deck"
+	"github.com/mtg/mtg-ingestor/internal/kafka"
+	"github.com/sirupsen/logrus"
+)
+
+// deckPublisher publishes deck, deck card and deck stats events and records
+// published deck IDs in the ingest state
+type deckPublisher struct {
+	producer   *kafka.Producer
+	ingester   *deck.Ingester
+	cardIndex  *deck.CardNameIndex
+	statsTopic string
+	legality   legalityCheck
+	state      *ingestState
+	stateFile  string
+	force      bool
+	logger     *logrus.Logger
+}
+
+// publish sends a deck's events, returning the number of card events
+// published and whether the deck was skipped as unchanged. The deck is only
+// recorded as published once its deck and card events were all produced, so
+// a partly published deck is sent again on the next run.
+func (p *deckPublisher) publish(d *deck.Deck) (cardEvents int, skipped bool, err error) {
+	if _, seen := p.state.Published[d.ID]; seen && !p.force {
+		p.logger.Debugf("Skipping unchanged deck: %s", d.Name)
+		return 0, true, nil
+	}
+
+	// Legality results are part of the deck event payload
+	p.legality.apply(d, p.logger)
+
+	// Publish main deck event
+	if err := p.producer.PublishDeck(p.ingester.CreateDeckEvent(d)); err != nil {
+		return 0, false, err
+	}
+
+	// Publish individual card events for Flink processing
+	events := p.ingester.CreateDeckCardEvents(d)
+	for _, cardEvent := range events {
+		if err := p.producer.PublishDeckCard(cardEvent); err != nil {
+			p.logger.WithError(err).Error("Failed to publish deck card event")
+			continue
+		}
+		cardEvents++
+	}
+	if cardEvents == len(events) {
+		p.state.Published[d.ID] = time.Now()
+	} else {
+		p.logger.Warnf("Deck '%s' will be published again: %d of %d card events failed",
+			d.Name, len(events)-cardEvents, len(events))
+	}
+
+	// Publish composition stats alongside the deck event
+	if p.cardIndex != nil {
+		stats := deck.AnalyzeDeck(d, p.cardIndex.Lookup)
+		if err := p.producer.PublishDeckEvent(p.statsTopic, d.ID, p.ingester.CreateDeckAnalyzedEvent(d, stats)); err != nil {
+			p.logger.WithError(err).Errorf("Failed to publish deck stats for: %s", d.Name)
+		}
+	}
+
+	return cardEvents, false, nil
+}
+
+// flush waits for outstanding messages and saves the state once all were delivered
+func (p *deckPublisher) flush() {
+	if _, err := p.producer.FlushWithRetry(15 * time.Second); err != nil {
+		p.logger.WithError(err).Warn("Not updating ingest state")
+	} else if err := p.state.save(p.stateFile); err != nil {
+		p.logger.WithError(err).Error("Failed to save ingest state")
+	}
+}
@@ -0,0 +1,49 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+)
+
+// ingestState records which deck IDs have already been published
+type ingestState struct {
+	Published map[string]time.Time `json:"published"`
+}
+
+// loadState reads the state file, returning empty state if it doesn't exist yet
+func loadState(path string) (*ingestState, error) {
+	state := &ingestState{Published: map[string]time.Time{}}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return state, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read state file: %w", err)
+	}
+
+	if err := json.Unmarshal(data, state); err != nil {
+		return nil, fmt.Errorf("failed to parse state file: %w", err)
+	}
+	if state.Published == nil {
+		state.Published = map[string]time.Time{}
+	}
+	return state, nil
+}
+
+// save writes the state file atomically
+func (s *ingestState) save(path string) error {
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal state: %w", err)
+	}
+
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return fmt.Errorf("failed to write state file: %w", err)
+	}
+	return os.Rename(tmp, path)
+}
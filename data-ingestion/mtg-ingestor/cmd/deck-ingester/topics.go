@@ -0,0 +1,22 @@
+package main
+
+import "github.com/spf13/viper"
+
+// setTopicOverrides applies the topic flags over the kafka.topics config;
+// empty values leave the configured names in place
+func setTopicOverrides(decks, deckCards, prefix string) {
+	if decks != "" {
+		viper.Set("kafka.topics.decks", decks)
+	}
+	if deckCards != "" {
+		viper.Set("kafka.topics.deck_cards", deckCards)
+	}
+	if prefix != "" {
+		viper.Set("kafka.topics.prefix", prefix)
+	}
+}
+
+// topicName returns the kafka.topics.<key> topic with the configured prefix
+func topicName(key string) string {
+	return viper.GetString("kafka.topics.prefix") + viper.GetString("kafka.topics."+key)
+}
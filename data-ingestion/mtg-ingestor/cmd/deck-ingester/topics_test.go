@@ -0,0 +1,35 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/spf13/viper"
+)
+
+func TestTopicNames(t *testing.T) {
+	tests := []struct {
+		name                     string
+		decks, deckCards, prefix string
+		wantDecks, wantDeckCards string
+	}{
+		{name: "config defaults", wantDecks: "mtg.decks", wantDeckCards: "mtg.deck-cards"},
+		{name: "flag overrides", decks: "decks.v2", deckCards: "deck-cards.v2", wantDecks: "decks.v2", wantDeckCards: "deck-cards.v2"},
+		{name: "prefix", prefix: "staging.", wantDecks: "staging.mtg.decks", wantDeckCards: "staging.mtg.deck-cards"},
+		{name: "prefix and override", decks: "decks.v2", prefix: "staging.", wantDecks: "staging.decks.v2", wantDeckCards: "staging.mtg.deck-cards"},
+	}
+
+	for _, tt := range tests {
+		viper.Reset()
+		viper.SetDefault("kafka.topics.decks", "mtg.decks")
+		viper.SetDefault("kafka.topics.deck_cards", "mtg.deck-cards")
+
+		setTopicOverrides(tt.decks, tt.deckCards, tt.prefix)
+		if got := topicName("decks"); got != tt.wantDecks {
+			t.Errorf("%s: decks topic = %q, want %q", tt.name, got, tt.wantDecks)
+		}
+		if got := topicName("deck_cards"); got != tt.wantDeckCards {
+			t.Errorf("%s: deck cards topic = %q, want %q", tt.name, got, tt.wantDeckCards)
+		}
+	}
+	viper.Reset()
+}
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/mtg/mtg-ingestor/internal/deck"
+	"github.com/sirupsen/logrus"
+)
+
+// validateDecks parses every deck file under dir and writes a report of each
+// deck's skipped lines, unknown cards and format violations. It returns the
+// number of decks and files with problems; nothing is published.
+func validateDecks(dir string, ingester *deck.Ingester, cardIndex *deck.CardNameIndex, legality legalityCheck, logger *logrus.Logger, w io.Writer) (int, error) {
+	files, err := deck.FindDeckFiles(dir, ingester.Recursive)
+	if err != nil {
+		return 0, err
+	}
+
+	checked, failed := 0, 0
+	for _, path := range files {
+		decks, err := ingester.IngestMultiFile(path)
+		if err != nil {
+			fmt.Fprintf(w, "FAIL %s\n  %v\n", path, err)
+			failed++
+			continue
+		}
+		for _, d := range decks {
+			checked++
+			legality.apply(d, logger)
+			problems := deckProblems(d, cardIndex)
+			if len(problems) == 0 {
+				fmt.Fprintf(w, "OK   %s (%s)\n", d.Name, d.FilePath)
+				continue
+			}
+			failed++
+			fmt.Fprintf(w, "FAIL %s (%s)\n", d.Name, d.FilePath)
+			for _, problem := range problems {
+				fmt.Fprintf(w, "  %s\n", problem)
+			}
+		}
+	}
+
+	fmt.Fprintf(w, "\n%d decks in %d files checked, %d with problems\n", checked, len(files), failed)
+	return failed, nil
+}
+
+// deckProblems describes everything that makes a deck fail validation
+func deckProblems(d *deck.Deck, cardIndex *deck.CardNameIndex) []string {
+	var problems []string
+	for _, issue := range d.ParseReport.Issues {
+		problems = append(problems, fmt.Sprintf("line %d: %s: %q", issue.Line, issue.Reason, issue.Text))
+	}
+	for _, name := range d.UnknownCards {
+		problem := "unknown card: " + name
+		if cardIndex != nil {
+			if suggestions := cardIndex.Suggest(name); len(suggestions) > 0 {
+				problem += fmt.Sprintf(" (did you mean %s?)", strings.Join(suggestions, ", "))
+			}
+		}
+		problems = append(problems, problem)
+	}
+	for _, result := range d.Legality {
+		for _, problem := range result.Problems {
+			problems = append(problems, fmt.Sprintf("%s: %s", result.Format, problem))
+		}
+	}
+	return problems
+}
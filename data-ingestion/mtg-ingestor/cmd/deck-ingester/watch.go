@@ -0,0 +1,166 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"syscall"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/mtg/mtg-ingestor/internal/deck"
+)
+
+// watchDecks publishes deck files as they are created, modified or removed
+// until the process is interrupted. Events are coalesced until the directory
+// has been quiet for the debounce period, so an editor's burst of writes
+// produces a single publish. deckIDs maps each file to the deck IDs it last
+// published, so removed or replaced decks can be tombstoned.
+func watchDecks(dir string, p *deckPublisher, deckIDs map[string][]string, debounce time.Duration) error {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return fmt.Errorf("failed to create watcher: %w", err)
+	}
+	defer watcher.Close()
+
+	if err := addWatchDirs(watcher, dir, p.ingester.Recursive); err != nil {
+		return err
+	}
+
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGINT, syscall.SIGTERM)
+	defer signal.Stop(signals)
+
+	timer := time.NewTimer(debounce)
+	timer.Stop()
+	pending := map[string]bool{}
+
+	p.logger.Infof("Watching %s for deck changes", dir)
+	for {
+		select {
+		case event, ok := <-watcher.Events:
+			if !ok {
+				return nil
+			}
+			if event.Has(fsnotify.Create) && p.ingester.Recursive {
+				if info, err := os.Stat(event.Name); err == nil && info.IsDir() {
+					if err := addWatchDirs(watcher, event.Name, true); err != nil {
+						p.logger.WithError(err).Warnf("Failed to watch new directory: %s", event.Name)
+					}
+					continue
+				}
+			}
+			if !deck.IsDeckFile(filepath.Base(event.Name)) || event.Op == fsnotify.Chmod {
+				continue
+			}
+			pending[event.Name] = true
+			timer.Reset(debounce)
+
+		case err, ok := <-watcher.Errors:
+			if !ok {
+				return nil
+			}
+			p.logger.WithError(err).Warn("Deck watcher error")
+
+		case <-timer.C:
+			for path := range pending {
+				p.syncFile(dir, path, deckIDs)
+			}
+			pending = map[string]bool{}
+			p.flush()
+
+		case sig := <-signals:
+			p.logger.Infof("Received %v, stopping deck watcher", sig)
+			if len(pending) > 0 {
+				for path := range pending {
+					p.syncFile(dir, path, deckIDs)
+				}
+				p.flush()
+			}
+			return nil
+		}
+	}
+}
+
+// syncFile publishes the current contents of a deck file, or deletions if it
+// is gone, tombstoning the decks the file previously produced
+func (p *deckPublisher) syncFile(root, path string, deckIDs map[string][]string) {
+	previous := deckIDs[path]
+
+	if _, err := os.Stat(path); errors.Is(err, fs.ErrNotExist) {
+		if len(previous) == 0 {
+			return
+		}
+		if remaining := p.deleteDecks(previous, nil); len(remaining) > 0 {
+			deckIDs[path] = remaining
+			return
+		}
+		delete(deckIDs, path)
+		p.logger.Infof("Published deletion of removed deck file: %s", path)
+		return
+	}
+
+	decks, err := p.ingester.IngestMultiFile(path)
+	if err != nil {
+		p.logger.WithError(err).Errorf("Failed to ingest deck file: %s", path)
+		return
+	}
+
+	current := make(map[string]bool, len(decks))
+	ids := make([]string, 0, len(decks))
+	for _, d := range decks {
+		d.Category = deck.Category(root, path)
+		cardEvents, skipped, err := p.publish(d)
+		if err != nil {
+			p.logger.WithError(err).Errorf("Failed to publish deck event for: %s", d.Name)
+			continue
+		}
+		current[d.ID] = true
+		ids = append(ids, d.ID)
+		if !skipped {
+			p.logger.Infof("Published deck '%s' with %d card events", d.Name, cardEvents)
+		}
+	}
+
+	// An edit changes deck IDs, so drop the superseded decks
+	deckIDs[path] = append(ids, p.deleteDecks(previous, current)...)
+}
+
+// deleteDecks tombstones each deck ID not in keep, returning those whose
+// deletion failed so they can be retried on the next change
+func (p *deckPublisher) deleteDecks(ids []string, keep map[string]bool) []string {
+	var failed []string
+	for _, id := range ids {
+		if keep[id] {
+			continue
+		}
+		if err := p.producer.PublishDeckDeletion(id); err != nil {
+			p.logger.WithError(err).Errorf("Failed to publish deletion of deck: %s", id)
+			failed = append(failed, id)
+			continue
+		}
+		delete(p.state.Published, id)
+	}
+	return failed
+}
+
+// addWatchDirs watches dir and, when recursive, every directory beneath it
+func addWatchDirs(watcher *fsnotify.Watcher, dir string, recursive bool) error {
+	if !recursive {
+		return watcher.Add(dir)
+	}
+	return filepath.WalkDir(dir, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if entry.IsDir() {
+			if err := watcher.Add(path); err != nil {
+				return fmt.Errorf("failed to watch %s: %w", path, err)
+			}
+		}
+		return nil
+	})
+}
@@ -0,0 +1,51 @@
+package main
+
+import (
+	"github.com/mtg/mtg-ingestor/internal/fetcher"
+	"github.com/mtg/mtg-ingestor/internal/models"
+	"github.com/mtg/mtg-ingestor/internal/sink"
+	"github.com/sirupsen/logrus"
+)
+
+// loadCardSnapshot returns the cards published by the previous run, or nil when
+// every card should be published: no snapshot yet, an unreadable one, or a
+// sink that can't publish deltas
+func loadCardSnapshot(logger *logrus.Logger, store fetcher.SnapshotStore, publisher sink.Sink) map[string]models.Card {
+	if _, ok := publisher.(sink.CardDeltaSink); !ok {
+		logger.Warn("Configured sink does not support card delta events, publishing every card")
+		return nil
+	}
+	previous, meta, err := store.Load()
+	if err != nil {
+		logger.Warnf("Ignoring unreadable card snapshot, publishing every card: %v", err)
+		return nil
+	}
+	if previous == nil {
+		logger.Info("No card snapshot yet, publishing every card")
+		return nil
+	}
+	logger.Infof("Comparing against snapshot of %d cards from MTGJSON %s (%s)", len(previous), meta.Version, meta.Date)
+	return previous
+}
+
+// publishCardDeltas publishes card.added, card.changed and card.removed events
+// for the cards that differ from the snapshot, returning how many were
+// published out of how many deltas
+func publishCardDeltas(logger *logrus.Logger, deltaSink sink.CardDeltaSink, previous, cards map[string]models.Card) (published, total int) {
+	diff := models.DiffCards(previous, cards)
+	deltas := diff.Deltas(previous, cards)
+	logger.Infof("Publishing %d card deltas: %d added, %d changed, %d removed",
+		len(deltas), len(diff.Added), len(diff.Changed), len(diff.Removed))
+
+	for _, delta := range deltas {
+		if err := deltaSink.PublishCardDelta(delta); err != nil {
+			logger.Errorf("Failed to publish %s card %s: %v", delta.Kind, delta.Card.Name, err)
+			continue
+		}
+		published++
+		if published%1000 == 0 {
+			logger.Infof("Published %d/%d card deltas", published, len(deltas))
+		}
+	}
+	return published, len(deltas)
+}
@@ -0,0 +1,17 @@
+package main
+
+import "github.com/mtg/mtg-ingestor/internal/models"
+
+// filterSets drops digital-only sets, and with them their cards, when
+// excludeDigital is set. It filters in place and returns the sets kept.
+func filterSets(sets map[string]models.Set, excludeDigital bool) map[string]models.Set {
+	if !excludeDigital {
+		return sets
+	}
+	for code, set := range sets {
+		if set.IsDigitalOnly() {
+			delete(sets, code)
+		}
+	}
+	return sets
+}
@@ -0,0 +1,38 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/mtg/mtg-ingestor/internal/models"
+)
+
+func TestFilterSets(t *testing.T) {
+	newSets := func() map[string]models.Set {
+		return map[string]models.Set{
+			"DOM": {Code: "DOM", Name: "Dominaria", Cards: make([]models.Card, 2)},
+			"VMA": {Code: "VMA", Name: "Vintage Masters", IsOnlineOnly: true, Cards: make([]models.Card, 3)},
+			"PR2": {Code: "PR2", Name: "Promos", IsFoilOnly: true},
+		}
+	}
+
+	tests := []struct {
+		name           string
+		excludeDigital bool
+		want           []string
+	}{
+		{"filter off keeps digital sets", false, []string{"DOM", "PR2", "VMA"}},
+		{"filter on drops digital sets", true, []string{"DOM", "PR2"}},
+	}
+
+	for _, tt := range tests {
+		sets := filterSets(newSets(), tt.excludeDigital)
+		if len(sets) != len(tt.want) {
+			t.Errorf("%s: kept %d sets, want %v", tt.name, len(sets), tt.want)
+		}
+		for _, code := range tt.want {
+			if _, ok := sets[code]; !ok {
+				t.Errorf("%s: set %s was dropped", tt.name, code)
+			}
+		}
+	}
+}
@@ -0,0 +1,82 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/mtg/mtg-ingestor/internal/models"
+)
+
+// histogramBarWidth is the width of the largest set's bar
+const histogramBarWidth = 40
+
+// setCount is one row of the cards-per-set histogram
+type setCount struct {
+	Code     string
+	Name     string
+	Cards    int
+	Declared int
+}
+
+// Anomalous reports whether the parsed card count disagrees with the set's
+// declared total size; sets declaring no size are never flagged
+func (c setCount) Anomalous() bool {
+	return c.Declared > 0 && c.Cards != c.Declared
+}
+
+// setHistogram counts the cards of each set, sorted by "count" (largest
+// first) or "code"
+func setHistogram(sets map[string]models.Set, sortBy string) ([]setCount, error) {
+	counts := make([]setCount, 0, len(sets))
+	for code, set := range sets {
+		counts = append(counts, setCount{Code: code, Name: set.Name, Cards: len(set.Cards), Declared: set.TotalSetSize})
+	}
+
+	switch sortBy {
+	case "count":
+		sort.Slice(counts, func(i, j int) bool {
+			if counts[i].Cards != counts[j].Cards {
+				return counts[i].Cards > counts[j].Cards
+			}
+			return counts[i].Code < counts[j].Code
+		})
+	case "code":
+		sort.Slice(counts, func(i, j int) bool { return counts[i].Code < counts[j].Code })
+	default:
+		return nil, fmt.Errorf("unknown histogram sort %q, expected count or code", sortBy)
+	}
+	return counts, nil
+}
+
+// printSetHistogram writes the histogram as a table with a bar per set,
+// marking sets whose card count disagrees with their declared size
+func printSetHistogram(w io.Writer, counts []setCount) error {
+	largest := 0
+	for _, c := range counts {
+		largest = max(largest, c.Cards)
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "CODE\tNAME\tCARDS\tDECLARED\t\t")
+	anomalies := 0
+	for _, c := range counts {
+		bar := ""
+		if largest > 0 {
+			bar = strings.Repeat("#", (c.Cards*histogramBarWidth+largest-1)/largest)
+		}
+		flag := ""
+		if c.Anomalous() {
+			flag = fmt.Sprintf("MISMATCH (%+d)", c.Cards-c.Declared)
+			anomalies++
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%d\t%d\t%s\t%s\n", c.Code, c.Name, c.Cards, c.Declared, bar, flag)
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+	_, err := fmt.Fprintf(w, "%d sets, %d with a card count differing from the declared size\n", len(counts), anomalies)
+	return err
+}
@@ -0,0 +1,44 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/mtg/mtg-ingestor/internal/models"
+)
+
+func TestSetHistogram(t *testing.T) {
+	sets := map[string]models.Set{
+		"AAA": {Name: "Small", TotalSetSize: 1, Cards: make([]models.Card, 1)},
+		"BBB": {Name: "Large", TotalSetSize: 4, Cards: make([]models.Card, 3)},
+		"CCC": {Name: "Undeclared", Cards: make([]models.Card, 2)},
+	}
+
+	counts, err := setHistogram(sets, "count")
+	if err != nil {
+		t.Fatalf("setHistogram: %v", err)
+	}
+	var order []string
+	for _, c := range counts {
+		order = append(order, c.Code)
+	}
+	if got := strings.Join(order, ","); got != "BBB,CCC,AAA" {
+		t.Errorf("count order = %s, want BBB,CCC,AAA", got)
+	}
+	if !counts[0].Anomalous() || counts[1].Anomalous() || counts[2].Anomalous() {
+		t.Errorf("only BBB should be anomalous: %+v", counts)
+	}
+
+	var buf bytes.Buffer
+	if err := printSetHistogram(&buf, counts); err != nil {
+		t.Fatalf("printSetHistogram: %v", err)
+	}
+	if !strings.Contains(buf.String(), "MISMATCH (-1)") || !strings.HasSuffix(buf.String(), "3 sets, 1 with a card count differing from the declared size\n") {
+		t.Errorf("unexpected histogram:\n%s", buf.String())
+	}
+
+	if _, err := setHistogram(sets, "size"); err == nil {
+		t.Error("setHistogram accepted an unknown sort")
+	}
+}
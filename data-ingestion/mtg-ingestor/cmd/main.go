@@ -1,137 +1,803 @@
 package main
 
 import (
+	"encoding/json"
+	"errors"
+	"flag"
 	"fmt"
 	"os"
+	"sort"
+	"strings"
 	"time"
 
 	"github.com/mtg/mtg-ingestor/internal/fetcher"
 	"github.com/mtg/mtg-ingestor/internal/kafka"
+	"github.com/mtg/mtg-ingestor/internal/logging"
+	"github.com/mtg/mtg-ingestor/internal/models"
+	"github.com/mtg/mtg-ingestor/internal/sink"
 	"github.com/sirupsen/logrus"
 	"github.com/spf13/viper"
 )
 
 func main() {
+	var (
+		skipPrices  = flag.Bool("skip-prices", false, "Skip fetching and publishing prices")
+		pricesOnly  = flag.Bool("prices-only", false, "Only fetch and publish prices, skipping sets and cards")
+		fetchSets   = flag.Bool("sets", false, "Fetch and publish sets (with their cards)")
+		fetchCards  = flag.Bool("cards", false, "Fetch and publish atomic cards")
+		fetchPrices = flag.Bool("prices", false, "Fetch and publish prices")
+		fetchAll    = flag.Bool("all", false, "Fetch and publish everything (default when no stage flag is given)")
+		since       = flag.String("since", "", "Only publish sets released on or after this date (YYYY-MM-DD)")
+		format      = flag.String("format", "", "Only publish cards legal or restricted in this format, e.g. standard")
+		sinkName    = flag.String("sink", "kafka", "Where to publish: kafka, postgres, memory or noop")
+		configPath  = flag.String("config", "", "Path to the config file (default: search /app/configs, ./configs and .)")
+		dryRun      = flag.Bool("dry-run", false, "Fetch and report counts without creating a Kafka producer or publishing")
+		limit       = flag.Int("limit", 0, "Publish at most this many sets, cards and price records each (0 = unlimited)")
+		fromFile    = flag.String("from-file", "", "Replay comma-separated local MTGJSON files (e.g. AllPrices.json.gz) instead of downloading; only their stages run")
+		workers     = flag.Int("workers", 1, "Goroutines publishing price records in parallel; more than 1 gives up ordering between records")
+		histogram   = flag.String("set-histogram", "", "Print cards per set, sorted by count or code, flagging sets that differ from their declared size")
+		logFormat   = flag.String("log-format", "", "Log format: json or text (overrides app.log_format)")
+		logOutput   = flag.String("log-output", "", "Log destination: stderr, stdout or a file path (overrides app.log_output)")
+	)
+	flag.Parse()
+
 	// Initialize logger
 	logger := logrus.New()
 	logger.SetFormatter(&logrus.JSONFormatter{})
 
 	// Load configuration
-	if err := loadConfig(); err != nil {
+	if err := loadConfig(*configPath); err != nil {
 		logger.Fatalf("Failed to load config: %v", err)
 	}
 
-	// Set log level
+	// Set log level, format and destination
 	level, err := logrus.ParseLevel(viper.GetString("app.log_level"))
 	if err != nil {
 		level = logrus.InfoLevel
 	}
 	logger.SetLevel(level)
+	if *logFormat != "" {
+		viper.Set("app.log_format", *logFormat)
+	}
+	if *logOutput != "" {
+		viper.Set("app.log_output", *logOutput)
+	}
+	logCloser, err := logging.Configure(logger, viper.GetString("app.log_format"), viper.GetString("app.log_output"))
+	if err != nil {
+		logger.Fatalf("Failed to configure logging: %v", err)
+	}
+	defer logCloser.Close()
+
+	switch *sinkName {
+	case "kafka", "postgres", "memory", "noop":
+	default:
+		logger.Fatalf("Unknown --sink %q, expected kafka, postgres, memory or noop", *sinkName)
+	}
+
+	runSets, runCards, runPrices, err := selectStages(stageFlags{
+		Sets:       *fetchSets,
+		Cards:      *fetchCards,
+		Prices:     *fetchPrices,
+		All:        *fetchAll,
+		SkipPrices: *skipPrices,
+		PricesOnly: *pricesOnly,
+	})
+	if err != nil {
+		logger.Fatal(err)
+	}
+
+	if *limit < 0 {
+		logger.Fatalf("Invalid --limit %d, expected 0 (unlimited) or more", *limit)
+	}
+	if *limit > 0 {
+		logger.Infof("Limit in effect: publishing at most %d sets, cards and price records each", *limit)
+	}
+
+	if *workers < 1 {
+		logger.Fatalf("Invalid --workers %d, expected 1 or more", *workers)
+	}
+
+	switch *histogram {
+	case "", "count", "code":
+	default:
+		logger.Fatalf("Unknown --set-histogram %q, expected count or code", *histogram)
+	}
+
+	var sinceDate time.Time
+	if *since != "" {
+		sinceDate, err = time.Parse("2006-01-02", *since)
+		if err != nil {
+			logger.Fatalf("Invalid --since date %q: %v", *since, err)
+		}
+	}
 
 	logger.Info("Starting MTG data ingestion job")
 
 	// Initialize MTG fetcher
-	mtgFetcher := fetcher.NewMTGFetcher(logger)
-
-	// Initialize Kafka producer
-	kafkaProducer, err := kafka.NewProducer(kafka.ProducerConfig{
-		Brokers:     viper.GetString("kafka.brokers"),
-		CardsTopic:  viper.GetString("kafka.topics.cards"),
-		SetsTopic:   viper.GetString("kafka.topics.sets"),
-		PricesTopic: viper.GetString("kafka.topics.prices"),
-		Logger:      logger,
+	mtgFetcher := fetcher.NewMTGFetcherWithHTTPConfig(logger, fetcher.HTTPConfig{
+		Timeout:               viper.GetDuration("fetcher.timeout"),
+		DialTimeout:           viper.GetDuration("fetcher.dial_timeout"),
+		TLSHandshakeTimeout:   viper.GetDuration("fetcher.tls_handshake_timeout"),
+		ResponseHeaderTimeout: viper.GetDuration("fetcher.response_header_timeout"),
+		IdleConnTimeout:       viper.GetDuration("fetcher.idle_conn_timeout"),
 	})
-	if err != nil {
-		logger.Fatalf("Failed to create Kafka producer: %v", err)
+	mtgFetcher.DedupeUnchanged = viper.GetBool("fetcher.dedupe_unchanged_prices")
+	mtgFetcher.DedupeDuplicates = viper.GetBool("fetcher.dedupe_duplicate_prices")
+	mtgFetcher.VerifyChecksums = viper.GetBool("fetcher.verify_checksums")
+	mtgFetcher.DownloadDir = viper.GetString("fetcher.download_dir")
+	mtgFetcher.RetryAttempts = viper.GetInt("fetcher.retry_attempts")
+	mtgFetcher.RetryDelay = viper.GetDuration("fetcher.retry_delay")
+	mtgFetcher.Progress = logProgress(logger, 5*time.Second)
+	if err := mtgFetcher.SetCompression(viper.GetString("fetcher.compression")); err != nil {
+		logger.Fatalf("Invalid fetcher.compression: %v", err)
+	}
+
+	// Replaying cached files runs just the stages they cover, without touching the network
+	replaying := *fromFile != ""
+	if replaying {
+		runSets, runCards, runPrices = false, false, false
+		for _, path := range strings.Split(*fromFile, ",") {
+			name, err := mtgFetcher.ReplayFile(strings.TrimSpace(path))
+			if err != nil {
+				logger.Fatalf("Invalid --from-file: %v", err)
+			}
+			switch name {
+			case fetcher.AllSetsFile:
+				runSets = true
+			case fetcher.AtomicCardsFile:
+				runCards = true
+			case fetcher.AllPricesFile:
+				runPrices = true
+			}
+		}
+	}
+
+	// Conditional requests: remember ETag/Last-Modified between runs
+	httpCacheFile := viper.GetString("fetcher.http_cache_file")
+	var httpCache *fetcher.HTTPCache
+	if httpCacheFile != "" {
+		httpCache, err = fetcher.LoadHTTPCache(httpCacheFile)
+		if err != nil {
+			logger.Warnf("Ignoring unreadable HTTP cache: %v", err)
+			httpCache = fetcher.NewHTTPCache()
+		}
+		mtgFetcher.SetHTTPCache(httpCache)
+	}
+
+	// Skip the whole run when MTGJSON hasn't published a new build since last time
+	metaFile := viper.GetString("fetcher.meta_file")
+	if metaFile != "" && !replaying {
+		lastMeta, err := fetcher.LoadMeta(metaFile)
+		if err != nil {
+			logger.Warnf("Ignoring unreadable meta file: %v", err)
+		}
+		changed, err := mtgFetcher.HasChangedSince(lastMeta)
+		if err != nil {
+			logger.Warnf("Could not check MTGJSON version, running full ingestion: %v", err)
+		} else if !changed {
+			logger.Infof("MTGJSON version %s (%s) unchanged, skipping ingestion", lastMeta.Version, lastMeta.Date)
+			return
+		}
+	}
+
+	// Dry runs fetch everything but never create a producer, so they can't touch the cluster
+	var (
+		publisher    sink.Sink
+		publishSet   func(models.Set) error
+		publishCard  func(models.Card) error
+		publishPrice func(interface{}) error
+		// publishPriceBatch, when set, packs priceBatchSize records per message
+		publishPriceBatch func([]fetcher.PriceData) error
+		priceBatchSize    int
+		memorySink        *sink.InMemorySink
+	)
+	if *dryRun {
+		logger.Info("Dry run mode - fetching only, nothing will be published to Kafka")
+	} else if *sinkName == "memory" || *sinkName == "noop" {
+		// Local runs: exercise the whole pipeline without any external service
+		if *sinkName == "memory" {
+			memorySink = sink.NewInMemorySink()
+			publisher = memorySink
+		} else {
+			publisher = sink.NoopSink{}
+		}
+		publishSet = publisher.PublishSet
+		publishCard = publisher.PublishCard
+		publishPrice = publisher.PublishPrice
+		logger.Infof("Using %s sink, nothing will be published to Kafka", *sinkName)
+	} else if *sinkName == "postgres" {
+		pgSink, err := sink.NewPostgresSink(sink.PostgresConfig{
+			DSN:       postgresDSN(),
+			BatchSize: viper.GetInt("postgres.batch_size"),
+			Logger:    logger,
+		})
+		if err != nil {
+			logger.Fatalf("Failed to create Postgres sink: %v", err)
+		}
+		publisher = pgSink
+		defer publisher.Close()
+		publishSet = pgSink.PublishSet
+		publishCard = pgSink.PublishCard
+		publishPrice = pgSink.PublishPrice
+		logger.Info("Writing cards and sets to Postgres instead of Kafka")
+		if runPrices {
+			logger.Warn("Postgres sink does not store prices, skipping the prices stage")
+			runPrices = false
+		}
+	} else {
+		// Initialize Kafka producer
+		kafkaProducer, err := kafka.NewProducer(kafka.ProducerConfig{
+			Brokers:     viper.GetString("kafka.brokers"),
+			CardsTopic:  viper.GetString("kafka.topics.cards"),
+			SetsTopic:   viper.GetString("kafka.topics.sets"),
+			PricesTopic: viper.GetString("kafka.topics.prices"),
+			Logger:      logger,
+
+			PriceChangesTopic: viper.GetString("kafka.topics.price_changes"),
+			SetSummariesTopic: viper.GetString("kafka.topics.set_summaries"),
+			CardDeltasTopic:   viper.GetString("kafka.topics.card_deltas"),
+
+			QueueBufferingMaxMessages: viper.GetInt("kafka.producer.queue_buffering_max_messages"),
+			QueueBufferingMaxKbytes:   viper.GetInt("kafka.producer.queue_buffering_max_kbytes"),
+			PublishConcurrency:        viper.GetInt("kafka.producer.publish_concurrency"),
+			PublishRateLimit:          viper.GetFloat64("kafka.producer.publish_rate_limit"),
+			QueueFullTimeout:          viper.GetDuration("kafka.producer.queue_full_timeout"),
+			PartitionBy:               viper.GetString("kafka.producer.partition_by"),
+			StripRulings:              viper.GetBool("kafka.producer.strip_rulings"),
+			PriceBatchSize:            viper.GetInt("kafka.producer.price_batch_size"),
+			TopicCompression:          viper.GetStringMapString("kafka.producer.topic_compression"),
+			DLQTopic:                  viper.GetString("kafka.topics.dlq"),
+			Acks:                      viper.GetString("kafka.producer.acks"),
+			Retries:                   optionalInt("kafka.producer.retries"),
+			RetryBackoff:              viper.GetDuration("kafka.producer.retry_backoff"),
+			Linger:                    optionalDuration("kafka.producer.linger"),
+			BatchSize:                 viper.GetInt("kafka.producer.batch_size"),
+
+			SchemaRegistryURL:      viper.GetString("kafka.schema_registry.url"),
+			SchemaRegistryUsername: viper.GetString("kafka.schema_registry.username"),
+			SchemaRegistryPassword: viper.GetString("kafka.schema_registry.password"),
+		})
+		if err != nil {
+			logger.Fatalf("Failed to create Kafka producer: %v", err)
+		}
+
+		// Optionally tee every event to a secondary cluster (migration / DR)
+		publisher = kafkaProducer
+		teeEnabled := false
+		if secondaryBrokers := viper.GetString("kafka.secondary.brokers"); secondaryBrokers != "" {
+			secondaryProducer, err := kafka.NewProducer(kafka.ProducerConfig{
+				Brokers:     secondaryBrokers,
+				CardsTopic:  viper.GetString("kafka.topics.cards"),
+				SetsTopic:   viper.GetString("kafka.topics.sets"),
+				PricesTopic: viper.GetString("kafka.topics.prices"),
+				Logger:      logger,
+
+				PriceChangesTopic: viper.GetString("kafka.topics.price_changes"),
+				SetSummariesTopic: viper.GetString("kafka.topics.set_summaries"),
+				CardDeltasTopic:   viper.GetString("kafka.topics.card_deltas"),
+
+				QueueBufferingMaxMessages: viper.GetInt("kafka.producer.queue_buffering_max_messages"),
+				QueueBufferingMaxKbytes:   viper.GetInt("kafka.producer.queue_buffering_max_kbytes"),
+				PublishConcurrency:        viper.GetInt("kafka.producer.publish_concurrency"),
+				PublishRateLimit:          viper.GetFloat64("kafka.producer.publish_rate_limit"),
+				QueueFullTimeout:          viper.GetDuration("kafka.producer.queue_full_timeout"),
+				PartitionBy:               viper.GetString("kafka.producer.partition_by"),
+				StripRulings:              viper.GetBool("kafka.producer.strip_rulings"),
+				TopicCompression:          viper.GetStringMapString("kafka.producer.topic_compression"),
+				DLQTopic:                  viper.GetString("kafka.topics.dlq"),
+				Acks:                      viper.GetString("kafka.producer.acks"),
+				Retries:                   optionalInt("kafka.producer.retries"),
+				RetryBackoff:              viper.GetDuration("kafka.producer.retry_backoff"),
+				Linger:                    optionalDuration("kafka.producer.linger"),
+				BatchSize:                 viper.GetInt("kafka.producer.batch_size"),
+
+				SchemaRegistryURL:      viper.GetString("kafka.schema_registry.url"),
+				SchemaRegistryUsername: viper.GetString("kafka.schema_registry.username"),
+				SchemaRegistryPassword: viper.GetString("kafka.schema_registry.password"),
+			})
+			if err != nil {
+				logger.Fatalf("Failed to create secondary Kafka producer: %v", err)
+			}
+			multiProducer := kafka.NewMultiProducer(logger, kafkaProducer, secondaryProducer)
+			multiProducer.FailOnSecondaryError = viper.GetBool("kafka.secondary.fail_on_error")
+			publisher = multiProducer
+			teeEnabled = true
+			logger.Infof("Teeing events to secondary Kafka cluster at %s", secondaryBrokers)
+		}
+		defer publisher.Close()
+
+		// Async publishing is the default; sync mode waits for each broker ack so counts are accurate
+		publishSet = publisher.PublishSet
+		publishCard = publisher.PublishCard
+		publishPrice = publisher.PublishPrice
+		if viper.GetBool("kafka.producer.sync_delivery") && teeEnabled {
+			logger.Warn("Sync delivery is not supported when teeing to a secondary cluster, using async delivery")
+		} else if viper.GetBool("kafka.producer.sync_delivery") {
+			timeout := viper.GetDuration("kafka.producer.delivery_timeout")
+			if timeout <= 0 {
+				timeout = 30 * time.Second
+			}
+			logger.Infof("Sync delivery enabled (timeout %v)", timeout)
+			publishSet = func(set models.Set) error { return kafkaProducer.PublishSetSync(set, timeout) }
+			publishCard = func(card models.Card) error { return kafkaProducer.PublishCardSync(card, timeout) }
+			publishPrice = func(price interface{}) error { return kafkaProducer.PublishPriceSync(price, timeout) }
+		}
+
+		// Batched prices go to the primary cluster only and are delivered asynchronously
+		if priceBatchSize = viper.GetInt("kafka.producer.price_batch_size"); priceBatchSize > 0 {
+			if teeEnabled || viper.GetBool("kafka.producer.sync_delivery") {
+				logger.Warn("Price batching is not supported with teeing or sync delivery, publishing one message per price")
+			} else {
+				logger.Infof("Publishing prices in batches of %d records per message", priceBatchSize)
+				publishPriceBatch = kafkaProducer.PublishPriceBatch
+			}
+		}
 	}
-	defer kafkaProducer.Close()
+
+	// A run that skips stages or filters records mustn't mark the MTGJSON version as ingested;
+	// the set histogram is only a report, so it doesn't count
+	partial := !runSets || !runCards || !runPrices || *since != "" || *format != "" ||
+		viper.GetBool("filters.exclude_digital_only")
 
 	// Start ingestion process
 	startTime := time.Now()
+	summary := &runSummary{}
 
-	// Fetch and publish sets data
-	logger.Info("Fetching MTG sets data...")
-	sets, err := mtgFetcher.FetchAllSets()
-	if err != nil {
-		logger.Errorf("Failed to fetch sets: %v", err)
-	} else {
-		logger.Infof("Publishing %d sets to Kafka", len(sets))
-		publishedSets := 0
-		for _, set := range sets {
-			if err := kafkaProducer.PublishSet(set); err != nil {
-				logger.Errorf("Failed to publish set %s: %v", set.Code, err)
+	if runSets {
+		// Fetch and publish sets data
+		logger.Info("Fetching MTG sets data...")
+		stage := summary.stage("sets")
+		sets, fetchStats, err := mtgFetcher.FetchAllSetsWithStats()
+		if errors.Is(err, fetcher.ErrNotModified) {
+			logger.Info("Sets unchanged since last run, skipping")
+			stage.Unchanged = true
+		} else if err != nil {
+			logger.Errorf("Failed to fetch sets: %v", err)
+			stage.FetchError = err.Error()
+		} else {
+			stage.recordFetch(logger, fetchStats)
+			// Optionally drop digital-only sets (and with them their cards)
+			excludeDigital := viper.GetBool("filters.exclude_digital_only")
+			sets = filterSets(sets, excludeDigital)
+			if excludeDigital {
+				logger.Infof("Excluding digital-only sets, %d physical sets remain", len(sets))
+			}
+
+			// Optionally keep only sets released on or after --since
+			if !sinceDate.IsZero() {
+				for code, set := range sets {
+					released, err := set.ParseReleaseDate()
+					if err != nil {
+						logger.Warnf("Skipping set %s with unparseable release date %q", code, set.ReleaseDate)
+						delete(sets, code)
+						continue
+					}
+					if released.Before(sinceDate) {
+						delete(sets, code)
+					}
+				}
+				logger.Infof("Keeping %d sets released since %s", len(sets), *since)
+			}
+
+			// Declared sizes count every card, so the histogram comes before the --format filter
+			if *histogram != "" {
+				counts, _ := setHistogram(sets, *histogram)
+				for _, c := range counts {
+					if c.Anomalous() {
+						logger.Warnf("Set %s has %d cards but declares %d", c.Code, c.Cards, c.Declared)
+					}
+				}
+				if err := printSetHistogram(os.Stdout, counts); err != nil {
+					logger.Warnf("Failed to print set histogram: %v", err)
+				}
+			}
+
+			// Optionally drop cards outside --format; the set records themselves are kept
+			if *format != "" {
+				kept := 0
+				for code, set := range sets {
+					legal := set.Cards[:0]
+					for _, card := range set.Cards {
+						if card.IsLegalIn(*format) {
+							legal = append(legal, card)
+						}
+					}
+					set.Cards = legal
+					sets[code] = set
+					kept += len(legal)
+				}
+				logger.Infof("Keeping %d cards legal in %s", kept, *format)
+			}
+
+			if *limit > 0 && len(sets) > *limit {
+				limitMap(sets, *limit)
+				logger.Infof("Limiting to %d sets", len(sets))
+			}
+
+			if *dryRun {
+				logDryRunSample(logger, "sets", len(sets), sampleSet(sets))
 			} else {
-				publishedSets++
-				if publishedSets%100 == 0 {
-					logger.Infof("Published %d/%d sets", publishedSets, len(sets))
+				logger.Infof("Publishing %d sets to Kafka", len(sets))
+				publishedSets, failedSets := 0, 0
+				for _, set := range sets {
+					if err := publishSet(set); err != nil {
+						logger.Errorf("Failed to publish set %s: %v", set.Code, err)
+						// Batching sinks lose every buffered row when a write fails
+						failedSets += sink.FailedRecords(err)
+					} else {
+						publishedSets++
+						if publishedSets%100 == 0 {
+							logger.Infof("Published %d/%d sets", publishedSets, len(sets))
+						}
+					}
+				}
+				logger.Infof("Successfully published %d sets", publishedSets)
+				stage.Published, stage.Failed = publishedSets, failedSets
+
+				summarySink, canPublishSummaries := publisher.(sink.SetSummarySink)
+				if viper.GetBool("sets.emit_summaries") && !canPublishSummaries {
+					logger.Warn("Configured sink does not support set summary events, skipping them")
+				} else if viper.GetBool("sets.emit_summaries") {
+					logger.Infof("Publishing %d set summary events to Kafka", len(sets))
+					publishedSummaries := 0
+					for _, set := range sets {
+						if err := summarySink.PublishSetSummary(models.SummarizeSet(set)); err != nil {
+							logger.Errorf("Failed to publish summary for set %s: %v", set.Code, err)
+						} else {
+							publishedSummaries++
+						}
+					}
+					logger.Infof("Successfully published %d set summary events", publishedSummaries)
+					summaryStage := summary.stage("set_summaries")
+					summaryStage.Published, summaryStage.Failed = publishedSummaries, len(sets)-publishedSummaries
 				}
 			}
 		}
-		logger.Infof("Successfully published %d sets", publishedSets)
 	}
 
-	// Fetch and publish atomic cards
-	logger.Info("Fetching atomic cards data...")
-	cards, err := mtgFetcher.FetchAtomicCards()
-	if err != nil {
-		logger.Errorf("Failed to fetch atomic cards: %v", err)
-	} else {
-		logger.Infof("Publishing %d cards to Kafka", len(cards))
-		publishedCards := 0
-		for _, card := range cards {
-			if err := kafkaProducer.PublishCard(card); err != nil {
-				logger.Errorf("Failed to publish card %s: %v", card.Name, err)
+	// The card snapshot lets the cards stage publish deltas instead of every card
+	var snapshotStore fetcher.SnapshotStore
+	if path := viper.GetString("fetcher.snapshot_file"); path != "" {
+		snapshotStore = fetcher.NewFileSnapshotStore(path)
+	}
+	// snapshotCards replaces the snapshot once every card has been delivered
+	var snapshotCards map[string]models.Card
+
+	if runCards {
+		// Fetch and publish atomic cards
+		logger.Info("Fetching atomic cards data...")
+		stage := summary.stage("cards")
+		cards, fetchStats, err := mtgFetcher.FetchAtomicCardsWithStats()
+		if errors.Is(err, fetcher.ErrNotModified) {
+			logger.Info("Atomic cards unchanged since last run, skipping")
+			stage.Unchanged = true
+		} else if err != nil {
+			logger.Errorf("Failed to fetch atomic cards: %v", err)
+			stage.FetchError = err.Error()
+		} else {
+			stage.recordFetch(logger, fetchStats)
+			if *format != "" {
+				for name, card := range cards {
+					if !card.IsLegalIn(*format) {
+						delete(cards, name)
+					}
+				}
+				logger.Infof("Keeping %d atomic cards legal in %s", len(cards), *format)
+			}
+
+			if *limit > 0 && len(cards) > *limit {
+				limitMap(cards, *limit)
+				logger.Infof("Limiting to %d cards", len(cards))
+			}
+
+			if *dryRun {
+				logDryRunSample(logger, "cards", len(cards), sampleCard(cards))
 			} else {
-				publishedCards++
-				if publishedCards%1000 == 0 {
-					logger.Infof("Published %d/%d cards", publishedCards, len(cards))
+				// With a snapshot of the previous run only the cards that changed are published
+				var previous map[string]models.Card
+				if snapshotStore != nil {
+					previous = loadCardSnapshot(logger, snapshotStore, publisher)
+				}
+
+				publishedCards, failedCards, total := 0, 0, len(cards)
+				if previous != nil {
+					publishedCards, total = publishCardDeltas(logger, publisher.(sink.CardDeltaSink), previous, cards)
+					failedCards = total - publishedCards
+					logger.Infof("Successfully published %d card deltas", publishedCards)
+				} else {
+					logger.Infof("Publishing %d cards to Kafka", len(cards))
+					for _, card := range cards {
+						if err := publishCard(card); err != nil {
+							logger.Errorf("Failed to publish card %s: %v", card.Name, err)
+							// Batching sinks lose every buffered row when a write fails,
+							// including cards already counted as published
+							lost := sink.FailedRecords(err)
+							failedCards += lost
+							publishedCards -= min(lost-1, publishedCards)
+						} else {
+							publishedCards++
+							if publishedCards%1000 == 0 {
+								logger.Infof("Published %d/%d cards", publishedCards, len(cards))
+							}
+						}
+					}
+					logger.Infof("Successfully published %d cards", publishedCards)
+				}
+				stage.Published, stage.Failed = publishedCards, failedCards
+				if snapshotStore != nil && publishedCards == total {
+					snapshotCards = cards
 				}
 			}
 		}
-		logger.Infof("Successfully published %d cards", publishedCards)
 	}
 
-	// Fetch and publish prices
-	logger.Info("Fetching price data...")
-	prices, err := mtgFetcher.FetchPrices()
-	if err != nil {
-		logger.Errorf("Failed to fetch prices: %v", err)
-	} else {
-		logger.Infof("Publishing %d individual price records to Kafka", len(prices))
-		publishedPrices := 0
-		for _, price := range prices {
-			if err := kafkaProducer.PublishPrice(price); err != nil {
-				logger.Errorf("Failed to publish price: %v", err)
+	if runPrices {
+		// Prices are published as they are decoded, so the full record list is
+		// never held unless price change events need it
+		logger.Info("Fetching price data...")
+		stage := summary.stage("prices")
+		feed := make(chan fetcher.PriceData, 1024)
+		fetched := make(chan struct{})
+		var (
+			fetchStats fetcher.FetchStats
+			fetchErr   error
+		)
+		go func() {
+			defer close(fetched)
+			fetchStats, fetchErr = mtgFetcher.StreamPrices(feed)
+		}()
+		prices := limitPrices(feed, *limit)
+
+		changeSink, canPublishChanges := publisher.(sink.PriceChangeSink)
+		emitChanges := viper.GetBool("prices.emit_changes") && !*dryRun
+		if emitChanges && !canPublishChanges {
+			logger.Warn("Configured sink does not support price change events, skipping them")
+			emitChanges = false
+		}
+		var allPrices []fetcher.PriceData
+		if emitChanges {
+			prices = collectPrices(prices, &allPrices)
+		}
+
+		var publishedPrices, failedPrices, dryRunCount int
+		var dryRunSample interface{}
+		if *dryRun {
+			for p := range prices {
+				if dryRunCount == 0 {
+					dryRunSample = p
+				}
+				dryRunCount++
+			}
+		} else {
+			batchSize, publishBatch := priceBatchSize, publishPriceBatch
+			if publishPriceBatch != nil {
+				logger.Infof("Publishing price records to Kafka in batches as they are decoded using %d workers", *workers)
+			} else {
+				logger.Infof("Publishing individual price records to Kafka as they are decoded using %d workers", *workers)
+				batchSize = 1
+				publishBatch = func(batch []fetcher.PriceData) error { return publishPrice(batch[0]) }
+			}
+			var err error
+			publishedPrices, failedPrices, err = publishPrices(logger, prices, *workers, batchSize, publishBatch)
+			if err != nil {
+				logger.Errorf("Price publishing incomplete: %v", err)
+			}
+		}
+		<-fetched
+
+		if errors.Is(fetchErr, fetcher.ErrNotModified) {
+			logger.Info("Prices unchanged since last run, skipping")
+			stage.Unchanged = true
+		} else {
+			if fetchErr != nil {
+				// Records decoded before the error may already be published
+				logger.Errorf("Failed to fetch prices: %v", fetchErr)
+				stage.FetchError = fetchErr.Error()
 			} else {
-				publishedPrices++
-				if publishedPrices%1000 == 0 {
-					logger.Infof("Published %d/%d prices", publishedPrices, len(prices))
+				stage.recordFetch(logger, fetchStats)
+			}
+
+			if *dryRun {
+				logDryRunSample(logger, "prices", dryRunCount, dryRunSample)
+			} else {
+				logger.Infof("Successfully published %d price records", publishedPrices)
+				stage.Published, stage.Failed = publishedPrices, failedPrices
+			}
+
+			if emitChanges && fetchErr == nil {
+				changes := fetcher.PriceChanges(allPrices)
+				logger.Infof("Publishing %d price change events to Kafka", len(changes))
+				publishedChanges := 0
+				for _, change := range changes {
+					if err := changeSink.PublishPriceChange(change); err != nil {
+						logger.Errorf("Failed to publish price change: %v", err)
+					} else {
+						publishedChanges++
+					}
 				}
+				logger.Infof("Successfully published %d price change events", publishedChanges)
+				changeStage := summary.stage("price_changes")
+				changeStage.Published, changeStage.Failed = publishedChanges, len(changes)-publishedChanges
 			}
 		}
-		logger.Infof("Successfully published %d price records", publishedPrices)
 	}
 
-	// Flush any remaining messages
-	remaining := kafkaProducer.Flush(30000)
-	if remaining > 0 {
+	// Flush any remaining messages; dry runs don't record state so the next real run isn't skipped
+	if *dryRun {
+		logger.Info("Dry run complete, meta and HTTP cache files left untouched")
+	} else if remaining := flushPublisher(publisher, 30*time.Second); remaining > 0 {
 		logger.Warnf("%d messages were not delivered", remaining)
+		summary.Undelivered = remaining
+	} else if *limit > 0 {
+		// A partial publish mustn't make the next run think the data is unchanged
+		logger.Info("Publishing was limited, meta and HTTP cache files left untouched")
+	} else if replaying {
+		// Cached files may be older than what the last network run recorded
+		logger.Info("Replayed local files, meta and HTTP cache files left untouched")
+	} else if partial {
+		// Recording the version would skip the data this run left out next time
+		logger.Info("Only part of the data was ingested, meta and HTTP cache files left untouched")
+	} else if !summary.complete() {
+		logger.Warn("Some records were not fetched or published, meta and HTTP cache files left untouched")
+	} else {
+		if metaFile != "" && !mtgFetcher.LastMeta().IsZero() {
+			if err := fetcher.SaveMeta(metaFile, mtgFetcher.LastMeta()); err != nil {
+				logger.Warnf("Failed to save meta file: %v", err)
+			}
+		}
+		if httpCache != nil {
+			// Every stage got here fully ingested, so its validators can be trusted next run
+			for _, name := range []string{fetcher.AllSetsFile, fetcher.AtomicCardsFile, fetcher.AllPricesFile} {
+				mtgFetcher.CommitValidators(name)
+			}
+			if err := httpCache.Save(httpCacheFile); err != nil {
+				logger.Warnf("Failed to save HTTP cache: %v", err)
+			}
+		}
+	}
+
+	// The snapshot is what downstream has seen, so it's only replaced after a
+	// complete, fully delivered publish
+	if snapshotCards != nil && !*dryRun && *limit == 0 && summary.Undelivered == 0 {
+		if err := snapshotStore.Save(snapshotCards, mtgFetcher.LastMeta()); err != nil {
+			logger.Warnf("Failed to save card snapshot: %v", err)
+		} else {
+			logger.Infof("Saved snapshot of %d cards", len(snapshotCards))
+		}
+	}
+
+	if memorySink != nil {
+		logger.Infof("In-memory sink recorded %d sets, %d set summaries, %d cards, %d card deltas, %d prices and %d price changes",
+			len(memorySink.Sets()), len(memorySink.SetSummaries()), len(memorySink.Cards()), len(memorySink.CardDeltas()),
+			len(memorySink.Prices()), len(memorySink.PriceChanges()))
 	}
 
 	duration := time.Since(startTime)
 	logger.Infof("Ingestion completed in %v", duration)
+
+	// Final machine-readable summary; schedulers rely on the exit status
+	summary.Duration = duration.String()
+	summary.Success = !summary.failed(viper.GetFloat64("app.max_failure_rate"))
+	logger.WithField("summary", summary).Info("Ingestion summary")
+	if !summary.Success {
+		if publisher != nil {
+			publisher.Close()
+		}
+		os.Exit(1)
+	}
 }
 
-func loadConfig() error {
-	viper.SetConfigName("config")
-	viper.SetConfigType("yaml")
-	viper.AddConfigPath("/app/configs")
-	viper.AddConfigPath("./configs")
-	viper.AddConfigPath(".")
+// logProgress returns a fetcher progress callback that logs at most once per interval
+func logProgress(logger *logrus.Logger, interval time.Duration) fetcher.ProgressFunc {
+	var lastLogged time.Time
+	return func(bytesRead, totalBytes int64) {
+		done := totalBytes > 0 && bytesRead >= totalBytes
+		if !done && time.Since(lastLogged) < interval {
+			return
+		}
+		lastLogged = time.Now()
+		if totalBytes < 0 {
+			logger.Infof("Downloaded %.1f MB", float64(bytesRead)/(1<<20))
+			return
+		}
+		logger.Infof("Downloaded %.1f/%.1f MB (%.0f%%)",
+			float64(bytesRead)/(1<<20), float64(totalBytes)/(1<<20), 100*float64(bytesRead)/float64(totalBytes))
+	}
+}
+
+// flushPublisher flushes publisher, retrying through leader elections when it
+// supports FlushWithRetry, and returns the number of undelivered messages
+func flushPublisher(publisher sink.Sink, timeout time.Duration) int {
+	if retrier, ok := publisher.(interface {
+		FlushWithRetry(time.Duration) (int, error)
+	}); ok {
+		remaining, _ := retrier.FlushWithRetry(timeout)
+		return remaining
+	}
+	return publisher.Flush(int(timeout.Milliseconds()))
+}
+
+// optionalInt returns the configured value of key, or nil when it is unset so
+// the consumer applies its own default; unlike GetInt it tells 0 from unset
+func optionalInt(key string) *int {
+	if !viper.IsSet(key) {
+		return nil
+	}
+	v := viper.GetInt(key)
+	return &v
+}
+
+// optionalDuration is optionalInt for durations
+func optionalDuration(key string) *time.Duration {
+	if !viper.IsSet(key) {
+		return nil
+	}
+	v := viper.GetDuration(key)
+	return &v
+}
+
+// limitMap keeps the first n entries of m by key, so a limited run is repeatable
+func limitMap[V any](m map[string]V, n int) {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys[min(n, len(keys)):] {
+		delete(m, key)
+	}
+}
+
+// logDryRunSample reports what a dry run would have published
+func logDryRunSample(logger *logrus.Logger, entity string, count int, sample interface{}) {
+	logger.Infof("Dry run: would publish %d %s", count, entity)
+	if sample == nil {
+		return
+	}
+	data, err := json.Marshal(sample)
+	if err != nil {
+		logger.Warnf("Dry run: could not encode sample %s: %v", entity, err)
+		return
+	}
+	logger.Infof("Dry run: sample %s: %s", entity, data)
+}
+
+// sampleSet returns one set without its cards, to keep the logged sample small
+func sampleSet(sets map[string]models.Set) interface{} {
+	for _, set := range sets {
+		set.Cards = nil
+		return set
+	}
+	return nil
+}
+
+func sampleCard(cards map[string]models.Card) interface{} {
+	for _, card := range cards {
+		return card
+	}
+	return nil
+}
 
-	// Enable environment variable override
+// loadConfig reads configPath, or searches the default locations when it's
+// empty. Only a searched-for config may be missing; an explicit path must exist.
+func loadConfig(configPath string) error {
+	if configPath != "" {
+		if _, err := os.Stat(configPath); err != nil {
+			return fmt.Errorf("cannot read --config file: %w", err)
+		}
+		viper.SetConfigFile(configPath)
+	} else {
+		viper.SetConfigName("config")
+		viper.SetConfigType("yaml")
+		viper.AddConfigPath("/app/configs")
+		viper.AddConfigPath("./configs")
+		viper.AddConfigPath(".")
+	}
+
+	// Enable environment variable override, e.g. MTG_KAFKA_PRODUCER_ACKS for kafka.producer.acks
 	viper.AutomaticEnv()
 	viper.SetEnvPrefix("MTG")
+	viper.SetEnvKeyReplacer(strings.NewReplacer(".", "_"))
 
 	// Read config file
 	if err := viper.ReadInConfig(); err != nil {
@@ -150,17 +816,80 @@ func setDefaults() {
 	viper.SetDefault("app.name", "mtg-ingestor")
 	viper.SetDefault("app.environment", "development")
 	viper.SetDefault("app.log_level", "info")
+	viper.SetDefault("app.log_format", "json")
+	viper.SetDefault("app.log_output", "stderr")
+	viper.SetDefault("app.max_failure_rate", 1.0)
 
 	viper.SetDefault("kafka.brokers", getEnvOrDefault("KAFKA_BROKERS", "localhost:9092"))
 	viper.SetDefault("kafka.topics.cards", "mtg.cards")
 	viper.SetDefault("kafka.topics.sets", "mtg.sets")
 	viper.SetDefault("kafka.topics.prices", "mtg.prices")
+	viper.SetDefault("kafka.topics.price_changes", "mtg.price-changes")
+	viper.SetDefault("kafka.topics.set_summaries", "mtg.set-summaries")
+	viper.SetDefault("kafka.topics.card_deltas", "mtg.card-deltas")
+	viper.SetDefault("kafka.topics.dlq", "")
+	viper.SetDefault("kafka.producer.acks", "all")
+	viper.SetDefault("kafka.producer.retries", 10)
+	viper.SetDefault("kafka.producer.retry_backoff", "100ms")
+	viper.SetDefault("kafka.producer.linger", "10ms")
+	viper.SetDefault("kafka.producer.batch_size", 16384)
+	viper.SetDefault("kafka.producer.sync_delivery", false)
+	viper.SetDefault("kafka.producer.delivery_timeout", "30s")
+	viper.SetDefault("kafka.producer.queue_buffering_max_messages", 0)
+	viper.SetDefault("kafka.producer.queue_buffering_max_kbytes", 0)
+	viper.SetDefault("kafka.producer.publish_concurrency", 8)
+	viper.SetDefault("kafka.producer.publish_rate_limit", 0)
+	viper.SetDefault("kafka.producer.queue_full_timeout", "30s")
+	viper.SetDefault("kafka.producer.partition_by", "uuid")
+	viper.SetDefault("kafka.producer.strip_rulings", false)
+	viper.SetDefault("kafka.producer.price_batch_size", 0)
+	viper.SetDefault("kafka.producer.topic_compression", map[string]string{})
+	viper.SetDefault("kafka.schema_registry.url", "")
+	viper.SetDefault("kafka.schema_registry.username", "")
+	viper.SetDefault("kafka.schema_registry.password", "")
+	viper.SetDefault("kafka.secondary.brokers", "")
+	viper.SetDefault("kafka.secondary.fail_on_error", false)
+
+	viper.SetDefault("filters.exclude_digital_only", false)
+
+	viper.SetDefault("fetcher.timeout", "30m")
+	viper.SetDefault("fetcher.dial_timeout", "30s")
+	viper.SetDefault("fetcher.tls_handshake_timeout", "10s")
+	viper.SetDefault("fetcher.response_header_timeout", "1m")
+	viper.SetDefault("fetcher.idle_conn_timeout", "90s")
+	viper.SetDefault("fetcher.compression", "gzip")
+	viper.SetDefault("fetcher.dedupe_unchanged_prices", false)
+	viper.SetDefault("fetcher.dedupe_duplicate_prices", false)
+	viper.SetDefault("fetcher.verify_checksums", false)
+	viper.SetDefault("fetcher.download_dir", "")
+	viper.SetDefault("fetcher.retry_attempts", 3)
+	viper.SetDefault("fetcher.retry_delay", "5s")
+
+	viper.SetDefault("prices.emit_changes", false)
+	viper.SetDefault("sets.emit_summaries", false)
+	viper.SetDefault("fetcher.meta_file", "")
+	viper.SetDefault("fetcher.snapshot_file", "")
+	viper.SetDefault("fetcher.http_cache_file", "")
 
 	viper.SetDefault("postgres.host", getEnvOrDefault("POSTGRES_HOST", "localhost"))
 	viper.SetDefault("postgres.port", 5432)
 	viper.SetDefault("postgres.database", "mtg")
 	viper.SetDefault("postgres.user", "mtg_user")
+	viper.SetDefault("postgres.password", os.Getenv("POSTGRES_PASSWORD"))
 	viper.SetDefault("postgres.ssl_mode", "disable")
+	viper.SetDefault("postgres.batch_size", 500)
+}
+
+// postgresDSN builds the connection string from the postgres config block
+func postgresDSN() string {
+	return fmt.Sprintf("host=%s port=%d dbname=%s user=%s password=%s sslmode=%s",
+		viper.GetString("postgres.host"),
+		viper.GetInt("postgres.port"),
+		viper.GetString("postgres.database"),
+		viper.GetString("postgres.user"),
+		os.ExpandEnv(viper.GetString("postgres.password")),
+		viper.GetString("postgres.ssl_mode"),
+	)
 }
 
 func getEnvOrDefault(key, defaultValue string) string {
@@ -168,4 +897,4 @@ func getEnvOrDefault(key, defaultValue string) string {
 		return value
 	}
 	return defaultValue
-}
\ No newline at end of file
+}
@@ -0,0 +1,115 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+
+	"github.com/mtg/mtg-ingestor/internal/fetcher"
+	"github.com/sirupsen/logrus"
+)
+
+// maxReportedPriceErrors caps how many failures publishPrices keeps for its
+// returned error; the rest are only counted
+const maxReportedPriceErrors = 10
+
+// publishPrices publishes the records received on prices in batches of
+// batchSize over workers goroutines until prices is closed, logging progress
+// as it goes. It returns the number of price records published and failed and,
+// when any failed, an error joining the first few failures. Records keep their
+// order only with a single worker.
+func publishPrices(logger *logrus.Logger, prices <-chan fetcher.PriceData, workers, batchSize int, publish func([]fetcher.PriceData) error) (int, int, error) {
+	workers = max(workers, 1)
+	batchSize = max(batchSize, 1)
+	progressEvery := int64(1000)
+	if batchSize > 1 {
+		progressEvery = int64(100 * batchSize)
+	}
+
+	var (
+		published atomic.Int64
+		failed    atomic.Int64
+		mu        sync.Mutex
+		errs      []error
+		wg        sync.WaitGroup
+	)
+	jobs := make(chan []fetcher.PriceData, workers)
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for batch := range jobs {
+				if err := publish(batch); err != nil {
+					logger.Errorf("Failed to publish %d price records: %v", len(batch), err)
+					if failed.Add(int64(len(batch))) <= maxReportedPriceErrors*int64(batchSize) {
+						mu.Lock()
+						if len(errs) < maxReportedPriceErrors {
+							errs = append(errs, err)
+						}
+						mu.Unlock()
+					}
+					continue
+				}
+				n := int64(len(batch))
+				if total := published.Add(n); total/progressEvery != (total-n)/progressEvery {
+					logger.Infof("Published %d prices", total)
+				}
+			}
+		}()
+	}
+
+	batch := make([]fetcher.PriceData, 0, batchSize)
+	for p := range prices {
+		batch = append(batch, p)
+		if len(batch) == batchSize {
+			jobs <- batch
+			batch = make([]fetcher.PriceData, 0, batchSize)
+		}
+	}
+	if len(batch) > 0 {
+		jobs <- batch
+	}
+	close(jobs)
+	wg.Wait()
+
+	n, f := int(published.Load()), int(failed.Load())
+	if f > 0 {
+		return n, f, fmt.Errorf("%d of %d price records failed: %w", f, n+f, errors.Join(errs...))
+	}
+	return n, 0, nil
+}
+
+// limitPrices passes on at most limit records from prices (0 = all), then
+// drains the rest so whatever is feeding prices can finish
+func limitPrices(prices <-chan fetcher.PriceData, limit int) <-chan fetcher.PriceData {
+	if limit <= 0 {
+		return prices
+	}
+	out := make(chan fetcher.PriceData, cap(prices))
+	go func() {
+		defer close(out)
+		sent := 0
+		for p := range prices {
+			if sent < limit {
+				out <- p
+				sent++
+			}
+		}
+	}()
+	return out
+}
+
+// collectPrices passes prices through, appending each record to *all; *all
+// is complete once the returned channel has been drained
+func collectPrices(prices <-chan fetcher.PriceData, all *[]fetcher.PriceData) <-chan fetcher.PriceData {
+	out := make(chan fetcher.PriceData, cap(prices))
+	go func() {
+		defer close(out)
+		for p := range prices {
+			*all = append(*all, p)
+			out <- p
+		}
+	}()
+	return out
+}
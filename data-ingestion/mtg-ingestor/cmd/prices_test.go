@@ -0,0 +1,147 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/mtg/mtg-ingestor/internal/fetcher"
+	"github.com/sirupsen/logrus"
+)
+
+func quietLogger() *logrus.Logger {
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+	return logger
+}
+
+// testPrices returns n distinct price records
+func testPrices(n int) []fetcher.PriceData {
+	prices := make([]fetcher.PriceData, n)
+	for i := range prices {
+		prices[i] = fetcher.PriceData{CardUUID: fmt.Sprintf("card-%d", i), Source: "tcgplayer", Date: "2024-01-01", Price: 1}
+	}
+	return prices
+}
+
+// feedPrices sends prices on a channel the way the price fetcher does
+func feedPrices(prices []fetcher.PriceData) <-chan fetcher.PriceData {
+	out := make(chan fetcher.PriceData, 64)
+	go func() {
+		defer close(out)
+		for _, p := range prices {
+			out <- p
+		}
+	}()
+	return out
+}
+
+func TestPublishPricesCountsUnderConcurrency(t *testing.T) {
+	prices := testPrices(10007)
+
+	for _, workers := range []int{1, 8, 32} {
+		for _, batchSize := range []int{1, 100} {
+			var (
+				mu   sync.Mutex
+				seen = map[string]int{}
+			)
+			published, failed, err := publishPrices(quietLogger(), feedPrices(prices), workers, batchSize, func(batch []fetcher.PriceData) error {
+				mu.Lock()
+				defer mu.Unlock()
+				for _, p := range batch {
+					seen[p.CardUUID]++
+				}
+				return nil
+			})
+			if err != nil {
+				t.Fatalf("workers=%d batch=%d: %v", workers, batchSize, err)
+			}
+			if published != len(prices) || failed != 0 || len(seen) != len(prices) {
+				t.Errorf("workers=%d batch=%d: published %d (%d failed), saw %d distinct records, want %d",
+					workers, batchSize, published, failed, len(seen), len(prices))
+			}
+			for uuid, n := range seen {
+				if n != 1 {
+					t.Errorf("workers=%d batch=%d: %s published %d times", workers, batchSize, uuid, n)
+				}
+			}
+		}
+	}
+}
+
+func TestPublishPricesAggregatesFailures(t *testing.T) {
+	prices := testPrices(1000)
+
+	var calls atomic.Int64
+	published, failed, err := publishPrices(quietLogger(), feedPrices(prices), 8, 10, func(batch []fetcher.PriceData) error {
+		// Every fourth batch fails
+		if calls.Add(1)%4 == 0 {
+			return errors.New("queue full")
+		}
+		return nil
+	})
+
+	if published != 750 || failed != 250 {
+		t.Errorf("published = %d, failed = %d, want 750 and 250", published, failed)
+	}
+	if err == nil || !strings.HasPrefix(err.Error(), "250 of 1000 price records failed") {
+		t.Fatalf("error = %v, want 250 of 1000 failed", err)
+	}
+	if n := strings.Count(err.Error(), "queue full"); n != maxReportedPriceErrors {
+		t.Errorf("error reports %d failures, want the first %d", n, maxReportedPriceErrors)
+	}
+}
+
+// BenchmarkPublishPrices measures the speedup from more workers when each
+// publish waits on the producer, as PublishPrice does when its queue is full
+func BenchmarkPublishPrices(b *testing.B) {
+	prices := testPrices(200)
+	publish := func([]fetcher.PriceData) error {
+		time.Sleep(20 * time.Microsecond)
+		return nil
+	}
+
+	for _, workers := range []int{1, 4, 16} {
+		b.Run(fmt.Sprintf("workers=%d", workers), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				if _, _, err := publishPrices(quietLogger(), feedPrices(prices), workers, 1, publish); err != nil {
+					b.Fatal(err)
+				}
+			}
+			b.ReportMetric(float64(b.N*len(prices))/b.Elapsed().Seconds(), "prices/s")
+		})
+	}
+}
+
+func TestLimitPricesDrainsTheRest(t *testing.T) {
+	prices := testPrices(100)
+	for _, tt := range []struct{ limit, want int }{{0, 100}, {10, 10}, {500, 100}} {
+		fed := make(chan fetcher.PriceData)
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			defer close(fed)
+			for _, p := range prices {
+				fed <- p
+			}
+		}()
+
+		got := 0
+		for p := range limitPrices(fed, tt.limit) {
+			if p != prices[got] {
+				t.Fatalf("limit=%d: record %d = %+v, want %+v", tt.limit, got, p, prices[got])
+			}
+			got++
+		}
+		if got != tt.want {
+			t.Errorf("limit=%d: got %d records, want %d", tt.limit, got, tt.want)
+		}
+		// The feeder only finishes if every record was received
+		<-done
+	}
+}
@@ -0,0 +1,29 @@
+package main
+
+import "fmt"
+
+// stageFlags are the command-line flags choosing which ingestion stages run
+type stageFlags struct {
+	Sets, Cards, Prices, All bool
+	SkipPrices, PricesOnly   bool
+}
+
+// selectStages works out which stages to run: all by default, or only those
+// explicitly selected, then narrowed by --skip-prices and --prices-only
+func selectStages(f stageFlags) (runSets, runCards, runPrices bool, err error) {
+	if f.SkipPrices && f.PricesOnly {
+		return false, false, false, fmt.Errorf("--skip-prices and --prices-only are mutually exclusive")
+	}
+
+	runSets, runCards, runPrices = true, true, true
+	if !f.All && (f.Sets || f.Cards || f.Prices) {
+		runSets, runCards, runPrices = f.Sets, f.Cards, f.Prices
+	}
+	if f.SkipPrices {
+		runPrices = false
+	}
+	if f.PricesOnly {
+		runSets, runCards = false, false
+	}
+	return runSets, runCards, runPrices, nil
+}
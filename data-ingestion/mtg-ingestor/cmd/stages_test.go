@@ -0,0 +1,36 @@
+package main
+
+import "testing"
+
+func TestSelectStages(t *testing.T) {
+	tests := []struct {
+		name                            string
+		flags                           stageFlags
+		wantSets, wantCards, wantPrices bool
+	}{
+		{"default runs everything", stageFlags{}, true, true, true},
+		{"skip prices", stageFlags{SkipPrices: true}, true, true, false},
+		{"prices only", stageFlags{PricesOnly: true}, false, false, true},
+		{"explicit stages", stageFlags{Sets: true, Prices: true}, true, false, true},
+		{"explicit stages without prices", stageFlags{Cards: true, Prices: true, SkipPrices: true}, false, true, false},
+		{"all overrides explicit stages", stageFlags{All: true, Cards: true}, true, true, true},
+		{"all with skip prices", stageFlags{All: true, SkipPrices: true}, true, true, false},
+	}
+
+	for _, tt := range tests {
+		sets, cards, prices, err := selectStages(tt.flags)
+		if err != nil {
+			t.Fatalf("%s: selectStages: %v", tt.name, err)
+		}
+		if sets != tt.wantSets || cards != tt.wantCards || prices != tt.wantPrices {
+			t.Errorf("%s: stages = sets %v, cards %v, prices %v, want %v, %v, %v",
+				tt.name, sets, cards, prices, tt.wantSets, tt.wantCards, tt.wantPrices)
+		}
+	}
+}
+
+func TestSelectStagesRejectsConflictingFlags(t *testing.T) {
+	if _, _, _, err := selectStages(stageFlags{SkipPrices: true, PricesOnly: true}); err == nil {
+		t.Error("selectStages accepted --skip-prices with --prices-only")
+	}
+}
@@ -0,0 +1,78 @@
+package main
+
+import (
+	"time"
+
+	"github.com/mtg/mtg-ingestor/internal/fetcher"
+	"github.com/sirupsen/logrus"
+)
+
+// stageResult is the outcome of one ingestion stage
+type stageResult struct {
+	Name       string              `json:"name"`
+	Unchanged  bool                `json:"unchanged,omitempty"`
+	FetchError string              `json:"fetch_error,omitempty"`
+	Fetch      *fetcher.FetchStats `json:"fetch,omitempty"`
+	Published  int                 `json:"published"`
+	Failed     int                 `json:"failed"`
+}
+
+// recordFetch stores and logs the fetch stats of a successful fetch
+func (r *stageResult) recordFetch(logger *logrus.Logger, stats fetcher.FetchStats) {
+	r.Fetch = &stats
+	logger.Infof("Fetched %d %s: %.1f MB downloaded (%.1f MB decompressed) in %v, parsed in %v",
+		stats.Records, r.Name, float64(stats.BytesDownloaded)/(1<<20), float64(stats.DecompressedBytes)/(1<<20),
+		stats.DownloadDuration.Round(time.Millisecond), stats.ParseDuration.Round(time.Millisecond))
+}
+
+// FailureRate is the fraction of records that failed to publish; a failed fetch counts as 1
+func (r *stageResult) FailureRate() float64 {
+	if r.FetchError != "" {
+		return 1
+	}
+	total := r.Published + r.Failed
+	if total == 0 {
+		return 0
+	}
+	return float64(r.Failed) / float64(total)
+}
+
+// runSummary aggregates stage outcomes so the driver can report and exit accordingly
+type runSummary struct {
+	Stages      []*stageResult `json:"stages"`
+	Undelivered int            `json:"undelivered"`
+	Duration    string         `json:"duration"`
+	Success     bool           `json:"success"`
+}
+
+// stage adds and returns a result for the named stage
+func (s *runSummary) stage(name string) *stageResult {
+	r := &stageResult{Name: name}
+	s.Stages = append(s.Stages, r)
+	return r
+}
+
+// complete reports whether every stage fetched and delivered all of its records,
+// the only case in which the run may be recorded as done
+func (s *runSummary) complete() bool {
+	if s.Undelivered > 0 {
+		return false
+	}
+	for _, r := range s.Stages {
+		if r.FetchError != "" || r.Failed > 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// failed reports whether any stage's failure rate reached maxFailureRate.
+// With the default of 1 only a completely failed stage fails the run.
+func (s *runSummary) failed(maxFailureRate float64) bool {
+	for _, r := range s.Stages {
+		if r.Failed+len(r.FetchError) > 0 && r.FailureRate() >= maxFailureRate {
+			return true
+		}
+	}
+	return false
+}
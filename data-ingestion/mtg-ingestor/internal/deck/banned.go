@@ -0,0 +1,177 @@
+package deck
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/mtg/mtg-ingestor/internal/models"
+)
+
+const (
+	// sixtyCardMinimum is the smallest main deck allowed in constructed formats
+	sixtyCardMinimum = 60
+	// sixtyCardMaxSideboard is the largest sideboard allowed in constructed formats
+	sixtyCardMaxSideboard = 15
+	// sixtyCardMaxCopies is how many copies of a nonbasic card a deck may run
+	sixtyCardMaxCopies = 4
+)
+
+// BannedList maps a format, e.g. "modern", to the normalized names of the
+// cards banned in it
+type BannedList map[string]map[string]bool
+
+// NewBannedList builds a banned list from card names per format, as read from config
+func NewBannedList(names map[string][]string) BannedList {
+	banned := BannedList{}
+	for format, list := range names {
+		banned.Add(format, list...)
+	}
+	return banned
+}
+
+// BannedListFromCards builds a banned list from the MTGJSON legalities of the cards
+func BannedListFromCards(cards map[string]models.Card) BannedList {
+	banned := BannedList{}
+	for _, card := range cards {
+		for format, status := range card.Legalities {
+			if strings.EqualFold(status, "banned") {
+				banned.Add(format, card.Name)
+			}
+		}
+	}
+	return banned
+}
+
+// Add bans the named cards in the format
+func (b BannedList) Add(format string, names ...string) {
+	format = strings.ToLower(format)
+	if b[format] == nil {
+		b[format] = map[string]bool{}
+	}
+	for _, name := range names {
+		b[format][NormalizeCardName(name)] = true
+	}
+}
+
+// Merge adds every ban in other to the list
+func (b BannedList) Merge(other BannedList) {
+	for format, names := range other {
+		if b[format] == nil {
+			b[format] = map[string]bool{}
+		}
+		for name := range names {
+			b[format][name] = true
+		}
+	}
+}
+
+// IsBanned reports whether the card is banned in the format
+func (b BannedList) IsBanned(format, name string) bool {
+	return b[strings.ToLower(format)][NormalizeCardName(name)]
+}
+
+// CheckBanned returns the deck's cards, main deck and sideboard, that are
+// banned in the format, each named once and sorted
+func (b BannedList) CheckBanned(deck *Deck, format string) []string {
+	seen := map[string]bool{}
+	var found []string
+	for _, dc := range append(append([]DeckCard{}, deck.Cards...), deck.Sideboard...) {
+		key := NormalizeCardName(dc.Name)
+		if seen[key] || !b.IsBanned(format, dc.Name) {
+			continue
+		}
+		seen[key] = true
+		found = append(found, dc.Name)
+	}
+	sort.Strings(found)
+	return found
+}
+
+// IsLegalSixtyCard checks the deck against the constructed rules of a format:
+// at least 60 main deck cards, at most 15 in the sideboard, no more than four
+// copies of any card but basic lands (one if restricted), and no card banned
+// or otherwise not legal in the format. banned and lookup may be nil. It
+// returns a description of each problem.
+func IsLegalSixtyCard(deck *Deck, format string, banned BannedList, lookup func(name string) (models.Card, bool)) (bool, []string) {
+	var problems []string
+
+	if deck.TotalCards < sixtyCardMinimum {
+		problems = append(problems, fmt.Sprintf("deck has %d cards, expected at least %d", deck.TotalCards, sixtyCardMinimum))
+	}
+	sideboard := 0
+	for _, dc := range deck.Sideboard {
+		sideboard += dc.Quantity
+	}
+	if sideboard > sixtyCardMaxSideboard {
+		problems = append(problems, fmt.Sprintf("sideboard has %d cards, expected at most %d", sideboard, sixtyCardMaxSideboard))
+	}
+
+	for _, name := range banned.CheckBanned(deck, format) {
+		problems = append(problems, fmt.Sprintf("%s is banned in %s", name, format))
+	}
+
+	// Copies are counted across the main deck and sideboard together
+	counts := boardCounts(append(append([]DeckCard{}, deck.Cards...), deck.Sideboard...))
+	names := make([]string, 0, len(counts))
+	for key := range counts {
+		names = append(names, key)
+	}
+	sort.Strings(names)
+	for _, key := range names {
+		entry := counts[key]
+		var card models.Card
+		known := false
+		if lookup != nil {
+			card, known = lookup(entry.Name)
+		}
+
+		limit := sixtyCardMaxCopies
+		if known && strings.EqualFold(card.Legalities[strings.ToLower(format)], "restricted") {
+			limit = 1
+		}
+		basic := isBasicLandName(entry.Name)
+		if known {
+			basic = isBasicLand(card)
+		}
+		if entry.Quantity > limit && !basic {
+			problems = append(problems, fmt.Sprintf("%s appears %d times, expected at most %d", entry.Name, entry.Quantity, limit))
+		}
+		// Banned cards were already reported above
+		if known && !card.IsLegalIn(format) && !banned.IsBanned(format, entry.Name) {
+			problems = append(problems, fmt.Sprintf("%s is not legal in %s", entry.Name, format))
+		}
+	}
+
+	return len(problems) == 0, problems
+}
+
+// isBasicLandName recognizes basic lands by name, for decks checked without card data
+func isBasicLandName(name string) bool {
+	name = strings.TrimPrefix(name, "Snow-Covered ")
+	_, ok := basicLandColors[name]
+	return ok || name == "Wastes"
+}
+
+// FormatLegality is the outcome of checking a deck against one format
+type FormatLegality struct {
+	Format   string   `json:"format"`
+	Legal    bool     `json:"legal"`
+	Problems []string `json:"problems,omitempty"`
+}
+
+// CheckLegality runs IsLegalSixtyCard for each format and records the results
+// on the deck, so they travel with its deck.ingested event
+func (d *Deck) CheckLegality(formats []string, banned BannedList, lookup func(name string) (models.Card, bool)) {
+	d.Legality = nil
+	for _, format := range formats {
+		legal, problems := IsLegalSixtyCard(d, format, banned, lookup)
+		d.Legality = append(d.Legality, FormatLegality{Format: strings.ToLower(format), Legal: legal, Problems: problems})
+	}
+}
+
+// BannedList returns the bans recorded in the legalities of the indexed cards;
+// it is empty when the index was built from names alone
+func (c *CardNameIndex) BannedList() BannedList {
+	return BannedListFromCards(c.cards)
+}
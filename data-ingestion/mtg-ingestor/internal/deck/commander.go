@@ -0,0 +1,91 @@
+package deck
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/mtg/mtg-ingestor/internal/models"
+)
+
+// commanderDeckSize is the exact card count of a Commander deck, commander included
+const commanderDeckSize = 100
+
+// ValidateColorIdentity returns the names of the deck's cards whose color
+// identity falls outside the commander's. Basic lands, colorless cards and
+// cards the lookup doesn't know are never flagged.
+func ValidateColorIdentity(deck *Deck, commander models.Card, lookup func(name string) (models.Card, bool)) []string {
+	allowed := make(map[string]bool, len(commander.ColorIdentity))
+	for _, color := range commander.ColorIdentity {
+		allowed[color] = true
+	}
+
+	var violations []string
+	seen := map[string]bool{}
+	for _, dc := range deck.Cards {
+		key := strings.ToLower(dc.Name)
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+
+		card, ok := lookup(dc.Name)
+		if !ok || isBasicLand(card) {
+			continue
+		}
+		for _, color := range card.ColorIdentity {
+			if !allowed[color] {
+				violations = append(violations, dc.Name)
+				break
+			}
+		}
+	}
+	return violations
+}
+
+// IsLegalCommander checks the deck against the Commander rules: 100 cards,
+// singleton apart from basic lands, every card legal in the format and within
+// the commander's color identity. It returns a description of each problem.
+func IsLegalCommander(deck *Deck, commander models.Card, lookup func(name string) (models.Card, bool)) (bool, []string) {
+	var problems []string
+
+	if !commander.IsLegalIn("commander") {
+		problems = append(problems, fmt.Sprintf("%s is not legal as a commander", commander.Name))
+	}
+	if deck.TotalCards != commanderDeckSize {
+		problems = append(problems, fmt.Sprintf("deck has %d cards, expected %d", deck.TotalCards, commanderDeckSize))
+	}
+
+	counts := boardCounts(deck.Cards)
+	for _, dc := range deck.Cards {
+		entry, ok := counts[strings.ToLower(dc.Name)]
+		if !ok {
+			continue
+		}
+		// Report each card once
+		delete(counts, strings.ToLower(dc.Name))
+
+		card, known := lookup(dc.Name)
+		if entry.Quantity > 1 && !(known && isBasicLand(card)) {
+			problems = append(problems, fmt.Sprintf("%s appears %d times", entry.Name, entry.Quantity))
+		}
+		if known && !card.IsLegalIn("commander") {
+			problems = append(problems, fmt.Sprintf("%s is not legal in commander", entry.Name))
+		}
+	}
+
+	for _, name := range ValidateColorIdentity(deck, commander, lookup) {
+		problems = append(problems, fmt.Sprintf("%s is outside %s's color identity", name, commander.Name))
+	}
+
+	return len(problems) == 0, problems
+}
+
+// isBasicLand reports whether the card is a basic land, which any deck may run
+func isBasicLand(card models.Card) bool {
+	for _, supertype := range card.Supertypes {
+		if supertype == "Basic" {
+			return isLand(card)
+		}
+	}
+	return false
+}
@@ -0,0 +1,97 @@
+package deck
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// DeckFormat identifies the decklist dialect a file is written in
+type DeckFormat int
+
+const (
+	// DeckFormatAuto detects the format from the file contents
+	DeckFormatAuto DeckFormat = iota
+	// DeckFormatPlain is "<quantity> <card name>" per line
+	DeckFormatPlain
+	// DeckFormatArena is an MTG Arena export: "4 Name (SET) 123" with
+	// Deck/Commander/Companion/Sideboard section headers
+	DeckFormatArena
+	// DeckFormatMTGO is an MTGO text export, marking sideboard lines with "SB:"
+	DeckFormatMTGO
+	// DeckFormatDek is MTGO's XML .dek export
+	DeckFormatDek
+)
+
+var deckFormatNames = map[DeckFormat]string{
+	DeckFormatAuto:  "auto",
+	DeckFormatPlain: "plain",
+	DeckFormatArena: "arena",
+	DeckFormatMTGO:  "mtgo",
+	DeckFormatDek:   "dek",
+}
+
+func (f DeckFormat) String() string {
+	if name, ok := deckFormatNames[f]; ok {
+		return name
+	}
+	return fmt.Sprintf("DeckFormat(%d)", int(f))
+}
+
+// ParseDeckFormat parses a format name such as "arena", e.g. from a flag
+func ParseDeckFormat(name string) (DeckFormat, error) {
+	for format, formatName := range deckFormatNames {
+		if strings.EqualFold(name, formatName) {
+			return format, nil
+		}
+	}
+	return DeckFormatAuto, fmt.Errorf("unknown deck format: %q", name)
+}
+
+// arenaSetSuffix matches the "(SET) 123" printing suffix of Arena lines
+var arenaSetSuffix = regexp.MustCompile(`\s+\([A-Za-z0-9]{2,6}\)\s+\S+$`)
+
+// arenaSections are the section headers Arena exports use besides "Sideboard"
+var arenaSections = map[string]bool{
+	"deck":      true,
+	"commander": true,
+	"companion": true,
+}
+
+// DetectFormat guesses the decklist dialect: XML means an MTGO .dek file,
+// "(SET) num" suffixes or Arena section headers mean Arena, "SB:" prefixes
+// mean MTGO, anything else is plain
+func DetectFormat(content []byte) DeckFormat {
+	if isDekContent(content) {
+		return DeckFormatDek
+	}
+
+	mtgo := false
+	scanner := bufio.NewScanner(bytes.NewReader(content))
+	scanner.Buffer(make([]byte, 0, 4096), maxLineLength)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		switch {
+		case line == "":
+		case arenaSetSuffix.MatchString(line), isArenaSection(line), isSideboardHeader(line):
+			return DeckFormatArena
+		case hasSideboardPrefix(line):
+			mtgo = true
+		}
+	}
+	if mtgo {
+		return DeckFormatMTGO
+	}
+	return DeckFormatPlain
+}
+
+func isArenaSection(line string) bool {
+	return arenaSections[strings.ToLower(strings.TrimSuffix(line, ":"))]
+}
+
+// hasSideboardPrefix reports whether a line carries MTGO's "SB:" marker
+func hasSideboardPrefix(line string) bool {
+	return len(line) >= 3 && strings.EqualFold(line[:3], "SB:")
+}
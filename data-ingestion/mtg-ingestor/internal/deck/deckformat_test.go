@@ -0,0 +1,71 @@
+package deck
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestDeckFormatFixtures(t *testing.T) {
+	main := []DeckCard{
+		{Quantity: 4, Name: "Lightning Bolt"},
+		{Quantity: 4, Name: "Monastery Swiftspear"},
+		{Quantity: 4, Name: "Lava Spike"},
+		{Quantity: 20, Name: "Mountain"},
+	}
+	sideboard := []DeckCard{{Quantity: 2, Name: "Smash to Smithereens"}}
+
+	tests := []struct {
+		fixture       string
+		wantFormat    DeckFormat
+		wantSideboard []DeckCard
+	}{
+		{"arena.deck", DeckFormatArena, sideboard},
+		{"mtgo.deck", DeckFormatMTGO, sideboard},
+		{"mtgo-xml.dek", DeckFormatDek, sideboard},
+		{"plain.deck", DeckFormatPlain, nil},
+	}
+
+	for _, tt := range tests {
+		path := filepath.Join("testdata", tt.fixture)
+		content, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatalf("reading %s: %v", path, err)
+		}
+		if got := DetectFormat(content); got != tt.wantFormat {
+			t.Errorf("%s: DetectFormat = %s, want %s", tt.fixture, got, tt.wantFormat)
+		}
+
+		d, err := newTestIngester().IngestFile(path)
+		if err != nil {
+			t.Fatalf("%s: IngestFile: %v", tt.fixture, err)
+		}
+		if d.ParseReport.HasIssues() {
+			t.Errorf("%s: parse issues %+v", tt.fixture, d.ParseReport.Issues)
+		}
+		if got := cardLines(d.Cards); !reflect.DeepEqual(got, main) {
+			t.Errorf("%s: cards = %+v, want %+v", tt.fixture, got, main)
+		}
+		if got := cardLines(d.Sideboard); !reflect.DeepEqual(got, tt.wantSideboard) {
+			t.Errorf("%s: sideboard = %+v, want %+v", tt.fixture, got, tt.wantSideboard)
+		}
+		if d.TotalCards != 32 {
+			t.Errorf("%s: TotalCards = %d, want 32", tt.fixture, d.TotalCards)
+		}
+	}
+}
+
+func TestParseDeckFormat(t *testing.T) {
+	for _, format := range []DeckFormat{DeckFormatAuto, DeckFormatPlain, DeckFormatArena, DeckFormatMTGO, DeckFormatDek} {
+		if got, err := ParseDeckFormat(format.String()); err != nil || got != format {
+			t.Errorf("ParseDeckFormat(%q) = %s, %v", format.String(), got, err)
+		}
+	}
+	if got, err := ParseDeckFormat("ARENA"); err != nil || got != DeckFormatArena {
+		t.Errorf("ParseDeckFormat is case sensitive: %s, %v", got, err)
+	}
+	if _, err := ParseDeckFormat("cockatrice"); err == nil {
+		t.Error("ParseDeckFormat accepted an unknown format")
+	}
+}
@@ -0,0 +1,64 @@
+package deck
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// utf8BOM prefixes the .dek files MTGO writes
+var utf8BOM = []byte("\xef\xbb\xbf")
+
+// dekFile is the XML layout of an MTGO .dek export
+type dekFile struct {
+	XMLName xml.Name  `xml:"Deck"`
+	Cards   []dekCard `xml:"Cards"`
+}
+
+type dekCard struct {
+	CatID     string `xml:"CatID,attr"`
+	Quantity  string `xml:"Quantity,attr"`
+	Sideboard string `xml:"Sideboard,attr"`
+	Name      string `xml:"Name,attr"`
+}
+
+// isDekContent reports whether content looks like an MTGO .dek XML file
+func isDekContent(content []byte) bool {
+	trimmed := bytes.TrimSpace(bytes.TrimPrefix(content, utf8BOM))
+	return bytes.HasPrefix(trimmed, []byte("<?xml")) || bytes.HasPrefix(trimmed, []byte("<Deck"))
+}
+
+// parseDek parses an MTGO .dek XML file into deck; Sideboard="true" cards
+// go to the sideboard. Issues are reported by card element index.
+func (i *Ingester) parseDek(deck *Deck, content []byte) error {
+	var file dekFile
+	if err := xml.Unmarshal(bytes.TrimPrefix(content, utf8BOM), &file); err != nil {
+		return fmt.Errorf("failed to parse .dek file: %w", err)
+	}
+
+	for n, c := range file.Cards {
+		entry := n + 1
+		text := fmt.Sprintf(`Quantity=%q Name=%q`, c.Quantity, c.Name)
+
+		quantity, err := strconv.Atoi(strings.TrimSpace(c.Quantity))
+		if err != nil || quantity <= 0 {
+			i.logger.Warnf("%s: card %d: invalid quantity: %s", deck.FilePath, entry, text)
+			deck.ParseReport.add(entry, text, "invalid quantity")
+			deck.MalformedLines = append(deck.MalformedLines, text)
+			continue
+		}
+		name := sanitizeCardName(c.Name)
+		if name == "" {
+			i.logger.Warnf("%s: card %d: missing card name", deck.FilePath, entry)
+			deck.ParseReport.add(entry, text, "missing card name")
+			deck.MalformedLines = append(deck.MalformedLines, text)
+			continue
+		}
+
+		card := DeckCard{Quantity: quantity, Name: name, MTGOCatID: c.CatID}
+		i.addCard(deck, entry, card, strings.EqualFold(c.Sideboard, "true"))
+	}
+	return nil
+}
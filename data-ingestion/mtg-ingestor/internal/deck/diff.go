@@ -0,0 +1,100 @@
+package deck
+
+import (
+	"sort"
+	"strings"
+)
+
+// QuantityChange is a card present in both decks with a different count
+type QuantityChange struct {
+	Name  string `json:"name"`
+	From  int    `json:"from"`
+	To    int    `json:"to"`
+	Delta int    `json:"delta"`
+}
+
+// BoardDiff lists the changes to one board (main deck or sideboard)
+type BoardDiff struct {
+	Added   []DeckCard       `json:"added,omitempty"`
+	Removed []DeckCard       `json:"removed,omitempty"`
+	Changed []QuantityChange `json:"changed,omitempty"`
+}
+
+// IsEmpty reports whether the board is unchanged
+func (b BoardDiff) IsEmpty() bool {
+	return len(b.Added) == 0 && len(b.Removed) == 0 && len(b.Changed) == 0
+}
+
+// DeckDiff describes how deck b differs from deck a
+type DeckDiff struct {
+	Main      BoardDiff `json:"main"`
+	Sideboard BoardDiff `json:"sideboard"`
+}
+
+// IsEmpty reports whether both decks have the same cards
+func (d DeckDiff) IsEmpty() bool {
+	return d.Main.IsEmpty() && d.Sideboard.IsEmpty()
+}
+
+// DiffDecks compares two decks board by board; card names match case-insensitively
+// and each list is sorted by card name
+func DiffDecks(a, b *Deck) DeckDiff {
+	return DeckDiff{
+		Main:      diffBoard(a.Cards, b.Cards),
+		Sideboard: diffBoard(a.Sideboard, b.Sideboard),
+	}
+}
+
+func diffBoard(from, to []DeckCard) BoardDiff {
+	before := boardCounts(from)
+	after := boardCounts(to)
+
+	var diff BoardDiff
+	for key, old := range before {
+		current, ok := after[key]
+		switch {
+		case !ok:
+			diff.Removed = append(diff.Removed, old)
+		case current.Quantity != old.Quantity:
+			diff.Changed = append(diff.Changed, QuantityChange{
+				Name:  current.Name,
+				From:  old.Quantity,
+				To:    current.Quantity,
+				Delta: current.Quantity - old.Quantity,
+			})
+		}
+	}
+	for key, current := range after {
+		if _, ok := before[key]; !ok {
+			diff.Added = append(diff.Added, current)
+		}
+	}
+
+	sortCards(diff.Added)
+	sortCards(diff.Removed)
+	sort.Slice(diff.Changed, func(i, j int) bool {
+		return strings.ToLower(diff.Changed[i].Name) < strings.ToLower(diff.Changed[j].Name)
+	})
+	return diff
+}
+
+// boardCounts merges repeated lines for the same card
+func boardCounts(cards []DeckCard) map[string]DeckCard {
+	counts := make(map[string]DeckCard, len(cards))
+	for _, card := range cards {
+		key := strings.ToLower(card.Name)
+		entry, ok := counts[key]
+		if !ok {
+			entry.Name = card.Name
+		}
+		entry.Quantity += card.Quantity
+		counts[key] = entry
+	}
+	return counts
+}
+
+func sortCards(cards []DeckCard) {
+	sort.Slice(cards, func(i, j int) bool {
+		return strings.ToLower(cards[i].Name) < strings.ToLower(cards[j].Name)
+	})
+}
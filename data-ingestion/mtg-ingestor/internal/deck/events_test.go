@@ -0,0 +1,74 @@
+package deck
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestDeckIDIsStable(t *testing.T) {
+	ingest := func(content string) *Deck {
+		t.Helper()
+		d, err := newTestIngester().IngestReader(strings.NewReader(content), "burn.deck")
+		if err != nil {
+			t.Fatalf("IngestReader: %v", err)
+		}
+		return d
+	}
+
+	d := ingest("4 Lightning Bolt\n20 Mountain\n")
+	if reordered := ingest("20 mountain\n4 Lightning Bolt\n"); reordered.ID != d.ID {
+		t.Errorf("reordering and recasing cards changed the deck ID: %s != %s", reordered.ID, d.ID)
+	}
+	if changed := ingest("3 Lightning Bolt\n20 Mountain\n"); changed.ID == d.ID {
+		t.Error("changing a quantity kept the deck ID")
+	}
+	if withSideboard := ingest("4 Lightning Bolt\n20 Mountain\nSideboard\n4 Lightning Bolt\n"); withSideboard.ID == d.ID {
+		t.Error("adding a sideboard kept the deck ID")
+	}
+
+	other, err := newTestIngester().IngestReader(strings.NewReader("4 Lightning Bolt\n20 Mountain\n"), "other.deck")
+	if err != nil {
+		t.Fatalf("IngestReader: %v", err)
+	}
+	if other.ID == d.ID {
+		t.Error("decks from different files share an ID")
+	}
+}
+
+func TestDeckEventsAreIdempotent(t *testing.T) {
+	ingester := newTestIngester()
+	d, err := ingester.IngestReader(strings.NewReader("4 Lightning Bolt\n20 Mountain\n"), "burn.deck")
+	if err != nil {
+		t.Fatalf("IngestReader: %v", err)
+	}
+
+	first, second := ingester.CreateDeckEvent(d), ingester.CreateDeckEvent(d)
+	if first.EventID != second.EventID {
+		t.Errorf("deck event IDs differ between runs: %s != %s", first.EventID, second.EventID)
+	}
+	if analyzed := ingester.CreateDeckAnalyzedEvent(d, DeckStats{}); analyzed.EventID == first.EventID {
+		t.Error("deck.analyzed event shares the deck.ingested event ID")
+	}
+
+	cards, again := ingester.CreateDeckCardEvents(d), ingester.CreateDeckCardEvents(d)
+	if len(cards) != 2 || len(again) != 2 {
+		t.Fatalf("card events = %d and %d, want 2", len(cards), len(again))
+	}
+	for i := range cards {
+		if cards[i].EventID != again[i].EventID {
+			t.Errorf("card event %d ID differs between runs", i)
+		}
+	}
+	if cards[0].EventID == cards[1].EventID {
+		t.Error("card events of different cards share an ID")
+	}
+}
+
+func TestDeckCardKey(t *testing.T) {
+	if got := DeckCardKey("deck-1", "Lightning Bolt"); got != "deck-1:lightning bolt" {
+		t.Errorf("DeckCardKey = %q, want deck-1:lightning bolt", got)
+	}
+	if DeckCardKey("deck-1", "LIGHTNING BOLT") != DeckCardKey("deck-1", "lightning bolt") {
+		t.Error("DeckCardKey depends on card name casing")
+	}
+}
@@ -0,0 +1,174 @@
+package deck
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+
+	"github.com/mtg/mtg-ingestor/internal/models"
+)
+
+// typeGroups is the order card types are listed in exports; a card is grouped
+// under the first type it has, so an Artifact Creature is listed as a Creature
+var typeGroups = []string{"Creature", "Planeswalker", "Instant", "Sorcery", "Artifact", "Enchantment", "Battle", "Land"}
+
+// otherGroup holds cards that couldn't be resolved or have no listed type
+const otherGroup = "Other"
+
+// PriceLookup is optionally implemented by a CardLookup to include prices in exports
+type PriceLookup interface {
+	Price(name string) (float64, bool)
+}
+
+// TypeGroup is a set of deck cards sharing a primary card type
+type TypeGroup struct {
+	Type  string
+	Cards []DeckCard
+	Count int
+}
+
+// GroupByType buckets the deck's cards by primary type in typeGroups order,
+// with unresolved cards in a trailing "Other" group
+func (d *Deck) GroupByType(index CardLookup) []TypeGroup {
+	buckets := map[string]*TypeGroup{}
+	for _, dc := range d.Cards {
+		group := otherGroup
+		if card, ok := index.Lookup(dc.Name); ok {
+			group = primaryType(card)
+		}
+
+		b, ok := buckets[group]
+		if !ok {
+			b = &TypeGroup{Type: group}
+			buckets[group] = b
+		}
+		b.Cards = append(b.Cards, dc)
+		b.Count += dc.Quantity
+	}
+
+	var groups []TypeGroup
+	for _, t := range append(typeGroups, otherGroup) {
+		if b, ok := buckets[t]; ok {
+			sort.Slice(b.Cards, func(i, j int) bool { return b.Cards[i].Name < b.Cards[j].Name })
+			groups = append(groups, *b)
+		}
+	}
+	return groups
+}
+
+// primaryType returns the first of typeGroups the card has, or "Other"
+func primaryType(card models.Card) string {
+	for _, t := range typeGroups {
+		if hasType(card, t) {
+			return t
+		}
+	}
+	return otherGroup
+}
+
+// ExportMarkdown renders the deck as a Markdown list grouped by card type,
+// suitable for pasting into issues or forum posts. Prices are included when
+// the index also implements PriceLookup.
+func (d *Deck) ExportMarkdown(index CardLookup) []byte {
+	prices, withPrices := index.(PriceLookup)
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "# %s\n\n", d.Name)
+	fmt.Fprintf(&buf, "**%d cards** (%d unique)\n", d.TotalCards, d.UniqueCards)
+
+	total := 0.0
+	for _, group := range d.GroupByType(index) {
+		fmt.Fprintf(&buf, "\n## %s (%d)\n\n", group.Type, group.Count)
+		for _, dc := range group.Cards {
+			if withPrices {
+				if price, ok := prices.Price(dc.Name); ok {
+					fmt.Fprintf(&buf, "- %dx %s ($%.2f)\n", dc.Quantity, dc.Name, price)
+					total += price * float64(dc.Quantity)
+					continue
+				}
+			}
+			fmt.Fprintf(&buf, "- %dx %s\n", dc.Quantity, dc.Name)
+		}
+	}
+
+	if withPrices {
+		fmt.Fprintf(&buf, "\n**Total value:** $%.2f\n", total)
+	}
+
+	return buf.Bytes()
+}
+
+// ExportDecks writes decks to w as "json", "csv" (deck_name, card_name,
+// quantity, board) or "txt", a canonical deck list with one "=== Name ==="
+// banner per deck that IngestMultiFile parses back to the same decks
+func ExportDecks(decks []Deck, format string, w io.Writer) error {
+	switch format {
+	case "json":
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(decks)
+	case "csv":
+		return exportCSV(decks, w)
+	case "txt":
+		return exportText(decks, w)
+	default:
+		return fmt.Errorf("unsupported export format: %s", format)
+	}
+}
+
+func exportCSV(decks []Deck, w io.Writer) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write([]string{"deck_name", "card_name", "quantity", "board"}); err != nil {
+		return err
+	}
+	for _, d := range decks {
+		for _, card := range d.Cards {
+			record := []string{d.Name, card.Name, strconv.Itoa(card.Quantity), "main"}
+			if err := cw.Write(record); err != nil {
+				return err
+			}
+		}
+		for _, card := range d.Sideboard {
+			record := []string{d.Name, card.Name, strconv.Itoa(card.Quantity), "side"}
+			if err := cw.Write(record); err != nil {
+				return err
+			}
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+func exportText(decks []Deck, w io.Writer) error {
+	for i, d := range decks {
+		if i > 0 {
+			if _, err := fmt.Fprintln(w); err != nil {
+				return err
+			}
+		}
+		// A banner ends the previous deck's sideboard and names the next deck
+		if _, err := fmt.Fprintf(w, "=== %s ===\n", d.Name); err != nil {
+			return err
+		}
+		for _, card := range d.Cards {
+			if _, err := fmt.Fprintf(w, "%d %s\n", card.Quantity, card.Name); err != nil {
+				return err
+			}
+		}
+		if len(d.Sideboard) > 0 {
+			if _, err := fmt.Fprintln(w, "Sideboard"); err != nil {
+				return err
+			}
+			for _, card := range d.Sideboard {
+				if _, err := fmt.Fprintf(w, "%d %s\n", card.Quantity, card.Name); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,155 @@
+package deck
+
+import (
+	"bytes"
+	"flag"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/mtg/mtg-ingestor/internal/models"
+)
+
+// cardLines reduces cards to what a text export preserves
+func cardLines(cards []DeckCard) []DeckCard {
+	var lines []DeckCard
+	for _, card := range cards {
+		lines = append(lines, DeckCard{Quantity: card.Quantity, Name: card.Name})
+	}
+	return lines
+}
+
+func TestExportTextRoundTripsMultipleDecks(t *testing.T) {
+	decks := []Deck{
+		{
+			Name:      "Mono Red Burn",
+			Cards:     []DeckCard{{Quantity: 4, Name: "Lightning Bolt"}, {Quantity: 20, Name: "Mountain"}},
+			Sideboard: []DeckCard{{Quantity: 2, Name: "Smash to Smithereens"}},
+		},
+		{
+			Name:  "Elves",
+			Cards: []DeckCard{{Quantity: 4, Name: "Llanowar Elves"}, {Quantity: 18, Name: "Forest"}},
+		},
+		{
+			Name:      "Fire Ice",
+			Cards:     []DeckCard{{Quantity: 4, Name: "Fire // Ice"}},
+			Sideboard: []DeckCard{{Quantity: 1, Name: "Pyroblast"}},
+		},
+	}
+
+	var buf bytes.Buffer
+	if err := ExportDecks(decks, "txt", &buf); err != nil {
+		t.Fatalf("ExportDecks: %v", err)
+	}
+	parsed, err := newTestIngester().IngestMultiFile(writeDeckFile(t, "export.deck", buf.String()))
+	if err != nil {
+		t.Fatalf("IngestMultiFile: %v\n%s", err, buf.String())
+	}
+
+	if len(parsed) != len(decks) {
+		t.Fatalf("re-parsed %d decks, want %d:\n%s", len(parsed), len(decks), buf.String())
+	}
+	for n, want := range decks {
+		got := parsed[n]
+		if got.Name != want.Name {
+			t.Errorf("deck %d name = %q, want %q", n+1, got.Name, want.Name)
+		}
+		if !reflect.DeepEqual(cardLines(got.Cards), want.Cards) {
+			t.Errorf("deck %d cards = %v, want %v", n+1, cardLines(got.Cards), want.Cards)
+		}
+		if !reflect.DeepEqual(cardLines(got.Sideboard), want.Sideboard) {
+			t.Errorf("deck %d sideboard = %v, want %v", n+1, cardLines(got.Sideboard), want.Sideboard)
+		}
+		if got.ParseReport.HasIssues() {
+			t.Errorf("deck %d issues: %+v", n+1, got.ParseReport.Issues)
+		}
+	}
+}
+
+func TestExportTextSingleDeckWithIngestFile(t *testing.T) {
+	d := Deck{Name: "Burn", Cards: []DeckCard{{Quantity: 4, Name: "Lightning Bolt"}}}
+
+	var buf bytes.Buffer
+	if err := ExportDecks([]Deck{d}, "txt", &buf); err != nil {
+		t.Fatalf("ExportDecks: %v", err)
+	}
+	got, err := newTestIngester().IngestFile(writeDeckFile(t, "burn.deck", buf.String()))
+	if err != nil {
+		t.Fatalf("IngestFile: %v", err)
+	}
+	if got.Name != d.Name || !reflect.DeepEqual(cardLines(got.Cards), d.Cards) {
+		t.Errorf("IngestFile = %q %v, want %q %v", got.Name, cardLines(got.Cards), d.Name, d.Cards)
+	}
+}
+
+func TestExportDecksUnsupportedFormat(t *testing.T) {
+	if err := ExportDecks(nil, "xml", &bytes.Buffer{}); err == nil {
+		t.Error("ExportDecks accepted an unsupported format")
+	}
+}
+
+// pricedIndex adds a PriceLookup to a card index
+type pricedIndex struct {
+	*CardNameIndex
+	prices map[string]float64
+}
+
+func (p pricedIndex) Price(name string) (float64, bool) {
+	price, ok := p.prices[name]
+	return price, ok
+}
+
+var updateGolden = flag.Bool("update", false, "rewrite golden files in testdata")
+
+func TestExportMarkdownGolden(t *testing.T) {
+	index := testIndex(
+		models.Card{Name: "Goblin Guide", Types: []string{"Creature"}},
+		models.Card{Name: "Ornithopter", Types: []string{"Artifact", "Creature"}},
+		models.Card{Name: "Lightning Bolt", Types: []string{"Instant"}},
+		models.Card{Name: "Lava Spike", Types: []string{"Sorcery"}},
+		models.Card{Name: "Mountain", Types: []string{"Land"}},
+	)
+	d := &Deck{
+		Name: "Mono Red Burn",
+		Cards: []DeckCard{
+			{Quantity: 4, Name: "Lightning Bolt"},
+			{Quantity: 4, Name: "Goblin Guide"},
+			{Quantity: 2, Name: "Ornithopter"},
+			{Quantity: 4, Name: "Lava Spike"},
+			{Quantity: 18, Name: "Mountain"},
+			{Quantity: 1, Name: "Unknown Card"},
+		},
+		TotalCards:  33,
+		UniqueCards: 6,
+	}
+
+	tests := []struct {
+		golden string
+		index  CardLookup
+	}{
+		{"burn.md", index},
+		{"burn-priced.md", pricedIndex{index, map[string]float64{
+			"Lightning Bolt": 1.25,
+			"Goblin Guide":   2.5,
+			"Mountain":       0.1,
+		}}},
+	}
+
+	for _, tt := range tests {
+		got := d.ExportMarkdown(tt.index)
+		path := filepath.Join("testdata", tt.golden)
+		if *updateGolden {
+			if err := os.WriteFile(path, got, 0644); err != nil {
+				t.Fatalf("writing %s: %v", path, err)
+			}
+		}
+		want, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatalf("reading %s: %v", path, err)
+		}
+		if !bytes.Equal(got, want) {
+			t.Errorf("ExportMarkdown does not match %s (run with -update to rewrite it):\n%s", path, got)
+		}
+	}
+}
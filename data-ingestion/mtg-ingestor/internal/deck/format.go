@@ -0,0 +1,64 @@
+package deck
+
+import "github.com/mtg/mtg-ingestor/internal/models"
+
+// CardLookup resolves deck card names to full card data
+type CardLookup interface {
+	Lookup(name string) (models.Card, bool)
+}
+
+// formatsByRestrictiveness lists constructed formats from most to least restrictive
+var formatsByRestrictiveness = []string{"standard", "pioneer", "modern", "legacy", "vintage"}
+
+// InferFormat picks the most restrictive format in which every nonland card is legal.
+// Confidence is the fraction of nonland cards found in the index; when some cards
+// are unknown the broadest matching format is returned instead.
+func (d *Deck) InferFormat(index CardLookup) (string, float64) {
+	var known []models.Card
+	nonland := 0
+	for _, dc := range d.Cards {
+		card, ok := index.Lookup(dc.Name)
+		if ok && isLand(card) {
+			continue
+		}
+		nonland++
+		if ok {
+			known = append(known, card)
+		}
+	}
+
+	if len(known) == 0 {
+		return "", 0
+	}
+
+	var matching []string
+	for _, format := range formatsByRestrictiveness {
+		if allLegalIn(known, format) {
+			matching = append(matching, format)
+		}
+	}
+	if len(matching) == 0 {
+		return "", 0
+	}
+
+	confidence := float64(len(known)) / float64(nonland)
+	if confidence < 1 {
+		return matching[len(matching)-1], confidence
+	}
+	return matching[0], confidence
+}
+
+// allLegalIn reports whether every card is legal (or restricted) in the format
+func allLegalIn(cards []models.Card, format string) bool {
+	for _, card := range cards {
+		if !card.IsLegalIn(format) {
+			return false
+		}
+	}
+	return true
+}
+
+// isLand reports whether the card has the Land type
+func isLand(card models.Card) bool {
+	return hasType(card, "Land")
+}
@@ -0,0 +1,58 @@
+package deck
+
+import (
+	"testing"
+
+	"github.com/mtg/mtg-ingestor/internal/models"
+)
+
+// testIndex builds a card index the way the deck ingester does from atomic cards
+func testIndex(cards ...models.Card) *CardNameIndex {
+	byKey := make(map[string]models.Card, len(cards))
+	for _, card := range cards {
+		byKey[card.Name] = card
+	}
+	return NewCardNameIndexFromCards(byKey)
+}
+
+// legalIn returns a legalities map marking the card legal in each format
+func legalIn(formats ...string) map[string]string {
+	legalities := map[string]string{}
+	for _, format := range formats {
+		legalities[format] = "Legal"
+	}
+	return legalities
+}
+
+func TestInferFormat(t *testing.T) {
+	index := testIndex(
+		models.Card{Name: "Lightning Bolt", Types: []string{"Instant"}, Legalities: legalIn("modern", "legacy", "vintage")},
+		models.Card{Name: "Play with Fire", Types: []string{"Instant"}, Legalities: legalIn("standard", "pioneer", "modern", "legacy", "vintage")},
+		models.Card{Name: "Mountain", Types: []string{"Land"}},
+		models.Card{Name: "Black Lotus", Types: []string{"Artifact"}, Legalities: map[string]string{"vintage": "Restricted"}},
+	)
+
+	tests := []struct {
+		name           string
+		cards          []string
+		wantFormat     string
+		wantConfidence float64
+	}{
+		{"standard legal", []string{"Play with Fire", "Mountain"}, "standard", 1},
+		{"most restrictive shared format", []string{"Play with Fire", "Lightning Bolt", "Mountain"}, "modern", 1},
+		{"restricted counts as legal", []string{"Black Lotus", "Lightning Bolt"}, "vintage", 1},
+		{"unknown cards fall back to the broadest format", []string{"Play with Fire", "Unknown Card"}, "vintage", 0.5},
+		{"only lands", []string{"Mountain"}, "", 0},
+	}
+
+	for _, tt := range tests {
+		d := &Deck{}
+		for _, name := range tt.cards {
+			d.Cards = append(d.Cards, DeckCard{Quantity: 4, Name: name})
+		}
+		format, confidence := d.InferFormat(index)
+		if format != tt.wantFormat || confidence != tt.wantConfidence {
+			t.Errorf("%s: InferFormat = %q, %v, want %q, %v", tt.name, format, confidence, tt.wantFormat, tt.wantConfidence)
+		}
+	}
+}
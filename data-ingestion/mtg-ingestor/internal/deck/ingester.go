@@ -2,23 +2,37 @@ package deck
 
 import (
 	"bufio"
+	"bytes"
 	"encoding/json"
+	"errors"
 	"fmt"
-	"os"
+	"io"
+	"io/fs"
 	"path/filepath"
 	"regexp"
+	"sort"
 	"strconv"
 	"strings"
 	"time"
+	"unicode"
+	"unicode/utf8"
 
 	"github.com/google/uuid"
 	"github.com/sirupsen/logrus"
+	"golang.org/x/text/cases"
+	"golang.org/x/text/language"
 )
 
 // DeckCard represents a card in a deck
 type DeckCard struct {
 	Quantity int    `json:"quantity"`
 	Name     string `json:"name"`
+	// NormalizedName is the name as matched against card data, see NormalizeCardName
+	NormalizedName string `json:"normalized_name,omitempty"`
+	// Alchemy marks Arena's rebalanced "A-" versions of a card
+	Alchemy bool `json:"alchemy,omitempty"`
+	// MTGOCatID is the MTGO catalog ID from .dek files
+	MTGOCatID string `json:"mtgo_cat_id,omitempty"`
 }
 
 // Deck represents a complete deck
@@ -26,12 +40,41 @@ type Deck struct {
 	ID          string      `json:"id"`
 	Name        string      `json:"name"`
 	FilePath    string      `json:"file_path"`
+	Category    string      `json:"category,omitempty"`
 	Cards       []DeckCard  `json:"cards"`
+	Sideboard   []DeckCard  `json:"sideboard,omitempty"`
 	TotalCards  int         `json:"total_cards"`
 	UniqueCards int         `json:"unique_cards"`
+	UnknownCards []string   `json:"unknown_cards,omitempty"`
+	// MalformedLines are lines that looked like cards but had no valid quantity or name
+	MalformedLines []string `json:"malformed_lines,omitempty"`
+	ParseReport ParseReport `json:"parse_report"`
+	// Legality holds the result of each format the deck was checked against
+	Legality []FormatLegality `json:"legality,omitempty"`
 	IngestedAt  time.Time   `json:"ingested_at"`
 }
 
+// ParseIssue describes a deck file line that could not be used
+type ParseIssue struct {
+	Line   int    `json:"line"`
+	Text   string `json:"text"`
+	Reason string `json:"reason"`
+}
+
+// ParseReport collects the lines skipped while parsing a deck file
+type ParseReport struct {
+	Issues []ParseIssue `json:"issues,omitempty"`
+}
+
+// HasIssues reports whether any line was skipped
+func (r ParseReport) HasIssues() bool {
+	return len(r.Issues) > 0
+}
+
+func (r *ParseReport) add(line int, text, reason string) {
+	r.Issues = append(r.Issues, ParseIssue{Line: line, Text: text, Reason: reason})
+}
+
 // DeckEvent represents a deck event for Kafka
 type DeckEvent struct {
 	EventType string      `json:"eventType"`
@@ -44,13 +87,37 @@ type DeckEvent struct {
 
 // Ingester handles deck file ingestion
 type Ingester struct {
-	logger *logrus.Logger
+	logger    *logrus.Logger
+	validator CardNameValidator
+
+	// Recursive makes IngestDirectory descend into subdirectories
+	Recursive bool
+	// Format overrides decklist format detection; DeckFormatAuto detects it per file
+	Format DeckFormat
+	// NameDirective is the comment prefix of a line naming the deck, e.g.
+	// "// NAME: Mono Red Aggro"; decks without one are named after their file
+	NameDirective string
 }
 
+// DefaultNameDirective is the comment prefix that names a deck inside its file
+const DefaultNameDirective = "// NAME:"
+
 // NewIngester creates a new deck ingester
 func NewIngester(logger *logrus.Logger) *Ingester {
 	return &Ingester{
-		logger: logger,
+		logger:        logger,
+		Recursive:     true,
+		NameDirective: DefaultNameDirective,
+	}
+}
+
+// NewIngesterWithValidator creates a deck ingester that checks card names against a validator
+func NewIngesterWithValidator(logger *logrus.Logger, validator CardNameValidator) *Ingester {
+	return &Ingester{
+		logger:        logger,
+		validator:     validator,
+		Recursive:     true,
+		NameDirective: DefaultNameDirective,
 	}
 }
 
@@ -58,52 +125,165 @@ func NewIngester(logger *logrus.Logger) *Ingester {
 func (i *Ingester) IngestDirectory(dirPath string) ([]Deck, error) {
 	var decks []Deck
 
-	files, err := filepath.Glob(filepath.Join(dirPath, "*.deck"))
+	files, err := FindDeckFiles(dirPath, i.Recursive)
 	if err != nil {
-		return nil, fmt.Errorf("failed to list deck files: %w", err)
-	}
-
-	// Also check for .txt deck files
-	txtFiles, err := filepath.Glob(filepath.Join(dirPath, "*.deck.txt"))
-	if err == nil {
-		files = append(files, txtFiles...)
+		return nil, err
 	}
 
 	i.logger.Infof("Found %d deck files to process", len(files))
 
 	for _, filePath := range files {
-		deck, err := i.IngestFile(filePath)
+		fileDecks, err := i.IngestMultiFile(filePath)
 		if err != nil {
 			i.logger.WithError(err).Errorf("Failed to ingest deck file: %s", filePath)
 			continue
 		}
-		decks = append(decks, *deck)
+		for _, deck := range fileDecks {
+			deck.Category = Category(dirPath, filePath)
+			decks = append(decks, *deck)
+		}
 	}
 
 	return decks, nil
 }
 
-// IngestFile processes a single deck file
+// IsDeckFile reports whether a file name has a deck file extension
+func IsDeckFile(name string) bool {
+	return strings.HasSuffix(name, ".deck") || strings.HasSuffix(name, ".deck.txt") ||
+		strings.HasSuffix(name, ".dek")
+}
+
+// FindDeckFiles lists .deck, .deck.txt and MTGO .dek files in dirPath,
+// descending into subdirectories when recursive is set
+func FindDeckFiles(dirPath string, recursive bool) ([]string, error) {
+	var files []string
+	err := filepath.WalkDir(dirPath, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			if path != dirPath && !recursive {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if IsDeckFile(d.Name()) {
+			files = append(files, path)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list deck files: %w", err)
+	}
+	return files, nil
+}
+
+// Category returns the deck file's subdirectory relative to root, e.g.
+// "standard" for decks/standard/mono-red.deck, or "" at the top level
+func Category(root, filePath string) string {
+	rel, err := filepath.Rel(root, filepath.Dir(filePath))
+	if err != nil || rel == "." {
+		return ""
+	}
+	return filepath.ToSlash(rel)
+}
+
+// maxLineLength bounds a single deck line so oversized input fails cleanly
+const maxLineLength = 64 * 1024
+
+var cardRegex = regexp.MustCompile(`^(\d+)\s+(.+)$`)
+
+// quantityOnly matches a line with a quantity but no card name, e.g. "4"
+var quantityOnly = regexp.MustCompile(`^\d+$`)
+
+// ErrEmptyDeck is returned for a deck file without a single card, e.g. one
+// that is all comments or whose lines are all malformed
+var ErrEmptyDeck = errors.New("deck has no cards")
+
+// IngestFile processes a single deck file. A file holding several decks is
+// an error; use IngestMultiFile for those.
 func (i *Ingester) IngestFile(filePath string) (*Deck, error) {
-	file, err := os.Open(filePath)
+	decks, err := i.IngestMultiFile(filePath)
+	if err != nil {
+		return nil, err
+	}
+	if len(decks) > 1 {
+		return nil, fmt.Errorf("%s holds %d decks, expected one", filePath, len(decks))
+	}
+	return decks[0], nil
+}
+
+// IngestReader parses a deck from r; filePath names the deck and is used in messages
+func (i *Ingester) IngestReader(r io.Reader, filePath string) (*Deck, error) {
+	content, err := io.ReadAll(r)
 	if err != nil {
-		return nil, fmt.Errorf("failed to open file: %w", err)
+		return nil, fmt.Errorf("error reading file: %w", err)
 	}
-	defer file.Close()
+	name := extractDeckName(filePath)
+	if directive, ok := findNameDirective(content, i.NameDirective); ok {
+		name = directive
+	}
+	return i.parseDeck(content, filePath, name, filePath, 0)
+}
 
+// parseDeck parses one deck's content. idKey distinguishes the decks of a
+// multi-deck file in their IDs; for single decks it is the file path.
+// lineOffset is added to text line numbers when content starts mid-file.
+func (i *Ingester) parseDeck(content []byte, filePath, name, idKey string, lineOffset int) (*Deck, error) {
 	deck := &Deck{
-		ID:         uuid.New().String(),
-		Name:       extractDeckName(filePath),
+		Name:       name,
 		FilePath:   filePath,
 		Cards:      []DeckCard{},
 		IngestedAt: time.Now(),
 	}
 
-	scanner := bufio.NewScanner(file)
-	cardRegex := regexp.MustCompile(`^(\d+)\s+(.+)$`)
+	var err error
+	format := i.Format
+	if format == DeckFormatAuto {
+		format = DetectFormat(content)
+		i.logger.Debugf("%s: detected %s deck format", filePath, format)
+	}
+
+	if format == DeckFormatDek {
+		err = i.parseDek(deck, content)
+	} else {
+		err = i.parseText(deck, content, format, lineOffset)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if len(deck.Cards) == 0 && len(deck.Sideboard) == 0 {
+		if len(deck.MalformedLines) > 0 {
+			return nil, fmt.Errorf("%w: %d malformed lines, first: %q", ErrEmptyDeck, len(deck.MalformedLines), deck.MalformedLines[0])
+		}
+		return nil, ErrEmptyDeck
+	}
+
 	totalCards := 0
+	for _, card := range deck.Cards {
+		totalCards += card.Quantity
+	}
+
+	deck.ID = deckID(idKey, deck.Cards, deck.Sideboard)
+	deck.TotalCards = totalCards
+	deck.UniqueCards = len(deck.Cards)
+
+	i.logger.Infof("Ingested deck '%s': %d unique cards, %d total cards", 
+		deck.Name, deck.UniqueCards, deck.TotalCards)
+
+	return deck, nil
+}
+
+// parseText parses a plain, Arena or MTGO text decklist into deck, numbering
+// lines from lineOffset+1
+func (i *Ingester) parseText(deck *Deck, content []byte, format DeckFormat, lineOffset int) error {
+	scanner := bufio.NewScanner(bytes.NewReader(content))
+	scanner.Buffer(make([]byte, 0, 4096), maxLineLength)
+	lineNum := lineOffset
+	inSideboard := false
 
 	for scanner.Scan() {
+		lineNum++
 		line := strings.TrimSpace(scanner.Text())
 		
 		// Skip empty lines and comments
@@ -111,41 +291,92 @@ func (i *Ingester) IngestFile(filePath string) (*Deck, error) {
 			continue
 		}
 
-		matches := cardRegex.FindStringSubmatch(line)
-		if len(matches) == 3 {
-			quantity, err := strconv.Atoi(matches[1])
-			if err != nil {
-				i.logger.Warnf("Invalid quantity in line: %s", line)
+		// A "Sideboard" header moves the remaining cards to the sideboard;
+		// an "SB:" prefix marks a single sideboard line
+		if isSideboardHeader(line) {
+			inSideboard = true
+			continue
+		}
+		if format == DeckFormatArena {
+			// Deck, Commander and Companion cards all belong to the main deck
+			if isArenaSection(line) {
+				inSideboard = false
 				continue
 			}
+			line = arenaSetSuffix.ReplaceAllString(line, "")
+		}
+		sideboardLine := inSideboard
+		if hasSideboardPrefix(line) {
+			line = strings.TrimSpace(line[3:])
+			sideboardLine = true
+		}
 
-			cardName := strings.TrimSpace(matches[2])
-			deck.Cards = append(deck.Cards, DeckCard{
-				Quantity: quantity,
-				Name:     cardName,
-			})
-			totalCards += quantity
+		if quantityOnly.MatchString(line) {
+			i.logger.Warnf("%s:%d: missing card name in line: %s", deck.FilePath, lineNum, line)
+			deck.ParseReport.add(lineNum, line, "missing card name")
+			deck.MalformedLines = append(deck.MalformedLines, line)
+			continue
+		}
+		matches := cardRegex.FindStringSubmatch(line)
+		if len(matches) != 3 {
+			i.logger.Warnf("%s:%d: unrecognized line: %s", deck.FilePath, lineNum, line)
+			deck.ParseReport.add(lineNum, line, "expected '<quantity> <card name>'")
+			deck.MalformedLines = append(deck.MalformedLines, line)
+			continue
 		}
+
+		quantity, err := strconv.Atoi(matches[1])
+		if err != nil || quantity <= 0 {
+			i.logger.Warnf("%s:%d: invalid quantity in line: %s", deck.FilePath, lineNum, line)
+			deck.ParseReport.add(lineNum, line, "invalid quantity")
+			deck.MalformedLines = append(deck.MalformedLines, line)
+			continue
+		}
+
+		cardName := sanitizeCardName(matches[2])
+		if cardName == "" {
+			i.logger.Warnf("%s:%d: missing card name in line: %s", deck.FilePath, lineNum, line)
+			deck.ParseReport.add(lineNum, line, "missing card name")
+			deck.MalformedLines = append(deck.MalformedLines, line)
+			continue
+		}
+
+		i.addCard(deck, lineNum, DeckCard{Quantity: quantity, Name: cardName}, sideboardLine)
 	}
 
 	if err := scanner.Err(); err != nil {
-		return nil, fmt.Errorf("error reading file: %w", err)
+		return fmt.Errorf("error reading file: %w", err)
 	}
+	return nil
+}
 
-	deck.TotalCards = totalCards
-	deck.UniqueCards = len(deck.Cards)
+// addCard validates a parsed card and appends it to the main deck or sideboard
+func (i *Ingester) addCard(deck *Deck, lineNum int, card DeckCard, sideboard bool) {
+	card.NormalizedName = NormalizeCardName(card.Name)
+	card.Alchemy = IsAlchemyName(card.Name)
 
-	i.logger.Infof("Ingested deck '%s': %d unique cards, %d total cards", 
-		deck.Name, deck.UniqueCards, deck.TotalCards)
+	if i.validator != nil && !i.validator.Exists(card.Name) {
+		deck.UnknownCards = append(deck.UnknownCards, card.Name)
+		if suggestions := i.validator.Suggest(card.Name); len(suggestions) > 0 {
+			i.logger.Warnf("%s:%d: unknown card '%s', did you mean: %s?",
+				deck.FilePath, lineNum, card.Name, strings.Join(suggestions, ", "))
+		} else {
+			i.logger.Warnf("%s:%d: unknown card '%s'", deck.FilePath, lineNum, card.Name)
+		}
+	}
 
-	return deck, nil
+	if sideboard {
+		deck.Sideboard = append(deck.Sideboard, card)
+		return
+	}
+	deck.Cards = append(deck.Cards, card)
 }
 
 // CreateDeckEvent creates a Kafka event for a deck
 func (i *Ingester) CreateDeckEvent(deck *Deck) DeckEvent {
 	return DeckEvent{
 		EventType: "deck.ingested",
-		EventID:   uuid.New().String(),
+		EventID:   stableEventID("deck.ingested", deck.ID),
 		Timestamp: time.Now(),
 		Source:    "deck-ingester",
 		Version:   "v1",
@@ -153,6 +384,22 @@ func (i *Ingester) CreateDeckEvent(deck *Deck) DeckEvent {
 	}
 }
 
+// CreateDeckAnalyzedEvent creates a Kafka event carrying a deck's composition stats
+func (i *Ingester) CreateDeckAnalyzedEvent(deck *Deck, stats DeckStats) DeckEvent {
+	return DeckEvent{
+		EventType: "deck.analyzed",
+		EventID:   stableEventID("deck.analyzed", deck.ID),
+		Timestamp: time.Now(),
+		Source:    "deck-ingester",
+		Version:   "v1",
+		Data: map[string]interface{}{
+			"deck_id":   deck.ID,
+			"deck_name": deck.Name,
+			"stats":     stats,
+		},
+	}
+}
+
 // CreateDeckCardEvents creates individual card events for deck analysis
 func (i *Ingester) CreateDeckCardEvents(deck *Deck) []DeckEvent {
 	var events []DeckEvent
@@ -160,7 +407,7 @@ func (i *Ingester) CreateDeckCardEvents(deck *Deck) []DeckEvent {
 	for _, card := range deck.Cards {
 		event := DeckEvent{
 			EventType: "deck.card",
-			EventID:   uuid.New().String(),
+			EventID:   stableEventID("deck.card", DeckCardKey(deck.ID, card.Name)),
 			Timestamp: time.Now(),
 			Source:    "deck-ingester",
 			Version:   "v1",
@@ -177,17 +424,89 @@ func (i *Ingester) CreateDeckCardEvents(deck *Deck) []DeckEvent {
 	return events
 }
 
+// deckID derives a stable deck ID from the file path and its normalized card list,
+// so re-ingesting an unchanged file yields the same ID. Sideboard cards are
+// prefixed so decks without one keep the IDs they had before sideboards were parsed.
+func deckID(filePath string, cards, sideboard []DeckCard) string {
+	lines := make([]string, 0, len(cards)+len(sideboard))
+	for _, card := range cards {
+		lines = append(lines, fmt.Sprintf("%d %s", card.Quantity, strings.ToLower(card.Name)))
+	}
+	for _, card := range sideboard {
+		lines = append(lines, fmt.Sprintf("SB: %d %s", card.Quantity, strings.ToLower(card.Name)))
+	}
+	sort.Strings(lines)
+
+	content := filePath + "\n" + strings.Join(lines, "\n")
+	return uuid.NewSHA1(uuid.NameSpaceURL, []byte(content)).String()
+}
+
+// isSideboardHeader reports whether a line starts the sideboard section
+func isSideboardHeader(line string) bool {
+	return strings.EqualFold(strings.TrimSuffix(line, ":"), "sideboard")
+}
+
+// DeckCardKey is the Kafka key for a deck card event: deck ID plus normalized card name
+func DeckCardKey(deckID, cardName string) string {
+	return deckID + ":" + strings.ToLower(cardName)
+}
+
+// stableEventID derives an event ID from its key so retries and re-runs produce identical events
+func stableEventID(eventType, key string) string {
+	return uuid.NewSHA1(uuid.NameSpaceOID, []byte(eventType+"|"+key)).String()
+}
+
+// sanitizeCardName strips control characters and surrounding whitespace from a card name
+func sanitizeCardName(name string) string {
+	name = strings.Map(func(r rune) rune {
+		if unicode.IsControl(r) || r == utf8.RuneError {
+			return -1
+		}
+		return r
+	}, name)
+	return strings.TrimSpace(name)
+}
+
 // extractDeckName extracts deck name from file path
 func extractDeckName(filePath string) string {
 	base := filepath.Base(filePath)
 	// Remove extensions
-	name := strings.TrimSuffix(base, ".deck")
-	name = strings.TrimSuffix(name, ".txt")
-	// Replace hyphens and underscores with spaces
+	name := strings.TrimSuffix(base, ".txt")
+	name = strings.TrimSuffix(name, ".deck")
+	name = strings.TrimSuffix(name, ".dek")
+	// Replace hyphens and underscores with spaces, collapsing runs like "--"
 	name = strings.ReplaceAll(name, "-", " ")
 	name = strings.ReplaceAll(name, "_", " ")
-	// Title case
-	return strings.Title(name)
+	name = strings.Join(strings.Fields(name), " ")
+	return titleCase(name)
+}
+
+// titleCase capitalizes the first letter of each word, leaving the rest as
+// written so acronyms like "UR" or "MTGO" survive and "urza's" becomes
+// "Urza's" rather than strings.Title's "Urza'S". A Caser isn't safe for
+// concurrent use, so one is made per call.
+func titleCase(name string) string {
+	return cases.Title(language.English, cases.NoLower).String(name)
+}
+
+// findNameDirective returns the deck name given by the first line starting
+// with directive, matched case-insensitively
+func findNameDirective(content []byte, directive string) (string, bool) {
+	if directive == "" {
+		return "", false
+	}
+	scanner := bufio.NewScanner(bytes.NewReader(content))
+	scanner.Buffer(make([]byte, 0, 4096), maxLineLength)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if len(line) < len(directive) || !strings.EqualFold(line[:len(directive)], directive) {
+			continue
+		}
+		if name := strings.TrimSpace(line[len(directive):]); name != "" {
+			return name, true
+		}
+	}
+	return "", false
 }
 
 // ToJSON converts deck to JSON
@@ -0,0 +1,146 @@
+package deck
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+)
+
+func newTestIngester() *Ingester {
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+	return NewIngester(logger)
+}
+
+func TestIngestReaderReportsLineNumbers(t *testing.T) {
+	content := "// Burn\n" + // 1
+		"4 Lightning Bolt\n" + // 2
+		"\n" + // 3
+		"Lightning Bolt x4\n" + // 4
+		"0 Mountain\n" + // 5
+		"20 Mountain\n" + // 6
+		"SB: lots of Pyroblast\n" // 7
+	d, err := newTestIngester().IngestReader(strings.NewReader(content), "burn.deck")
+	if err != nil {
+		t.Fatalf("IngestReader: %v", err)
+	}
+
+	want := []ParseIssue{
+		{Line: 4, Text: "Lightning Bolt x4", Reason: "expected '<quantity> <card name>'"},
+		{Line: 5, Text: "0 Mountain", Reason: "invalid quantity"},
+		{Line: 7, Text: "lots of Pyroblast", Reason: "expected '<quantity> <card name>'"},
+	}
+	if len(d.ParseReport.Issues) != len(want) {
+		t.Fatalf("issues = %+v, want %+v", d.ParseReport.Issues, want)
+	}
+	for n, issue := range d.ParseReport.Issues {
+		if issue != want[n] {
+			t.Errorf("issue %d = %+v, want %+v", n, issue, want[n])
+		}
+	}
+	if d.TotalCards != 24 || d.UniqueCards != 2 {
+		t.Errorf("TotalCards/UniqueCards = %d/%d, want 24/2", d.TotalCards, d.UniqueCards)
+	}
+}
+
+func TestIngestReaderRecordsMissingCardNames(t *testing.T) {
+	content := "4 Lightning Bolt\n4\n2 \x01\x02\n20 Mountain\n"
+	d, err := newTestIngester().IngestReader(strings.NewReader(content), "burn.deck")
+	if err != nil {
+		t.Fatalf("IngestReader: %v", err)
+	}
+
+	if len(d.MalformedLines) != 2 {
+		t.Errorf("MalformedLines = %q, want both nameless lines", d.MalformedLines)
+	}
+	var lines []int
+	for _, issue := range d.ParseReport.Issues {
+		if issue.Reason != "missing card name" {
+			t.Errorf("line %d reason = %q, want missing card name", issue.Line, issue.Reason)
+		}
+		lines = append(lines, issue.Line)
+	}
+	if len(lines) != 2 || lines[0] != 2 || lines[1] != 3 {
+		t.Errorf("issue lines = %v, want [2 3]", lines)
+	}
+}
+
+func TestIngestReaderRejectsDeckWithoutCards(t *testing.T) {
+	_, err := newTestIngester().IngestReader(strings.NewReader("// nothing here\n4\n"), "empty.deck")
+	if !errors.Is(err, ErrEmptyDeck) {
+		t.Errorf("IngestReader error = %v, want ErrEmptyDeck", err)
+	}
+}
+
+// FuzzIngestReader checks the parser never panics and that what it reports
+// about a deck agrees with the cards and lines it kept
+func FuzzIngestReader(f *testing.F) {
+	fixtures, err := filepath.Glob(filepath.Join("testdata", "*.de*"))
+	if err != nil {
+		f.Fatal(err)
+	}
+	for _, path := range fixtures {
+		content, err := os.ReadFile(path)
+		if err != nil {
+			f.Fatal(err)
+		}
+		f.Add(content)
+	}
+	f.Add([]byte("4\n0 Forest\n-1 Island\nSB:\n"))
+	f.Add([]byte("Sideboard\n\x00 \xff\n99999999999999999999 Mountain\r\n"))
+
+	ingester := newTestIngester()
+	f.Fuzz(func(t *testing.T, content []byte) {
+		d, err := ingester.IngestReader(bytes.NewReader(content), "fuzz.deck")
+		if err != nil {
+			if d != nil {
+				t.Fatalf("got a deck along with error %v", err)
+			}
+			return
+		}
+
+		if len(d.Cards)+len(d.Sideboard) == 0 {
+			t.Fatal("deck without cards was not rejected")
+		}
+		total := 0
+		for _, card := range append(d.Cards, d.Sideboard...) {
+			if card.Quantity <= 0 || card.Name == "" {
+				t.Fatalf("invalid card kept: %+v", card)
+			}
+			if card.Name != strings.TrimSpace(card.Name) {
+				t.Fatalf("card name not trimmed: %q", card.Name)
+			}
+		}
+		for _, card := range d.Cards {
+			total += card.Quantity
+		}
+		if d.TotalCards != total || d.UniqueCards != len(d.Cards) {
+			t.Fatalf("TotalCards/UniqueCards = %d/%d, cards add up to %d/%d",
+				d.TotalCards, d.UniqueCards, total, len(d.Cards))
+		}
+
+		if len(d.MalformedLines) > len(d.ParseReport.Issues) {
+			t.Fatalf("%d malformed lines but only %d issues", len(d.MalformedLines), len(d.ParseReport.Issues))
+		}
+		if DetectFormat(content) == DeckFormatDek {
+			return
+		}
+		lines := bytes.Count(content, []byte("\n")) + 1
+		last := 0
+		for _, issue := range d.ParseReport.Issues {
+			if issue.Line <= last || issue.Line > lines {
+				t.Fatalf("issue on line %d after line %d in a %d line file", issue.Line, last, lines)
+			}
+			if issue.Reason == "" {
+				t.Fatalf("issue on line %d has no reason", issue.Line)
+			}
+			last = issue.Line
+		}
+	})
+}
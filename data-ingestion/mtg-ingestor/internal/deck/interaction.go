@@ -0,0 +1,62 @@
+package deck
+
+import (
+	"regexp"
+	"strings"
+)
+
+// interactionPatterns classifies a card as interaction when its rules text matches.
+//
+//	Category     Pattern                                   Example
+//	removal      destroy target / exile target             Swords to Plowshares
+//	removal      deals N damage to target/any target       Lightning Bolt
+//	removal      return target ... to its owner's hand     Unsummon
+//	sweeper      destroy/exile all / each                  Wrath of God
+//	counter      counter target                            Counterspell
+//	discard      target (player|opponent) ... discards     Thoughtseize
+//	edict        (player|opponent) sacrifices              Diabolic Edict
+var interactionPatterns = []*regexp.Regexp{
+	regexp.MustCompile(`(?i)\b(destroy|exile) target\b`),
+	regexp.MustCompile(`(?i)\bdeals? (\d+|x) damage to (any target|target)\b`),
+	regexp.MustCompile(`(?i)\breturn target .* to its owner's hand\b`),
+	regexp.MustCompile(`(?i)\b(destroy|exile) (all|each)\b`),
+	regexp.MustCompile(`(?i)\bcounter target\b`),
+	regexp.MustCompile(`(?i)\btarget (player|opponent) .*discards?\b`),
+	regexp.MustCompile(`(?i)\b(player|opponent) sacrifices\b`),
+}
+
+// IsInteraction reports whether rules text matches one of the interaction patterns
+func IsInteraction(text string) bool {
+	text = strings.TrimSpace(text)
+	if text == "" {
+		return false
+	}
+	for _, pattern := range interactionPatterns {
+		if pattern.MatchString(text) {
+			return true
+		}
+	}
+	return false
+}
+
+// InteractionDensity returns the fraction of nonland cards (by quantity) that are
+// removal, counters or discard. Cards missing from the index are not counted.
+func (d *Deck) InteractionDensity(index CardLookup) float64 {
+	nonland := 0
+	interaction := 0
+	for _, dc := range d.Cards {
+		card, ok := index.Lookup(dc.Name)
+		if !ok || isLand(card) {
+			continue
+		}
+		nonland += dc.Quantity
+		if IsInteraction(card.Text) {
+			interaction += dc.Quantity
+		}
+	}
+
+	if nonland == 0 {
+		return 0
+	}
+	return float64(interaction) / float64(nonland)
+}
@@ -0,0 +1,52 @@
+package deck
+
+import (
+	"testing"
+
+	"github.com/mtg/mtg-ingestor/internal/models"
+)
+
+func TestIsInteraction(t *testing.T) {
+	tests := []struct {
+		card string
+		text string
+		want bool
+	}{
+		{"Swords to Plowshares", "Exile target creature. Its controller gains life equal to its power.", true},
+		{"Lightning Bolt", "Lightning Bolt deals 3 damage to any target.", true},
+		{"Unsummon", "Return target creature to its owner's hand.", true},
+		{"Wrath of God", "Destroy all creatures. They can't be regenerated.", true},
+		{"Counterspell", "Counter target spell.", true},
+		{"Thoughtseize", "Target player reveals their hand. You choose a nonland card from it. That player discards that card. You lose 2 life.", true},
+		{"Diabolic Edict", "Target player sacrifices a creature.", true},
+		{"Llanowar Elves", "{T}: Add {G}.", false},
+		{"Grizzly Bears", "", false},
+	}
+
+	for _, tt := range tests {
+		if got := IsInteraction(tt.text); got != tt.want {
+			t.Errorf("IsInteraction(%s) = %v, want %v", tt.card, got, tt.want)
+		}
+	}
+}
+
+func TestInteractionDensity(t *testing.T) {
+	index := testIndex(
+		models.Card{Name: "Lightning Bolt", Types: []string{"Instant"}, Text: "Lightning Bolt deals 3 damage to any target."},
+		models.Card{Name: "Grizzly Bears", Types: []string{"Creature"}},
+		models.Card{Name: "Mountain", Types: []string{"Land"}},
+	)
+	d := &Deck{Cards: []DeckCard{
+		{Quantity: 4, Name: "Lightning Bolt"},
+		{Quantity: 12, Name: "Grizzly Bears"},
+		{Quantity: 20, Name: "Mountain"},
+		{Quantity: 4, Name: "Unknown Card"},
+	}}
+
+	if got := d.InteractionDensity(index); got != 0.25 {
+		t.Errorf("InteractionDensity = %v, want 0.25 (4 of 16 known nonland cards)", got)
+	}
+	if got := (&Deck{Cards: []DeckCard{{Quantity: 20, Name: "Mountain"}}}).InteractionDensity(index); got != 0 {
+		t.Errorf("InteractionDensity of lands only = %v, want 0", got)
+	}
+}
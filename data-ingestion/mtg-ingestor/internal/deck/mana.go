@@ -0,0 +1,105 @@
+package deck
+
+import (
+	"regexp"
+
+	"github.com/mtg/mtg-ingestor/internal/models"
+)
+
+// ManaSourceBreakdown counts the mana-producing cards in a deck (by quantity)
+type ManaSourceBreakdown struct {
+	Lands   int `json:"lands"`
+	Rocks   int `json:"rocks"`
+	Dorks   int `json:"dorks"`
+	Rituals int `json:"rituals"`
+	// ByColor counts sources able to produce each color (W, U, B, R, G, C)
+	ByColor map[string]int `json:"by_color"`
+}
+
+// Total returns the number of mana sources of every category
+func (m ManaSourceBreakdown) Total() int {
+	return m.Lands + m.Rocks + m.Dorks + m.Rituals
+}
+
+var (
+	addManaRegex    = regexp.MustCompile(`(?i)\badd\b[^.]*\{[WUBRGC]\}|\badd\b[^.]*\bmana\b`)
+	manaSymbolRegex = regexp.MustCompile(`\{([WUBRGC])\}`)
+	anyColorRegex   = regexp.MustCompile(`(?i)mana of any (one )?color`)
+
+	basicLandColors = map[string]string{
+		"Plains":   "W",
+		"Island":   "U",
+		"Swamp":    "B",
+		"Mountain": "R",
+		"Forest":   "G",
+	}
+)
+
+// ManaSources categorizes the deck's mana producers: lands, artifact rocks,
+// creature dorks and instant/sorcery rituals. Cards missing from the index are skipped.
+func (d *Deck) ManaSources(index CardLookup) ManaSourceBreakdown {
+	breakdown := ManaSourceBreakdown{ByColor: map[string]int{}}
+
+	for _, dc := range d.Cards {
+		card, ok := index.Lookup(dc.Name)
+		if !ok {
+			continue
+		}
+
+		land := isLand(card)
+		if !land && !addManaRegex.MatchString(card.Text) {
+			continue
+		}
+
+		switch {
+		case land:
+			breakdown.Lands += dc.Quantity
+		case hasType(card, "Creature"):
+			breakdown.Dorks += dc.Quantity
+		case hasType(card, "Artifact"):
+			breakdown.Rocks += dc.Quantity
+		case hasType(card, "Instant"), hasType(card, "Sorcery"):
+			breakdown.Rituals += dc.Quantity
+		default:
+			continue
+		}
+
+		for color := range producedColors(card) {
+			breakdown.ByColor[color] += dc.Quantity
+		}
+	}
+
+	return breakdown
+}
+
+// producedColors detects the colors of mana a card can add from its text and basic land types
+func producedColors(card models.Card) map[string]bool {
+	colors := map[string]bool{}
+
+	if anyColorRegex.MatchString(card.Text) {
+		for _, c := range []string{"W", "U", "B", "R", "G"} {
+			colors[c] = true
+		}
+	}
+	for _, m := range manaSymbolRegex.FindAllStringSubmatch(card.Text, -1) {
+		colors[m[1]] = true
+	}
+	for _, subtype := range card.Subtypes {
+		if c, ok := basicLandColors[subtype]; ok {
+			colors[c] = true
+		}
+	}
+
+	return colors
+}
+
+// hasType reports whether the card has the given card type, falling back to
+// the parsed type line for cards without MTGJSON's types list
+func hasType(card models.Card, cardType string) bool {
+	for _, t := range card.Types {
+		if t == cardType {
+			return true
+		}
+	}
+	return card.ParsedType.Has(cardType)
+}
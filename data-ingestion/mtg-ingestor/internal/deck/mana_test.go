@@ -0,0 +1,45 @@
+package deck
+
+import (
+	"testing"
+
+	"github.com/mtg/mtg-ingestor/internal/models"
+)
+
+func TestManaSources(t *testing.T) {
+	index := testIndex(
+		models.Card{Name: "Mountain", Types: []string{"Land"}, Subtypes: []string{"Mountain"}},
+		models.Card{Name: "Command Tower", Types: []string{"Land"}, Text: "{T}: Add one mana of any color in your commander's color identity."},
+		models.Card{Name: "Llanowar Elves", Types: []string{"Creature"}, Text: "{T}: Add {G}."},
+		models.Card{Name: "Sol Ring", Types: []string{"Artifact"}, Text: "{T}: Add {C}{C}."},
+		models.Card{Name: "Birds of Paradise", Types: []string{"Creature"}, Text: "Flying\n{T}: Add one mana of any color."},
+		models.Card{Name: "Dark Ritual", Types: []string{"Instant"}, Text: "Add {B}{B}{B}."},
+		models.Card{Name: "Lightning Bolt", Types: []string{"Instant"}, Text: "Lightning Bolt deals 3 damage to any target."},
+	)
+	d := &Deck{Cards: []DeckCard{
+		{Quantity: 10, Name: "Mountain"},
+		{Quantity: 1, Name: "Command Tower"},
+		{Quantity: 4, Name: "Llanowar Elves"},
+		{Quantity: 1, Name: "Sol Ring"},
+		{Quantity: 2, Name: "Birds of Paradise"},
+		{Quantity: 3, Name: "Dark Ritual"},
+		{Quantity: 4, Name: "Lightning Bolt"},
+		{Quantity: 4, Name: "Unknown Card"},
+	}}
+
+	got := d.ManaSources(index)
+	if got.Lands != 11 || got.Dorks != 6 || got.Rocks != 1 || got.Rituals != 3 {
+		t.Errorf("ManaSources = %d lands, %d dorks, %d rocks, %d rituals, want 11, 6, 1, 3",
+			got.Lands, got.Dorks, got.Rocks, got.Rituals)
+	}
+	if got.Total() != 21 {
+		t.Errorf("Total() = %d, want 21", got.Total())
+	}
+
+	wantColors := map[string]int{"W": 3, "U": 3, "B": 6, "R": 13, "G": 7, "C": 1}
+	for color, want := range wantColors {
+		if got.ByColor[color] != want {
+			t.Errorf("ByColor[%s] = %d, want %d", color, got.ByColor[color], want)
+		}
+	}
+}
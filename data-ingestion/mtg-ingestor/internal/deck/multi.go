@@ -0,0 +1,134 @@
+package deck
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+)
+
+// deckBanner matches a "=== Deck Name ===" line starting the next deck of a file
+var deckBanner = regexp.MustCompile(`^={3,}\s*(.*?)\s*={3,}$`)
+
+// deckSection is one deck's share of a multi-deck file
+type deckSection struct {
+	name    string
+	content []byte
+	// lineOffset is the number of file lines before content, so issues
+	// can be reported by their line in the file
+	lineOffset int
+}
+
+// IngestMultiFile parses a file that may hold several decks, separated by
+// "=== Deck Name ===" banners or form feeds. Each deck is named by its
+// banner, else by a name directive, else after the file and its position.
+// A file without separators yields a single deck, as IngestFile would.
+// Issues are reported by their line in the file, not in the section.
+func (i *Ingester) IngestMultiFile(filePath string) ([]*Deck, error) {
+	content, err := os.ReadFile(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open file: %w", err)
+	}
+
+	sections := splitDeckSections(content)
+	if isDekContent(content) || (len(sections) <= 1 && !hasBanner(sections)) {
+		d, err := i.IngestReader(bytes.NewReader(content), filePath)
+		if err != nil {
+			return nil, err
+		}
+		return []*Deck{d}, nil
+	}
+
+	var decks []*Deck
+	var errs []error
+	for n, section := range sections {
+		name := section.name
+		if name == "" {
+			if directive, ok := findNameDirective(section.content, i.NameDirective); ok {
+				name = directive
+			} else {
+				name = fmt.Sprintf("%s %d", extractDeckName(filePath), n+1)
+			}
+		}
+		// A lone deck keeps the ID it would get without a banner
+		idKey := filePath
+		if len(sections) > 1 {
+			idKey = fmt.Sprintf("%s#%d", filePath, n+1)
+		}
+		d, err := i.parseDeck(section.content, filePath, name, idKey, section.lineOffset)
+		if err != nil {
+			i.logger.WithError(err).Warnf("%s: skipping deck %d (%s)", filePath, n+1, name)
+			errs = append(errs, fmt.Errorf("deck %d (%s): %w", n+1, name, err))
+			continue
+		}
+		decks = append(decks, d)
+	}
+	if len(decks) == 0 {
+		return nil, errors.Join(errs...)
+	}
+	return decks, nil
+}
+
+// splitDeckSections splits content at deck banners and form feeds. Unnamed
+// stretches without card lines, such as a preamble, are dropped.
+func splitDeckSections(content []byte) []deckSection {
+	var sections []deckSection
+	current := deckSection{}
+	var buf bytes.Buffer
+	lineNum := 0
+	flush := func() {
+		current.content = append([]byte(nil), buf.Bytes()...)
+		if current.name != "" || hasCardLines(current.content) {
+			sections = append(sections, current)
+		}
+		current = deckSection{}
+		buf.Reset()
+	}
+
+	for _, line := range bytes.SplitAfter(content, []byte("\n")) {
+		lineNum++
+		for n, part := range bytes.Split(line, []byte("\f")) {
+			if n > 0 {
+				flush()
+			}
+			if m := deckBanner.FindSubmatch(bytes.TrimSpace(part)); m != nil {
+				if buf.Len() > 0 || current.name != "" {
+					flush()
+				}
+				current.name = string(m[1])
+				continue
+			}
+			if buf.Len() == 0 {
+				current.lineOffset = lineNum - 1
+			}
+			buf.Write(part)
+		}
+	}
+	if buf.Len() > 0 || current.name != "" {
+		flush()
+	}
+	return sections
+}
+
+// hasBanner reports whether any section was started by a "=== Name ===" banner
+func hasBanner(sections []deckSection) bool {
+	for _, section := range sections {
+		if section.name != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// hasCardLines reports whether content has anything besides blank lines and comments
+func hasCardLines(content []byte) bool {
+	for _, line := range strings.Split(string(content), "\n") {
+		line = strings.TrimSpace(line)
+		if line != "" && !strings.HasPrefix(line, "//") && !strings.HasPrefix(line, "#") {
+			return true
+		}
+	}
+	return false
+}
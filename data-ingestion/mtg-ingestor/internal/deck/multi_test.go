@@ -0,0 +1,87 @@
+package deck
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func writeDeckFile(t *testing.T, name, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestIngestMultiFileSingleBanner(t *testing.T) {
+	path := writeDeckFile(t, "burn.deck", "=== Mono Red Burn ===\n4 Lightning Bolt\n20 Mountain\n")
+
+	decks, err := newTestIngester().IngestMultiFile(path)
+	if err != nil {
+		t.Fatalf("IngestMultiFile: %v", err)
+	}
+	if len(decks) != 1 {
+		t.Fatalf("got %d decks, want 1", len(decks))
+	}
+	d := decks[0]
+	if d.Name != "Mono Red Burn" {
+		t.Errorf("Name = %q, want the banner name", d.Name)
+	}
+	if d.ParseReport.HasIssues() || len(d.MalformedLines) > 0 {
+		t.Errorf("banner reported as an issue: %+v", d.ParseReport.Issues)
+	}
+	if d.TotalCards != 24 {
+		t.Errorf("TotalCards = %d, want 24", d.TotalCards)
+	}
+}
+
+func TestIngestMultiFileReportsFileLineNumbers(t *testing.T) {
+	content := "=== Burn ===\n" + // 1
+		"4 Lightning Bolt\n" + // 2
+		"oops\n" + // 3
+		"\n" + // 4
+		"=== Elves ===\n" + // 5
+		"4 Llanowar Elves\n" + // 6
+		"zero Forest\n" + // 7
+		"\f4 Giant Growth\n" + // 8, third deck
+		"bad line\n" // 9
+	decks, err := newTestIngester().IngestMultiFile(writeDeckFile(t, "decks.deck", content))
+	if err != nil {
+		t.Fatalf("IngestMultiFile: %v", err)
+	}
+	if len(decks) != 3 {
+		t.Fatalf("got %d decks, want 3", len(decks))
+	}
+
+	wantLines := []int{3, 7, 9}
+	for n, d := range decks {
+		if len(d.ParseReport.Issues) != 1 {
+			t.Errorf("deck %d (%s): issues = %+v, want one", n+1, d.Name, d.ParseReport.Issues)
+			continue
+		}
+		if got := d.ParseReport.Issues[0].Line; got != wantLines[n] {
+			t.Errorf("deck %d (%s): issue on line %d, want %d", n+1, d.Name, got, wantLines[n])
+		}
+	}
+	if decks[0].Name != "Burn" || decks[1].Name != "Elves" || decks[2].Name != "Decks 3" {
+		t.Errorf("names = %q, %q, %q", decks[0].Name, decks[1].Name, decks[2].Name)
+	}
+}
+
+func TestIngestMultiFileWithoutSeparators(t *testing.T) {
+	path := writeDeckFile(t, "mono-red.deck", "4 Lightning Bolt\n20 Mountain\n")
+
+	decks, err := newTestIngester().IngestMultiFile(path)
+	if err != nil {
+		t.Fatalf("IngestMultiFile: %v", err)
+	}
+	single, err := newTestIngester().IngestFile(path)
+	if err != nil {
+		t.Fatalf("IngestFile: %v", err)
+	}
+	if len(decks) != 1 || decks[0].ID != single.ID || decks[0].Name != "Mono Red" {
+		t.Errorf("IngestMultiFile = %+v, want the single deck %+v", decks, single)
+	}
+}
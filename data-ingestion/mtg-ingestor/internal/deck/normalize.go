@@ -0,0 +1,38 @@
+package deck
+
+import (
+	"regexp"
+	"strings"
+)
+
+// alchemyPrefix marks Arena's rebalanced Alchemy versions, e.g. "A-Lightning Bolt"
+const alchemyPrefix = "A-"
+
+var (
+	// splitSeparator matches the ways split and double-faced names are written: "/", "//", " / "
+	splitSeparator = regexp.MustCompile(`\s*/{1,2}\s*`)
+	whitespace     = regexp.MustCompile(`\s+`)
+)
+
+// NormalizeCardName returns the key deck card names are compared by: lowercase,
+// single-spaced, split-card halves joined by " // " and without Arena's "A-"
+// Alchemy prefix, so "fire/ice" and "Fire // Ice" normalize the same
+func NormalizeCardName(raw string) string {
+	name, _ := stripAlchemyPrefix(strings.TrimSpace(raw))
+	name = whitespace.ReplaceAllString(name, " ")
+	name = splitSeparator.ReplaceAllString(name, " // ")
+	return strings.ToLower(name)
+}
+
+// IsAlchemyName reports whether a name carries Arena's "A-" Alchemy prefix
+func IsAlchemyName(raw string) bool {
+	_, alchemy := stripAlchemyPrefix(strings.TrimSpace(raw))
+	return alchemy
+}
+
+func stripAlchemyPrefix(name string) (string, bool) {
+	if len(name) > len(alchemyPrefix) && strings.HasPrefix(name, alchemyPrefix) {
+		return name[len(alchemyPrefix):], true
+	}
+	return name, false
+}
@@ -0,0 +1,123 @@
+package deck
+
+import (
+	"encoding/json"
+	"io"
+	"path/filepath"
+	"testing"
+
+	"github.com/mtg/mtg-ingestor/internal/fetcher"
+	"github.com/mtg/mtg-ingestor/internal/models"
+	"github.com/sirupsen/logrus"
+)
+
+func quietLogger() *logrus.Logger {
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+	return logger
+}
+
+// loadSplitCards replays the split-card AtomicCards fixture through the fetcher
+func loadSplitCards(t *testing.T) map[string]models.Card {
+	t.Helper()
+	f := fetcher.NewMTGFetcher(quietLogger())
+	if _, err := f.ReplayFile(filepath.Join("testdata", "AtomicCards-split.json")); err != nil {
+		t.Fatalf("ReplayFile: %v", err)
+	}
+	cards, err := f.FetchAtomicCards()
+	if err != nil {
+		t.Fatalf("FetchAtomicCards: %v", err)
+	}
+	return cards
+}
+
+func TestSplitCardsKeepTheirFaces(t *testing.T) {
+	index := NewCardNameIndexFromCards(loadSplitCards(t))
+
+	tests := []struct {
+		name  string
+		faces []string
+	}{
+		{"Fire // Ice", []string{"Fire", "Ice"}},
+		{"Delver of Secrets // Insectile Aberration", []string{"Delver of Secrets", "Insectile Aberration"}},
+		{"Lightning Bolt", nil},
+	}
+
+	for _, tt := range tests {
+		card, ok := index.Lookup(tt.name)
+		if !ok {
+			t.Fatalf("Lookup(%q) found nothing", tt.name)
+		}
+
+		// Faces must survive publishing, i.e. a JSON round trip
+		data, err := json.Marshal(card)
+		if err != nil {
+			t.Fatalf("%s: marshal: %v", tt.name, err)
+		}
+		var decoded models.Card
+		if err := json.Unmarshal(data, &decoded); err != nil {
+			t.Fatalf("%s: unmarshal: %v", tt.name, err)
+		}
+
+		if len(decoded.Faces) != len(tt.faces) {
+			t.Fatalf("%s: faces = %+v, want %q", tt.name, decoded.Faces, tt.faces)
+		}
+		for i, face := range decoded.Faces {
+			if face.Name != tt.faces[i] {
+				t.Errorf("%s: face %d = %q, want %q", tt.name, i, face.Name, tt.faces[i])
+			}
+		}
+	}
+
+	fire, _ := index.Lookup("Fire // Ice")
+	if face := fire.Faces[0]; face.ManaCost != "{1}{R}" || face.Side != "a" {
+		t.Errorf("Fire face = %+v, want its own mana cost and side", face)
+	}
+	delver, _ := index.Lookup("Delver of Secrets // Insectile Aberration")
+	if back := delver.Faces[1]; back.Power != "3" || back.Toughness != "2" {
+		t.Errorf("Insectile Aberration face = %+v, want 3/2", back)
+	}
+}
+
+func TestSplitCardsResolveByFaceAndFullName(t *testing.T) {
+	index := NewCardNameIndexFromCards(loadSplitCards(t))
+
+	tests := []struct {
+		name string
+		want string
+	}{
+		{"Fire // Ice", "Fire // Ice"},
+		{"fire/ice", "Fire // Ice"},
+		{"Fire", "Fire // Ice"},
+		{"Ice", "Fire // Ice"},
+		{"Delver of Secrets", "Delver of Secrets // Insectile Aberration"},
+		{"Lightning Bolt", "Lightning Bolt"},
+	}
+
+	for _, tt := range tests {
+		if !index.Exists(tt.name) {
+			t.Errorf("Exists(%q) = false", tt.name)
+		}
+		if card, ok := index.Lookup(tt.name); !ok || card.Name != tt.want {
+			t.Errorf("Lookup(%q) = %q, %v, want %q", tt.name, card.Name, ok, tt.want)
+		}
+	}
+
+	if suggestions := index.Suggest("Fier"); len(suggestions) != 1 || suggestions[0] != "Fire // Ice" {
+		t.Errorf("Suggest(Fier) = %q, want the full name once", suggestions)
+	}
+}
+
+func TestSplitCardDeckResolves(t *testing.T) {
+	ingester := NewIngesterWithValidator(quietLogger(), NewCardNameIndexFromCards(loadSplitCards(t)))
+	d, err := ingester.IngestFile(filepath.Join("testdata", "split.deck"))
+	if err != nil {
+		t.Fatalf("IngestFile: %v", err)
+	}
+	if len(d.UnknownCards) != 0 {
+		t.Errorf("unknown cards = %q, want every face and full name resolved", d.UnknownCards)
+	}
+	if d.TotalCards != 12 || len(d.Sideboard) != 1 {
+		t.Errorf("deck = %d cards, %d sideboard entries, want 12 and 1", d.TotalCards, len(d.Sideboard))
+	}
+}
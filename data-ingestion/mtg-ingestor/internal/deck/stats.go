@@ -0,0 +1,68 @@
+package deck
+
+import (
+	"github.com/mtg/mtg-ingestor/internal/models"
+)
+
+// maxCurveBucket collects every card at this mana value or above
+const maxCurveBucket = 7
+
+// DeckStats holds composition aggregates for a deck, counted by quantity
+type DeckStats struct {
+	// ManaCurve maps mana value to nonland card count; 7 means 7 or more
+	ManaCurve map[int]int `json:"mana_curve"`
+	// Colors counts cards per color (W, U, B, R, G, or C for colorless)
+	Colors map[string]int `json:"colors"`
+	// Types counts cards per primary type (Creature, Land, Instant, ...)
+	Types map[string]int `json:"types"`
+	// AverageCMC is the mean mana value of nonland cards
+	AverageCMC float64 `json:"average_cmc"`
+	// Unresolved counts cards the lookup didn't know
+	Unresolved int `json:"unresolved"`
+}
+
+// AnalyzeDeck computes the mana curve, color and type breakdowns and average
+// mana value of a deck, joining card names against lookup
+func AnalyzeDeck(deck *Deck, lookup func(name string) (models.Card, bool)) DeckStats {
+	stats := DeckStats{
+		ManaCurve: map[int]int{},
+		Colors:    map[string]int{},
+		Types:     map[string]int{},
+	}
+
+	nonland := 0
+	totalCMC := 0.0
+	for _, dc := range deck.Cards {
+		card, ok := lookup(dc.Name)
+		if !ok {
+			stats.Unresolved += dc.Quantity
+			stats.Types[otherGroup] += dc.Quantity
+			continue
+		}
+
+		stats.Types[primaryType(card)] += dc.Quantity
+
+		if len(card.Colors) == 0 {
+			stats.Colors["C"] += dc.Quantity
+		}
+		for _, color := range card.Colors {
+			stats.Colors[color] += dc.Quantity
+		}
+
+		if isLand(card) {
+			continue
+		}
+		bucket := int(card.ConvertedMana)
+		if bucket > maxCurveBucket {
+			bucket = maxCurveBucket
+		}
+		stats.ManaCurve[bucket] += dc.Quantity
+		totalCMC += card.ConvertedMana * float64(dc.Quantity)
+		nonland += dc.Quantity
+	}
+
+	if nonland > 0 {
+		stats.AverageCMC = totalCMC / float64(nonland)
+	}
+	return stats
+}
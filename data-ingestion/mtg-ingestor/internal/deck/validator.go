@@ -0,0 +1,135 @@
+package deck
+
+import (
+	"sort"
+
+	"github.com/mtg/mtg-ingestor/internal/models"
+)
+
+// CardNameValidator checks deck card names against a known card pool
+type CardNameValidator interface {
+	Exists(name string) bool
+	Suggest(name string) []string
+}
+
+// CardNameIndex is a CardNameValidator backed by an in-memory set of card names
+type CardNameIndex struct {
+	names map[string]string      // normalized name -> canonical name
+	cards map[string]models.Card // normalized name -> card, when built from card data
+	// MaxSuggestions caps the number of names returned by Suggest
+	MaxSuggestions int
+	// MaxDistance is the largest edit distance still considered a suggestion
+	MaxDistance int
+}
+
+// NewCardNameIndex builds an index from a list of card names
+func NewCardNameIndex(names []string) *CardNameIndex {
+	idx := &CardNameIndex{
+		names:          make(map[string]string, len(names)),
+		MaxSuggestions: 3,
+		MaxDistance:    3,
+	}
+	for _, name := range names {
+		idx.names[NormalizeCardName(name)] = name
+	}
+	return idx
+}
+
+// NewCardNameIndexFromCards builds an index from the atomic cards map returned by the fetcher.
+// Multi-face cards are also found by each face's name, e.g. "Fire" for "Fire // Ice",
+// unless another card already has that name.
+func NewCardNameIndexFromCards(cards map[string]models.Card) *CardNameIndex {
+	names := make([]string, 0, len(cards))
+	for _, card := range cards {
+		names = append(names, card.Name)
+	}
+	idx := NewCardNameIndex(names)
+	idx.cards = make(map[string]models.Card, len(cards))
+	for _, card := range cards {
+		idx.cards[NormalizeCardName(card.Name)] = card
+	}
+	for _, card := range cards {
+		if !card.IsMultiFaced() {
+			continue
+		}
+		for _, face := range card.FaceNames() {
+			key := NormalizeCardName(face)
+			if _, taken := idx.cards[key]; !taken {
+				idx.names[key] = card.Name
+				idx.cards[key] = card
+			}
+		}
+	}
+	return idx
+}
+
+// Lookup returns the card data for a name, compared by NormalizeCardName
+func (c *CardNameIndex) Lookup(name string) (models.Card, bool) {
+	card, ok := c.cards[NormalizeCardName(name)]
+	return card, ok
+}
+
+// Exists reports whether the card name is known, compared by NormalizeCardName
+func (c *CardNameIndex) Exists(name string) bool {
+	_, ok := c.names[NormalizeCardName(name)]
+	return ok
+}
+
+// Suggest returns the closest known card names by edit distance
+func (c *CardNameIndex) Suggest(name string) []string {
+	type candidate struct {
+		name     string
+		distance int
+	}
+
+	// Face names map to their card's full name, so keep each name's closest key only
+	target := NormalizeCardName(name)
+	closest := map[string]int{}
+	for lower, canonical := range c.names {
+		d := levenshtein(target, lower)
+		if best, seen := closest[canonical]; d <= c.MaxDistance && (!seen || d < best) {
+			closest[canonical] = d
+		}
+	}
+	candidates := make([]candidate, 0, len(closest))
+	for canonical, d := range closest {
+		candidates = append(candidates, candidate{name: canonical, distance: d})
+	}
+
+	sort.Slice(candidates, func(i, j int) bool {
+		if candidates[i].distance != candidates[j].distance {
+			return candidates[i].distance < candidates[j].distance
+		}
+		return candidates[i].name < candidates[j].name
+	})
+
+	var suggestions []string
+	for i := 0; i < len(candidates) && i < c.MaxSuggestions; i++ {
+		suggestions = append(suggestions, candidates[i].name)
+	}
+	return suggestions
+}
+
+// levenshtein computes the edit distance between two strings
+func levenshtein(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	prev := make([]int, len(rb)+1)
+	curr := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+
+	for i := 1; i <= len(ra); i++ {
+		curr[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+
+	return prev[len(rb)]
+}
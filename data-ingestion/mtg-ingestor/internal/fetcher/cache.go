@@ -0,0 +1,93 @@
+package fetcher
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+)
+
+// ErrNotModified is returned by fetch methods when the server answers 304 Not Modified
+var ErrNotModified = errors.New("not modified since last fetch")
+
+// CacheEntry holds the validators returned for a URL
+type CacheEntry struct {
+	ETag         string `json:"etag,omitempty"`
+	LastModified string `json:"last_modified,omitempty"`
+}
+
+// HTTPCache stores ETag/Last-Modified validators per URL so later fetches can be conditional.
+// It can be persisted to a JSON file to survive restarts between cron runs.
+// Validators from a new response stay pending until Commit, so a body that was
+// truncated or never published isn't answered with 304 on the next run.
+type HTTPCache struct {
+	mu      sync.Mutex
+	Entries map[string]CacheEntry `json:"entries"`
+	pending map[string]CacheEntry
+}
+
+// NewHTTPCache creates an empty cache
+func NewHTTPCache() *HTTPCache {
+	return &HTTPCache{Entries: map[string]CacheEntry{}, pending: map[string]CacheEntry{}}
+}
+
+// LoadHTTPCache reads a cache file, returning an empty cache if it doesn't exist
+func LoadHTTPCache(path string) (*HTTPCache, error) {
+	cache := NewHTTPCache()
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return cache, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read http cache: %w", err)
+	}
+
+	if err := json.Unmarshal(data, cache); err != nil {
+		return nil, fmt.Errorf("failed to parse http cache: %w", err)
+	}
+	if cache.Entries == nil {
+		cache.Entries = map[string]CacheEntry{}
+	}
+	if cache.pending == nil {
+		cache.pending = map[string]CacheEntry{}
+	}
+	return cache, nil
+}
+
+// Save writes the cache to path
+func (c *HTTPCache) Save(path string) error {
+	c.mu.Lock()
+	data, err := json.MarshalIndent(c, "", "  ")
+	c.mu.Unlock()
+	if err != nil {
+		return fmt.Errorf("failed to marshal http cache: %w", err)
+	}
+	return os.WriteFile(path, data, 0644)
+}
+
+func (c *HTTPCache) get(url string) (CacheEntry, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	entry, ok := c.Entries[url]
+	return entry, ok
+}
+
+// Commit makes the pending validators for url current; it is a no-op when the
+// last fetch of url didn't return new validators
+func (c *HTTPCache) Commit(url string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if entry, ok := c.pending[url]; ok {
+		c.Entries[url] = entry
+		delete(c.pending, url)
+	}
+}
+
+// setPending records the validators of a response until Commit
+func (c *HTTPCache) setPending(url string, entry CacheEntry) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.pending[url] = entry
+}
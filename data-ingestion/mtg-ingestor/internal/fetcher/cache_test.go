@@ -0,0 +1,92 @@
+package fetcher
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"path/filepath"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+)
+
+// doerFunc adapts a function to httpDoer
+type doerFunc func(req *http.Request) (*http.Response, error)
+
+func (d doerFunc) Do(req *http.Request) (*http.Response, error) {
+	return d(req)
+}
+
+// response builds a canned response with the given status, body and headers
+func response(status int, body []byte, headers ...string) *http.Response {
+	resp := &http.Response{
+		StatusCode:    status,
+		Header:        http.Header{},
+		Body:          io.NopCloser(bytes.NewReader(body)),
+		ContentLength: int64(len(body)),
+	}
+	for i := 0; i+1 < len(headers); i += 2 {
+		resp.Header.Set(headers[i], headers[i+1])
+	}
+	return resp
+}
+
+func quietLogger() *logrus.Logger {
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+	return logger
+}
+
+func TestHTTPCacheKeepsValidatorsPendingUntilCommit(t *testing.T) {
+	var conditional []string
+	f := NewMTGFetcherWithClient(quietLogger(), doerFunc(func(req *http.Request) (*http.Response, error) {
+		conditional = append(conditional, req.Header.Get("If-None-Match"))
+		return response(http.StatusOK, nil, "ETag", `"v1"`), nil
+	}))
+	cache := NewHTTPCache()
+	f.SetHTTPCache(cache)
+	url := f.archiveURL(AllSetsFile)
+
+	for i := 0; i < 2; i++ {
+		resp, err := f.get(url)
+		if err != nil {
+			t.Fatalf("get: %v", err)
+		}
+		resp.Body.Close()
+	}
+	if conditional[1] != "" {
+		t.Fatalf("uncommitted validator was sent: If-None-Match %q", conditional[1])
+	}
+
+	path := filepath.Join(t.TempDir(), "cache.json")
+	if err := cache.Save(path); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+	if loaded, err := LoadHTTPCache(path); err != nil || len(loaded.Entries) != 0 {
+		t.Fatalf("pending validators were saved: %+v, %v", loaded, err)
+	}
+
+	f.CommitValidators(AllSetsFile)
+	if _, err := f.get(url); err != nil {
+		t.Fatalf("get: %v", err)
+	}
+	if conditional[2] != `"v1"` {
+		t.Errorf("committed validator not sent: If-None-Match %q", conditional[2])
+	}
+}
+
+func TestHTTPCacheNotModified(t *testing.T) {
+	f := NewMTGFetcherWithClient(quietLogger(), doerFunc(func(req *http.Request) (*http.Response, error) {
+		if req.Header.Get("If-None-Match") == `"v1"` {
+			return response(http.StatusNotModified, nil), nil
+		}
+		return response(http.StatusOK, nil, "ETag", `"v1"`), nil
+	}))
+	cache := NewHTTPCache()
+	cache.Entries[f.archiveURL(AllSetsFile)] = CacheEntry{ETag: `"v1"`}
+	f.SetHTTPCache(cache)
+
+	if _, err := f.FetchAllSets(); err != ErrNotModified {
+		t.Fatalf("FetchAllSets error = %v, want ErrNotModified", err)
+	}
+}
@@ -0,0 +1,81 @@
+package fetcher
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// ErrChecksumMismatch is returned when a download doesn't match its published .sha256
+var ErrChecksumMismatch = errors.New("checksum mismatch")
+
+// fetchChecksum downloads the .sha256 sidecar for url and returns the hex digest
+func (f *MTGFetcher) fetchChecksum(url string) (string, error) {
+	resp, err := f.getURL(url + ".sha256")
+	if err != nil {
+		return "", fmt.Errorf("failed to fetch checksum: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("unexpected status code fetching checksum: %d", resp.StatusCode)
+	}
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, 1024))
+	if err != nil {
+		return "", fmt.Errorf("failed to read checksum: %w", err)
+	}
+
+	// Sidecars may be "<digest>" or "<digest>  <filename>"
+	fields := strings.Fields(string(data))
+	if len(fields) == 0 {
+		return "", fmt.Errorf("empty checksum file for %s", url)
+	}
+	return strings.ToLower(fields[0]), nil
+}
+
+// readArchiveBody decompresses a downloaded archive. With VerifyChecksums set, the
+// compressed bytes are hashed as they stream and compared to the .sha256 sidecar
+// before any data is returned. contentType is the response's Content-Type, if
+// known, quoted when the body turns out not to be an archive.
+func (f *MTGFetcher) readArchiveBody(url string, body io.Reader, contentType string) ([]byte, error) {
+	var expected string
+	var hasher hash.Hash
+	if f.VerifyChecksums {
+		var err error
+		if expected, err = f.fetchChecksum(url); err != nil {
+			return nil, err
+		}
+		hasher = sha256.New()
+		body = io.TeeReader(body, hasher)
+	}
+
+	reader, err := f.decompress(body, contentType)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", url, err)
+	}
+	defer reader.Close()
+
+	data, err := io.ReadAll(reader)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response: %w", err)
+	}
+
+	if hasher != nil {
+		// Hash any trailing bytes the decompressor didn't consume
+		if _, err := io.Copy(io.Discard, body); err != nil {
+			return nil, fmt.Errorf("failed to read response: %w", err)
+		}
+		if actual := hex.EncodeToString(hasher.Sum(nil)); actual != expected {
+			return nil, fmt.Errorf("%w for %s: expected %s, got %s", ErrChecksumMismatch, url, expected, actual)
+		}
+		f.logger.Debugf("Verified checksum for %s", url)
+	}
+
+	return data, nil
+}
@@ -0,0 +1,139 @@
+package fetcher
+
+import (
+	"bufio"
+	"bytes"
+	"compress/bzip2"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"unicode"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+// Compression formats MTGJSON publishes its files in
+const (
+	CompressionGzip  = "gzip"
+	CompressionZstd  = "zstd"
+	CompressionBzip2 = "bzip2"
+)
+
+// compressionExtensions maps each format to the suffix of its MTGJSON files
+var compressionExtensions = map[string]string{
+	CompressionGzip:  ".gz",
+	CompressionZstd:  ".zst",
+	CompressionBzip2: ".bz2",
+}
+
+// compressionMagic is the signature each archive format starts with
+var compressionMagic = map[string][]byte{
+	CompressionGzip:  {0x1f, 0x8b},
+	CompressionZstd:  {0x28, 0xb5, 0x2f, 0xfd},
+	CompressionBzip2: []byte("BZh"),
+}
+
+// ErrNotArchive is returned when a download doesn't start like the expected
+// archive format, e.g. an HTML error page served with a 200 status
+var ErrNotArchive = errors.New("not a compressed archive")
+
+// archiveSnippetLength bounds how much of a non-archive body is quoted in errors
+const archiveSnippetLength = 120
+
+// SetCompression picks the archive format to download; zstd decompresses
+// fastest, gzip is the default
+func (f *MTGFetcher) SetCompression(format string) error {
+	if format == "" {
+		format = CompressionGzip
+	}
+	if _, ok := compressionExtensions[format]; !ok {
+		return fmt.Errorf("unsupported compression format: %q", format)
+	}
+	f.compression = format
+	return nil
+}
+
+// archiveURL returns the URL of an MTGJSON file, e.g. "AllSets.json", in the
+// configured compression format
+func (f *MTGFetcher) archiveURL(name string) string {
+	return fmt.Sprintf("%s/%s%s", f.baseURL, name, compressionExtensions[f.compressionFormat()])
+}
+
+func (f *MTGFetcher) compressionFormat() string {
+	if f.compression == "" {
+		return CompressionGzip
+	}
+	return f.compression
+}
+
+// decompress wraps r in the reader for the configured compression format
+func (f *MTGFetcher) decompress(r io.Reader, contentType string) (io.ReadCloser, error) {
+	return decompressAs(r, f.compressionFormat(), contentType)
+}
+
+// decompressAs wraps r in the reader for format, first checking that r starts
+// with the format's signature so a non-archive body fails with a readable error
+func decompressAs(r io.Reader, format, contentType string) (io.ReadCloser, error) {
+	buffered := bufio.NewReader(r)
+	if err := sniffArchive(buffered, format, contentType); err != nil {
+		return nil, err
+	}
+	r = buffered
+
+	switch format {
+	case CompressionZstd:
+		decoder, err := zstd.NewReader(r)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create zstd reader: %w", err)
+		}
+		return decoder.IOReadCloser(), nil
+	case CompressionBzip2:
+		return io.NopCloser(bzip2.NewReader(r)), nil
+	default:
+		gzReader, err := gzip.NewReader(r)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create gzip reader: %w", err)
+		}
+		return gzReader, nil
+	}
+}
+
+// sniffArchive peeks at the start of r and, when it lacks the signature of
+// format, returns ErrNotArchive with the content type and the first bytes,
+// e.g. "expected gzip, got text/html: <!DOCTYPE html>..."
+func sniffArchive(r *bufio.Reader, format, contentType string) error {
+	magic := compressionMagic[format]
+	head, err := r.Peek(archiveSnippetLength)
+	if bytes.HasPrefix(head, magic) {
+		return nil
+	}
+	if len(head) == 0 {
+		if err != nil && err != io.EOF {
+			return fmt.Errorf("failed to read response: %w", err)
+		}
+		return fmt.Errorf("%w: expected %s, got an empty body", ErrNotArchive, format)
+	}
+	if contentType == "" {
+		contentType = http.DetectContentType(head)
+	}
+	return fmt.Errorf("%w: expected %s, got %s: %s", ErrNotArchive, format, contentType, snippet(head))
+}
+
+// snippet renders the start of a body for an error message, collapsing
+// whitespace and replacing unprintable bytes
+func snippet(head []byte) string {
+	text := strings.Map(func(r rune) rune {
+		if r == unicode.ReplacementChar || (!unicode.IsPrint(r) && !unicode.IsSpace(r)) {
+			return '.'
+		}
+		return r
+	}, strings.ToValidUTF8(string(head), "."))
+	text = strings.Join(strings.Fields(text), " ")
+	if len(head) == archiveSnippetLength {
+		text += "..."
+	}
+	return text
+}
@@ -0,0 +1,128 @@
+package fetcher
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// downloadResumable downloads url into DownloadDir, resuming a partial download
+// with a Range request on each retry. Data is written to a .part file that is
+// only renamed to its final name once the full body has been received.
+func (f *MTGFetcher) downloadResumable(url string) (string, error) {
+	target := filepath.Join(f.DownloadDir, path.Base(url))
+	partial := target + ".part"
+
+	rangesSupported := true
+	var lastErr error
+	for attempt := 0; attempt <= f.RetryAttempts; attempt++ {
+		if attempt > 0 {
+			f.logger.Warnf("Download of %s failed (attempt %d/%d): %v", url, attempt, f.RetryAttempts+1, lastErr)
+			if f.RetryDelay > 0 {
+				time.Sleep(f.RetryDelay)
+			}
+		}
+
+		var offset int64
+		if info, err := os.Stat(partial); err == nil && rangesSupported {
+			offset = info.Size()
+		}
+
+		complete, supported, err := f.downloadChunk(url, partial, offset)
+		if err == ErrNotModified {
+			return "", err
+		}
+		// Once the server has ignored a Range request, every retry starts over
+		rangesSupported = rangesSupported && supported
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		if !complete {
+			lastErr = errors.New("connection closed before the full body was received")
+			continue
+		}
+
+		if err := os.Rename(partial, target); err != nil {
+			return "", fmt.Errorf("failed to finalize download: %w", err)
+		}
+		return target, nil
+	}
+
+	return "", fmt.Errorf("download failed after %d attempts: %w", f.RetryAttempts+1, lastErr)
+}
+
+// downloadChunk fetches url from offset, appending to partial. It reports whether
+// the body is now complete and whether byte ranges may still be used; only a
+// server answering a Range request with the full body rules them out.
+func (f *MTGFetcher) downloadChunk(url, partial string, offset int64) (complete, rangesSupported bool, err error) {
+	resp, err := f.getRange(url, offset)
+	if err != nil {
+		return false, true, err
+	}
+	defer resp.Body.Close()
+
+	rangesSupported = true
+	flags := os.O_CREATE | os.O_WRONLY
+	switch {
+	case resp.StatusCode == http.StatusPartialContent && offset > 0:
+		flags |= os.O_APPEND
+		f.logger.Infof("Resuming download of %s at byte %d", url, offset)
+	case resp.StatusCode == http.StatusOK:
+		// Either a fresh download or the server ignored the Range header
+		flags |= os.O_TRUNC
+		if offset > 0 {
+			f.logger.Infof("Server ignored the Range request for %s, downloading from the start", url)
+			rangesSupported = false
+		}
+		offset = 0
+	case resp.StatusCode == http.StatusRequestedRangeNotSatisfiable && offset > 0:
+		// The partial file already holds the whole body when its size is the
+		// one in "Content-Range: bytes */<size>"; otherwise start over
+		if size, ok := unsatisfiedRangeSize(resp.Header.Get("Content-Range")); ok && size == offset {
+			f.logger.Infof("Download of %s was already complete", url)
+			return true, true, nil
+		}
+		return false, false, fmt.Errorf("partial download of %d bytes doesn't match the file", offset)
+	default:
+		return false, true, fmt.Errorf("unexpected status code: %d", resp.StatusCode)
+	}
+
+	file, err := os.OpenFile(partial, flags, 0644)
+	if err != nil {
+		return false, rangesSupported, fmt.Errorf("failed to open download file: %w", err)
+	}
+	defer file.Close()
+
+	// Progress covers the whole file, including what a resumed download already has
+	total := int64(-1)
+	if resp.ContentLength >= 0 {
+		total = offset + resp.ContentLength
+	}
+	written, err := io.Copy(file, f.withProgressFrom(resp.Body, offset, total))
+	if err != nil {
+		return false, rangesSupported, fmt.Errorf("failed to write download: %w", err)
+	}
+
+	// Without a Content-Length, a clean EOF is the only completeness signal
+	complete = resp.ContentLength < 0 || written == resp.ContentLength
+	return complete, rangesSupported, nil
+}
+
+// unsatisfiedRangeSize parses the complete length from a 416 response's
+// "Content-Range: bytes */<size>" header
+func unsatisfiedRangeSize(contentRange string) (int64, bool) {
+	size, ok := strings.CutPrefix(contentRange, "bytes */")
+	if !ok {
+		return 0, false
+	}
+	n, err := strconv.ParseInt(size, 10, 64)
+	return n, err == nil
+}
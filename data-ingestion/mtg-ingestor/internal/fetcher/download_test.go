@@ -0,0 +1,140 @@
+package fetcher
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// droppedBody returns data and then fails like a reset connection
+type droppedBody struct {
+	r io.Reader
+}
+
+func (d *droppedBody) Read(p []byte) (int, error) {
+	n, err := d.r.Read(p)
+	if err == io.EOF {
+		return n, errors.New("connection reset by peer")
+	}
+	return n, err
+}
+
+func (d *droppedBody) Close() error { return nil }
+
+func newDownloadFetcher(t *testing.T, doer doerFunc) *MTGFetcher {
+	f := NewMTGFetcherWithClient(quietLogger(), doer)
+	f.DownloadDir = t.TempDir()
+	f.RetryAttempts = 3
+	return f
+}
+
+func TestDownloadResumableKeepsRangesAfterDroppedConnection(t *testing.T) {
+	const body = "0123456789"
+	var ranges []string
+	f := newDownloadFetcher(t, func(req *http.Request) (*http.Response, error) {
+		ranges = append(ranges, req.Header.Get("Range"))
+		if len(ranges) == 1 {
+			// No Accept-Ranges header, and the connection drops halfway
+			resp := response(http.StatusOK, nil)
+			resp.Body = &droppedBody{r: strings.NewReader(body[:4])}
+			resp.ContentLength = int64(len(body))
+			return resp, nil
+		}
+		var offset int
+		fmt.Sscanf(req.Header.Get("Range"), "bytes=%d-", &offset)
+		return response(http.StatusPartialContent, []byte(body[offset:])), nil
+	})
+
+	path, err := f.downloadResumable("https://example.test/AllPrices.json.gz")
+	if err != nil {
+		t.Fatalf("downloadResumable: %v", err)
+	}
+	if got := strings.Join(ranges, ","); got != ",bytes=4-" {
+		t.Errorf("Range headers = %q, want a resume at byte 4", got)
+	}
+	if data, _ := os.ReadFile(path); string(data) != body {
+		t.Errorf("downloaded %q, want %q", data, body)
+	}
+}
+
+func TestDownloadResumableTreatsUnsatisfiableRangeOnCompleteFileAsDone(t *testing.T) {
+	const body = "0123456789"
+	f := newDownloadFetcher(t, func(req *http.Request) (*http.Response, error) {
+		if req.Header.Get("Range") != "bytes=10-" {
+			t.Errorf("unexpected request with Range %q", req.Header.Get("Range"))
+		}
+		return response(http.StatusRequestedRangeNotSatisfiable, nil, "Content-Range", "bytes */10"), nil
+	})
+	partial := filepath.Join(f.DownloadDir, "AllPrices.json.gz.part")
+	if err := os.WriteFile(partial, []byte(body), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	path, err := f.downloadResumable("https://example.test/AllPrices.json.gz")
+	if err != nil {
+		t.Fatalf("downloadResumable: %v", err)
+	}
+	if data, _ := os.ReadFile(path); string(data) != body {
+		t.Errorf("downloaded %q, want %q", data, body)
+	}
+}
+
+func TestDownloadResumableRestartsWhenServerIgnoresRanges(t *testing.T) {
+	const body = "0123456789"
+	var ranges []string
+	f := newDownloadFetcher(t, func(req *http.Request) (*http.Response, error) {
+		ranges = append(ranges, req.Header.Get("Range"))
+		resp := response(http.StatusOK, nil)
+		resp.ContentLength = int64(len(body))
+		if len(ranges) < 3 {
+			resp.Body = &droppedBody{r: strings.NewReader(body[:4])}
+		} else {
+			resp.Body = io.NopCloser(strings.NewReader(body))
+		}
+		return resp, nil
+	})
+
+	path, err := f.downloadResumable("https://example.test/AllPrices.json.gz")
+	if err != nil {
+		t.Fatalf("downloadResumable: %v", err)
+	}
+	if got := strings.Join(ranges, ","); got != ",bytes=4-," {
+		t.Errorf("Range headers = %q, want no Range once the server ignored one", got)
+	}
+	if data, _ := os.ReadFile(path); string(data) != body {
+		t.Errorf("downloaded %q, want %q", data, body)
+	}
+}
+
+func TestDownloadResumableReportsProgressOfTheWholeFile(t *testing.T) {
+	const body = "0123456789"
+	attempts := 0
+	f := newDownloadFetcher(t, func(req *http.Request) (*http.Response, error) {
+		attempts++
+		if attempts == 1 {
+			resp := response(http.StatusOK, nil)
+			resp.Body = &droppedBody{r: strings.NewReader(body[:4])}
+			resp.ContentLength = int64(len(body))
+			return resp, nil
+		}
+		return response(http.StatusPartialContent, []byte(body[4:])), nil
+	})
+	type report struct{ read, total int64 }
+	var reports []report
+	f.Progress = func(read, total int64) { reports = append(reports, report{read, total}) }
+
+	if _, err := f.downloadResumable("https://example.test/AllPrices.json.gz"); err != nil {
+		t.Fatalf("downloadResumable: %v", err)
+	}
+	if len(reports) == 0 {
+		t.Fatal("no progress reported during the download")
+	}
+	if last := reports[len(reports)-1]; last != (report{10, 10}) {
+		t.Errorf("last progress report = %+v, want 10 of 10 bytes", last)
+	}
+}
@@ -1,62 +1,241 @@
 package fetcher
 
 import (
-	"compress/gzip"
+	"bytes"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
+	"os"
+	"sort"
+	"strings"
 	"time"
 
+	"github.com/google/uuid"
 	"github.com/mtg/mtg-ingestor/internal/models"
 	"github.com/sirupsen/logrus"
 )
 
 type MTGFetcher struct {
 	logger  *logrus.Logger
-	client  *http.Client
+	client  httpDoer
+	timeout time.Duration
 	baseURL string
+
+	// DedupeUnchanged collapses runs of identical prices so only the first
+	// and last date of each run are returned by FetchPrices
+	DedupeUnchanged bool
+
+	// DedupeDuplicates runs DedupePrices over the flattened price records
+	DedupeDuplicates bool
+
+	// VerifyChecksums checks each archive against its .sha256 sidecar
+	VerifyChecksums bool
+
+	// DownloadDir, when set, makes FetchPrices download to disk so an
+	// interrupted transfer can resume with a Range request
+	DownloadDir   string
+	RetryAttempts int
+	RetryDelay    time.Duration
+
+	// Progress, when set, is called periodically while archives download
+	Progress ProgressFunc
+
+	lastMeta    Meta
+	cache       *HTTPCache
+	compression string
+	// replay maps MTGJSON file names to local copies read instead of downloading
+	replay map[string]string
+}
+
+// Meta is the MTGJSON build version block included in every file
+type Meta struct {
+	Version string `json:"version"`
+	Date    string `json:"date"`
+}
+
+// IsZero reports whether no meta has been recorded
+func (m Meta) IsZero() bool {
+	return m.Version == "" && m.Date == ""
 }
 
 func NewMTGFetcher(logger *logrus.Logger) *MTGFetcher {
+	return NewMTGFetcherWithHTTPConfig(logger, DefaultHTTPConfig())
+}
+
+// NewMTGFetcherWithHTTPConfig creates a fetcher with tuned HTTP timeouts
+func NewMTGFetcherWithHTTPConfig(logger *logrus.Logger, config HTTPConfig) *MTGFetcher {
+	config = config.withDefaults()
 	return &MTGFetcher{
 		logger:  logger,
-		client:  &http.Client{Timeout: 30 * time.Minute},
+		client:  newHTTPClient(config),
+		timeout: config.Timeout,
 		baseURL: "https://mtgjson.com/api/v5",
 	}
 }
 
-// FetchAllSets fetches all MTG sets data
-func (f *MTGFetcher) FetchAllSets() (map[string]models.Set, error) {
-	url := fmt.Sprintf("%s/AllSets.json.gz", f.baseURL)
-	f.logger.Infof("Fetching MTG data from %s", url)
+// NewMTGFetcherWithClient creates a fetcher that sends its requests through
+// client, e.g. a stub serving canned archives; the default timeouts apply
+func NewMTGFetcherWithClient(logger *logrus.Logger, client httpDoer) *MTGFetcher {
+	f := NewMTGFetcher(logger)
+	f.client = client
+	return f
+}
+
+// SetHTTPCache enables conditional requests using the given validator cache
+func (f *MTGFetcher) SetHTTPCache(cache *HTTPCache) {
+	f.cache = cache
+}
+
+// get performs a GET, sending cached validators and recording new ones as
+// pending. It returns ErrNotModified when the server answers 304.
+func (f *MTGFetcher) get(url string) (*http.Response, error) {
+	return f.getRange(url, 0)
+}
 
-	resp, err := f.client.Get(url)
+// getRange is get starting at byte offset; resumed requests skip the
+// conditional headers since a partial file is already being downloaded
+func (f *MTGFetcher) getRange(url string, offset int64) (*http.Response, error) {
+	req, err := http.NewRequest(http.MethodGet, url, nil)
 	if err != nil {
-		return nil, fmt.Errorf("failed to fetch data: %w", err)
+		return nil, err
+	}
+
+	if offset > 0 {
+		req.Header.Set("Range", fmt.Sprintf("bytes=%d-", offset))
+	} else if f.cache != nil {
+		if entry, ok := f.cache.get(url); ok {
+			if entry.ETag != "" {
+				req.Header.Set("If-None-Match", entry.ETag)
+			}
+			if entry.LastModified != "" {
+				req.Header.Set("If-Modified-Since", entry.LastModified)
+			}
+		}
+	}
+
+	resp, err := f.do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode == http.StatusNotModified {
+		resp.Body.Close()
+		f.logger.Infof("%s not modified since last fetch", url)
+		return nil, ErrNotModified
+	}
+
+	if f.cache != nil && resp.StatusCode == http.StatusOK {
+		f.cache.setPending(url, CacheEntry{
+			ETag:         resp.Header.Get("ETag"),
+			LastModified: resp.Header.Get("Last-Modified"),
+		})
+	}
+
+	return resp, nil
+}
+
+// CommitValidators keeps the validators of the last download of an MTGJSON
+// file, e.g. "AllSets.json", once its data has been fully ingested
+func (f *MTGFetcher) CommitValidators(name string) {
+	if f.cache != nil {
+		f.cache.Commit(f.archiveURL(name))
+	}
+}
+
+// LoadMeta reads a previously saved meta block, returning a zero Meta if the file doesn't exist
+func LoadMeta(path string) (Meta, error) {
+	var meta Meta
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return meta, nil
+	}
+	if err != nil {
+		return meta, fmt.Errorf("failed to read meta file: %w", err)
+	}
+	if err := json.Unmarshal(data, &meta); err != nil {
+		return meta, fmt.Errorf("failed to parse meta file: %w", err)
+	}
+	return meta, nil
+}
+
+// SaveMeta persists a meta block for the next run's HasChangedSince check
+func SaveMeta(path string, meta Meta) error {
+	data, err := json.Marshal(meta)
+	if err != nil {
+		return fmt.Errorf("failed to marshal meta: %w", err)
+	}
+	return os.WriteFile(path, data, 0644)
+}
+
+// LastMeta returns the most recent MTGJSON meta block seen by this fetcher
+func (f *MTGFetcher) LastMeta() Meta {
+	return f.lastMeta
+}
+
+// FetchMeta fetches only the small MTGJSON Meta.json file
+func (f *MTGFetcher) FetchMeta() (Meta, error) {
+	url := fmt.Sprintf("%s/Meta.json", f.baseURL)
+
+	resp, err := f.getURL(url)
+	if err != nil {
+		return Meta{}, fmt.Errorf("failed to fetch meta: %w", err)
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("unexpected status code: %d", resp.StatusCode)
+		return Meta{}, fmt.Errorf("unexpected status code: %d", resp.StatusCode)
+	}
+
+	var metaResponse struct {
+		Meta Meta `json:"meta"`
+		Data Meta `json:"data"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&metaResponse); err != nil {
+		return Meta{}, fmt.Errorf("failed to unmarshal meta: %w", err)
+	}
+
+	meta := metaResponse.Data
+	if meta.IsZero() {
+		meta = metaResponse.Meta
 	}
+	f.lastMeta = meta
+	return meta, nil
+}
 
-	// Decompress gzip
-	gzReader, err := gzip.NewReader(resp.Body)
+// HasChangedSince reports whether MTGJSON has published a new build since lastMeta.
+// It only downloads Meta.json, so the driver can skip full fetches cheaply.
+func (f *MTGFetcher) HasChangedSince(lastMeta Meta) (bool, error) {
+	if lastMeta.IsZero() {
+		return true, nil
+	}
+
+	meta, err := f.FetchMeta()
 	if err != nil {
-		return nil, fmt.Errorf("failed to create gzip reader: %w", err)
+		return false, err
 	}
-	defer gzReader.Close()
 
-	// Read and parse JSON
-	data, err := io.ReadAll(gzReader)
+	return meta != lastMeta, nil
+}
+
+// FetchAllSets fetches all MTG sets data
+func (f *MTGFetcher) FetchAllSets() (map[string]models.Set, error) {
+	sets, _, err := f.FetchAllSetsWithStats()
+	return sets, err
+}
+
+// FetchAllSetsWithStats fetches all MTG sets data along with download and parse stats
+func (f *MTGFetcher) FetchAllSetsWithStats() (map[string]models.Set, FetchStats, error) {
+	data, stats, err := f.fetchArchive(AllSetsFile, "MTG data")
 	if err != nil {
-		return nil, fmt.Errorf("failed to read response: %w", err)
+		return nil, stats, err
 	}
 
+	parseStart := time.Now()
 	var allSets map[string]models.Set
 	if err := json.Unmarshal(data, &allSets); err != nil {
-		return nil, fmt.Errorf("failed to unmarshal JSON: %w", err)
+		return nil, stats, fmt.Errorf("failed to unmarshal JSON: %w", err)
 	}
 
 	// Add processed timestamp to each set
@@ -67,55 +246,90 @@ func (f *MTGFetcher) FetchAllSets() (map[string]models.Set, error) {
 		for i := range set.Cards {
 			set.Cards[i].ProcessedAt = now
 		}
+		attachFaces(set.Cards)
 		allSets[code] = set
 	}
 
+	stats.ParseDuration = time.Since(parseStart)
+	stats.Records = len(allSets)
+
 	f.logger.Infof("Successfully fetched %d sets", len(allSets))
-	return allSets, nil
+	return allSets, stats, nil
 }
 
-// FetchAtomicCards fetches individual card data
-func (f *MTGFetcher) FetchAtomicCards() (map[string]models.Card, error) {
-	url := fmt.Sprintf("%s/AtomicCards.json.gz", f.baseURL)
-	f.logger.Infof("Fetching atomic cards from %s", url)
+// fetchArchive downloads and decompresses an MTGJSON file, e.g. "AllSets.json",
+// or reads it from its replay file when one is set
+func (f *MTGFetcher) fetchArchive(name, what string) ([]byte, FetchStats, error) {
+	if data, stats, ok, err := f.readReplay(name); ok {
+		return data, stats, err
+	}
 
-	resp, err := f.client.Get(url)
+	var stats FetchStats
+	start := time.Now()
+	url := f.archiveURL(name)
+	f.logger.Infof("Fetching %s from %s", what, url)
+
+	resp, err := f.get(url)
+	if err == ErrNotModified {
+		return nil, stats, err
+	}
 	if err != nil {
-		return nil, fmt.Errorf("failed to fetch atomic cards: %w", err)
+		return nil, stats, fmt.Errorf("failed to fetch %s: %w", what, err)
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("unexpected status code: %d", resp.StatusCode)
+		return nil, stats, fmt.Errorf("unexpected status code: %d", resp.StatusCode)
 	}
 
-	gzReader, err := gzip.NewReader(resp.Body)
+	body := &countingReader{r: f.withProgress(resp.Body, resp.ContentLength)}
+	data, err := f.readArchiveBody(url, body, resp.Header.Get("Content-Type"))
 	if err != nil {
-		return nil, fmt.Errorf("failed to create gzip reader: %w", err)
+		return nil, stats, err
 	}
-	defer gzReader.Close()
+	stats.recordDownload(start, body, data)
+	return data, stats, nil
+}
+
+// FetchAtomicCards fetches individual card data
+func (f *MTGFetcher) FetchAtomicCards() (map[string]models.Card, error) {
+	cards, _, err := f.FetchAtomicCardsWithStats()
+	return cards, err
+}
 
-	data, err := io.ReadAll(gzReader)
+// FetchAtomicCardsWithStats fetches individual card data along with download and parse stats
+func (f *MTGFetcher) FetchAtomicCardsWithStats() (map[string]models.Card, FetchStats, error) {
+	data, stats, err := f.fetchArchive(AtomicCardsFile, "atomic cards")
 	if err != nil {
-		return nil, fmt.Errorf("failed to read response: %w", err)
+		return nil, stats, err
 	}
+	parseStart := time.Now()
 
 	// AtomicCards has structure: {"meta": {}, "data": {"cardName": [cardVariants]}}
 	var atomicResponse struct {
-		Meta interface{}                `json:"meta"`
+		Meta Meta                     `json:"meta"`
 		Data map[string][]interface{} `json:"data"`
 	}
 	
 	if err := json.Unmarshal(data, &atomicResponse); err != nil {
-		return nil, fmt.Errorf("failed to unmarshal atomic cards: %w", err)
+		return nil, stats, fmt.Errorf("failed to unmarshal atomic cards: %w", err)
 	}
+	f.lastMeta = atomicResponse.Meta
 
 	// Process each card and its variants
 	cards := make(map[string]models.Card)
 	now := time.Now()
-	cardCount := 0
 	
-	for cardName, variants := range atomicResponse.Data {
+	// Names are walked in sorted order so a key collision always keeps the
+	// same card, the first name alphabetically
+	names := make([]string, 0, len(atomicResponse.Data))
+	for cardName := range atomicResponse.Data {
+		names = append(names, cardName)
+	}
+	sort.Strings(names)
+
+	for _, cardName := range names {
+		variants := atomicResponse.Data[cardName]
 		// Take the first variant as the canonical version
 		if len(variants) > 0 {
 			cardBytes, err := json.Marshal(variants[0])
@@ -134,20 +348,91 @@ func (f *MTGFetcher) FetchAtomicCards() (map[string]models.Card, error) {
 			if card.Name == "" {
 				card.Name = cardName
 			}
+
+			// Multi-face cards list one entry per face under the shared name
+			if models.IsMultiFaceLayout(card.Layout) && len(variants) > 1 {
+				for _, variant := range variants {
+					faceBytes, err := json.Marshal(variant)
+					if err != nil {
+						continue
+					}
+					var face models.Card
+					if err := json.Unmarshal(faceBytes, &face); err != nil {
+						continue
+					}
+					card.Faces = append(card.Faces, face.Face())
+				}
+			}
 			
-			// Generate a UUID if not present
+			// Atomic cards have no printing UUID, so derive a stable one from the name
 			if card.UUID == "" {
-				card.UUID = fmt.Sprintf("%s_%d", cardName, cardCount)
+				card.UUID = AtomicCardUUID(cardName)
 			}
 			
 			card.ProcessedAt = now
+			// Keys are derived, so make a collision visible instead of letting
+			// one card silently replace another
+			if existing, ok := cards[card.UUID]; ok {
+				stats.DuplicateKeys++
+				f.logger.Warnf("Duplicate card key %s: dropping %q, already used by %q",
+					card.UUID, cardName, existing.Name)
+				continue
+			}
 			cards[card.UUID] = card
-			cardCount++
 		}
 	}
+	if stats.DuplicateKeys > 0 {
+		f.logger.Warnf("Dropped %d atomic cards with duplicate keys", stats.DuplicateKeys)
+	}
+
+	stats.ParseDuration = time.Since(parseStart)
+	stats.Records = len(cards)
 
 	f.logger.Infof("Successfully fetched %d unique cards", len(cards))
-	return cards, nil
+	return cards, stats, nil
+}
+
+// atomicCardNamespace scopes the name-based UUIDs of atomic cards
+var atomicCardNamespace = uuid.NewSHA1(uuid.NameSpaceURL, []byte("https://mtgjson.com/api/v5/AtomicCards"))
+
+// AtomicCardUUID returns the deterministic UUIDv5 used for an atomic card
+// without an MTGJSON UUID, so every run keys the card the same way
+func AtomicCardUUID(name string) string {
+	return uuid.NewSHA1(atomicCardNamespace, []byte(name)).String()
+}
+
+// attachFaces populates Faces on multi-face cards from the per-face records MTGJSON
+// emits for each printing. Faces are linked through otherFaceIds when present, falling
+// back to the shared name and collector number (ignoring a side suffix).
+func attachFaces(cards []models.Card) {
+	groups := make(map[string][]int)
+	for i, card := range cards {
+		if !models.IsMultiFaceLayout(card.Layout) {
+			continue
+		}
+
+		key := card.Name + "|" + strings.TrimRight(card.Number, "abcde")
+		if len(card.OtherFaceIDs) > 0 {
+			ids := append([]string{card.UUID}, card.OtherFaceIDs...)
+			sort.Strings(ids)
+			key = strings.Join(ids, "|")
+		}
+		groups[key] = append(groups[key], i)
+	}
+
+	for _, idxs := range groups {
+		if len(idxs) < 2 {
+			continue
+		}
+		faces := make([]models.CardFace, 0, len(idxs))
+		for _, i := range idxs {
+			faces = append(faces, cards[i].Face())
+		}
+		sort.SliceStable(faces, func(a, b int) bool { return faces[a].Side < faces[b].Side })
+		for _, i := range idxs {
+			cards[i].Faces = faces
+		}
+	}
 }
 
 // PriceData represents individual price data for a card
@@ -156,73 +441,178 @@ type PriceData struct {
 	Format       string    `json:"format"`      // paper, mtgo
 	Source       string    `json:"source"`      // cardkingdom, tcgplayer, etc
 	Type         string    `json:"type"`        // retail, buylist
-	Foil         bool      `json:"foil"`
+	Finish       string    `json:"finish"`      // normal, foil, etched
+	Foil         bool      `json:"foil"`        // Finish is "foil", kept for existing consumers
 	Date         string    `json:"date"`
 	Price        float64   `json:"price"`
 }
 
 // FetchPrices fetches price data and returns individual price records
 func (f *MTGFetcher) FetchPrices() ([]PriceData, error) {
-	url := fmt.Sprintf("%s/AllPrices.json.gz", f.baseURL)
-	f.logger.Infof("Fetching price data from %s", url)
+	prices, _, err := f.FetchPricesWithStats()
+	return prices, err
+}
 
-	resp, err := f.client.Get(url)
+// FetchPricesWithStats fetches price records along with download and parse stats
+func (f *MTGFetcher) FetchPricesWithStats() ([]PriceData, FetchStats, error) {
+	out := make(chan PriceData, 1024)
+	done := make(chan struct{})
+	var prices []PriceData
+	go func() {
+		defer close(done)
+		for p := range out {
+			prices = append(prices, p)
+		}
+	}()
+
+	stats, err := f.StreamPrices(out)
+	<-done
 	if err != nil {
-		return nil, fmt.Errorf("failed to fetch prices: %w", err)
+		return nil, stats, err
 	}
-	defer resp.Body.Close()
+	return prices, stats, nil
+}
 
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("unexpected status code: %d", resp.StatusCode)
-	}
+// StreamPrices decodes AllPrices one card at a time, sending each price record
+// on out as it is read so publishing can start before the file is flattened,
+// and closes out when done. Records sent before a decode error are not taken
+// back. DedupeDuplicates and DedupeUnchanged need every record at once, so with
+// either set nothing is sent until decoding finishes. ParseDuration includes
+// any time spent waiting on the receiver.
+func (f *MTGFetcher) StreamPrices(out chan<- PriceData) (FetchStats, error) {
+	defer close(out)
 
-	gzReader, err := gzip.NewReader(resp.Body)
+	data, stats, err := f.fetchPriceArchive()
 	if err != nil {
-		return nil, fmt.Errorf("failed to create gzip reader: %w", err)
+		return stats, err
 	}
-	defer gzReader.Close()
+	parseStart := time.Now()
 
-	data, err := io.ReadAll(gzReader)
+	collect := f.DedupeDuplicates || f.DedupeUnchanged
+	var collected []PriceData
+	emit := func(p PriceData) {
+		if collect {
+			collected = append(collected, p)
+			return
+		}
+		out <- p
+		stats.Records++
+	}
+	meta, err := decodePrices(data, emit)
 	if err != nil {
-		return nil, fmt.Errorf("failed to read response: %w", err)
+		return stats, fmt.Errorf("failed to unmarshal prices: %w", err)
+	}
+	f.lastMeta = meta
+
+	if collect {
+		if f.DedupeDuplicates {
+			var duplicates int
+			collected, duplicates = DedupePrices(collected)
+			f.logger.Infof("Removed %d duplicate price records", duplicates)
+		}
+		if f.DedupeUnchanged {
+			before := len(collected)
+			collected = CollapseUnchangedPrices(collected)
+			f.logger.Infof("Collapsed unchanged prices from %d to %d records", before, len(collected))
+		}
+		for _, p := range collected {
+			out <- p
+		}
+		stats.Records = len(collected)
 	}
 
-	// Parse the structure: {"meta": {}, "data": {cardUUID: {format: {source: {type: {foilStatus: {date: price}}}}}}}
-	var priceResponse struct {
-		Meta interface{}            `json:"meta"`
-		Data map[string]interface{} `json:"data"`
+	stats.ParseDuration = time.Since(parseStart)
+	f.logger.Infof("Successfully fetched %d price records", stats.Records)
+	return stats, nil
+}
+
+// decodePrices walks {"meta": {}, "data": {cardUUID: {...}}} with a streaming
+// decoder, flattening and emitting one card's prices at a time
+func decodePrices(data []byte, emit func(PriceData)) (Meta, error) {
+	var meta Meta
+	dec := json.NewDecoder(bytes.NewReader(data))
+	if err := expectDelim(dec, '{'); err != nil {
+		return meta, err
 	}
-	
-	if err := json.Unmarshal(data, &priceResponse); err != nil {
-		return nil, fmt.Errorf("failed to unmarshal prices: %w", err)
+	for dec.More() {
+		key, err := dec.Token()
+		if err != nil {
+			return meta, err
+		}
+		switch key {
+		case "meta":
+			if err := dec.Decode(&meta); err != nil {
+				return meta, err
+			}
+		case "data":
+			if err := expectDelim(dec, '{'); err != nil {
+				return meta, err
+			}
+			for dec.More() {
+				token, err := dec.Token()
+				if err != nil {
+					return meta, err
+				}
+				cardUUID, _ := token.(string)
+				var formatData interface{}
+				if err := dec.Decode(&formatData); err != nil {
+					return meta, err
+				}
+				flattenCardPrices(cardUUID, formatData, emit)
+			}
+			if err := expectDelim(dec, '}'); err != nil {
+				return meta, err
+			}
+		default:
+			var skip json.RawMessage
+			if err := dec.Decode(&skip); err != nil {
+				return meta, err
+			}
+		}
 	}
+	return meta, expectDelim(dec, '}')
+}
 
-	// Flatten price data into individual records
-	var prices []PriceData
-	for cardUUID, formatData := range priceResponse.Data {
-		if formatMap, ok := formatData.(map[string]interface{}); ok {
-			for format, sourceData := range formatMap {
-				if sourceMap, ok := sourceData.(map[string]interface{}); ok {
-					for source, typeData := range sourceMap {
-						if typeMap, ok := typeData.(map[string]interface{}); ok {
-							for priceType, foilData := range typeMap {
-								if foilMap, ok := foilData.(map[string]interface{}); ok {
-									for foilStatus, dateData := range foilMap {
-										isFoil := foilStatus == "foil"
-										if dateMap, ok := dateData.(map[string]interface{}); ok {
-											for date, price := range dateMap {
-												if priceFloat, ok := price.(float64); ok {
-													prices = append(prices, PriceData{
-														CardUUID: cardUUID,
-														Format:   format,
-														Source:   source,
-														Type:     priceType,
-														Foil:     isFoil,
-														Date:     date,
-														Price:    priceFloat,
-													})
-												}
-											}
+// expectDelim reads the next token and fails unless it is want
+func expectDelim(dec *json.Decoder, want json.Delim) error {
+	token, err := dec.Token()
+	if err != nil {
+		return err
+	}
+	if token != want {
+		return fmt.Errorf("expected %v, got %v", want, token)
+	}
+	return nil
+}
+
+// flattenCardPrices emits one record per price in a card's
+// {format: {source: {type: {finish: {date: price}}}}} tree, skipping
+// anything that isn't in that shape
+func flattenCardPrices(cardUUID string, formatData interface{}, emit func(PriceData)) {
+	formatMap, ok := formatData.(map[string]interface{})
+	if !ok {
+		return
+	}
+	for format, sourceData := range formatMap {
+		if sourceMap, ok := sourceData.(map[string]interface{}); ok {
+			for source, typeData := range sourceMap {
+				if typeMap, ok := typeData.(map[string]interface{}); ok {
+					for priceType, foilData := range typeMap {
+						if foilMap, ok := foilData.(map[string]interface{}); ok {
+							for finish, dateData := range foilMap {
+								if dateMap, ok := dateData.(map[string]interface{}); ok {
+									for date, price := range dateMap {
+										if priceFloat, ok := price.(float64); ok {
+											emit(PriceData{
+												CardUUID: cardUUID,
+												Format:   format,
+												Source:   source,
+												Type:     priceType,
+												Finish:   finish,
+												Foil:     finish == "foil",
+												Date:     date,
+												Price:    priceFloat,
+											})
 										}
 									}
 								}
@@ -233,7 +623,173 @@ func (f *MTGFetcher) FetchPrices() ([]PriceData, error) {
 			}
 		}
 	}
+}
+
+// fetchPriceArchive reads AllPrices from its replay file, the resumable download
+// directory or straight from the network
+func (f *MTGFetcher) fetchPriceArchive() ([]byte, FetchStats, error) {
+	if data, stats, ok, err := f.readReplay(AllPricesFile); ok {
+		return data, stats, err
+	}
+
+	var stats FetchStats
+	start := time.Now()
+	url := f.archiveURL(AllPricesFile)
+	f.logger.Infof("Fetching price data from %s", url)
+
+	var body io.Reader
+	var contentType string
+	if f.DownloadDir != "" {
+		// The price file is large enough that restarting from zero on a dropped
+		// connection is costly, so it goes to disk and resumes
+		path, err := f.downloadResumable(url)
+		if err == ErrNotModified {
+			return nil, stats, err
+		}
+		if err != nil {
+			return nil, stats, fmt.Errorf("failed to fetch prices: %w", err)
+		}
+		// Progress was reported while downloading, so the local read is silent
+		file, err := os.Open(path)
+		if err != nil {
+			return nil, stats, fmt.Errorf("failed to open downloaded prices: %w", err)
+		}
+		defer file.Close()
+		body = file
+	} else {
+		resp, err := f.get(url)
+		if err == ErrNotModified {
+			return nil, stats, err
+		}
+		if err != nil {
+			return nil, stats, fmt.Errorf("failed to fetch prices: %w", err)
+		}
+		defer resp.Body.Close()
+
+		if resp.StatusCode != http.StatusOK {
+			return nil, stats, fmt.Errorf("unexpected status code: %d", resp.StatusCode)
+		}
+		body = f.withProgress(resp.Body, resp.ContentLength)
+		contentType = resp.Header.Get("Content-Type")
+	}
+
+	counter := &countingReader{r: body}
+	data, err := f.readArchiveBody(url, counter, contentType)
+	if err != nil {
+		return nil, stats, err
+	}
+	stats.recordDownload(start, counter, data)
+	return data, stats, nil
+}
+
+// priceKey identifies a single price observation
+type priceKey struct {
+	CardUUID string
+	Format   string
+	Source   string
+	Type     string
+	Finish   string
+	Date     string
+}
+
+// DedupePrices keeps one record per (card, format, source, type, finish, date),
+// the last one seen winning, and returns the number of duplicates removed
+func DedupePrices(prices []PriceData) ([]PriceData, int) {
+	positions := make(map[priceKey]int, len(prices))
+	deduped := make([]PriceData, 0, len(prices))
+	for _, p := range prices {
+		key := priceKey{p.CardUUID, p.Format, p.Source, p.Type, p.Finish, p.Date}
+		if pos, ok := positions[key]; ok {
+			deduped[pos] = p
+			continue
+		}
+		positions[key] = len(deduped)
+		deduped = append(deduped, p)
+	}
+	return deduped, len(prices) - len(deduped)
+}
+
+// priceSeriesKey identifies a price history for one card/format/source/type/finish
+type priceSeriesKey struct {
+	CardUUID string
+	Format   string
+	Source   string
+	Type     string
+	Finish   string
+}
+
+// CollapseUnchangedPrices drops records whose price equals both the previous and next
+// date in the same series, keeping the first and last date of every unchanged run
+func CollapseUnchangedPrices(prices []PriceData) []PriceData {
+	series := make(map[priceSeriesKey][]PriceData)
+	var order []priceSeriesKey
+	for _, p := range prices {
+		key := priceSeriesKey{p.CardUUID, p.Format, p.Source, p.Type, p.Finish}
+		if _, ok := series[key]; !ok {
+			order = append(order, key)
+		}
+		series[key] = append(series[key], p)
+	}
+
+	collapsed := make([]PriceData, 0, len(prices))
+	for _, key := range order {
+		history := series[key]
+		// MTGJSON dates are YYYY-MM-DD so lexical order is chronological
+		sort.Slice(history, func(i, j int) bool { return history[i].Date < history[j].Date })
+
+		last := len(history) - 1
+		for i, p := range history {
+			if i == 0 || i == last ||
+				p.Price != history[i-1].Price || p.Price != history[i+1].Price {
+				collapsed = append(collapsed, p)
+			}
+		}
+	}
+
+	return collapsed
+}
 
-	f.logger.Infof("Successfully fetched %d price records", len(prices))
-	return prices, nil
-}
\ No newline at end of file
+// PriceChanges compares consecutive dates within each price series and returns
+// one change per date on which the price moved
+func PriceChanges(prices []PriceData) []models.PriceChange {
+	series := make(map[priceSeriesKey][]PriceData)
+	var order []priceSeriesKey
+	for _, p := range prices {
+		key := priceSeriesKey{p.CardUUID, p.Format, p.Source, p.Type, p.Finish}
+		if _, ok := series[key]; !ok {
+			order = append(order, key)
+		}
+		series[key] = append(series[key], p)
+	}
+
+	var changes []models.PriceChange
+	for _, key := range order {
+		history := series[key]
+		sort.Slice(history, func(i, j int) bool { return history[i].Date < history[j].Date })
+
+		for i := 1; i < len(history); i++ {
+			prev, curr := history[i-1], history[i]
+			if curr.Price == prev.Price {
+				continue
+			}
+			pct := 0.0
+			if prev.Price != 0 {
+				pct = (curr.Price - prev.Price) / prev.Price * 100
+			}
+			changes = append(changes, models.PriceChange{
+				CardUUID:  curr.CardUUID,
+				Format:    curr.Format,
+				Source:    curr.Source,
+				Type:      curr.Type,
+				Finish:    curr.Finish,
+				Foil:      curr.Foil,
+				OldPrice:  prev.Price,
+				NewPrice:  curr.Price,
+				PctChange: pct,
+				Date:      curr.Date,
+			})
+		}
+	}
+
+	return changes
+}
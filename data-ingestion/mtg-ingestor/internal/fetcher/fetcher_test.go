@@ -0,0 +1,263 @@
+package fetcher
+
+import (
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"net/http"
+	"path"
+	"sort"
+	"strings"
+	"testing"
+)
+
+func gzipped(t *testing.T, s string) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	if _, err := zw.Write([]byte(s)); err != nil {
+		t.Fatal(err)
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+// stubFetcher returns a fetcher whose requests for MTGJSON files are
+// answered by serve, keyed by file name such as "AllSets.json.gz"
+func stubFetcher(serve func(file string) *http.Response) *MTGFetcher {
+	return NewMTGFetcherWithClient(quietLogger(), doerFunc(func(req *http.Request) (*http.Response, error) {
+		return serve(path.Base(req.URL.Path)), nil
+	}))
+}
+
+// fetchCase is one canned answer for a fetch method
+type fetchCase struct {
+	name    string
+	status  int
+	body    []byte
+	wantErr string
+}
+
+// errorCases are the failures every fetch method must surface
+func errorCases(t *testing.T) []fetchCase {
+	return []fetchCase{
+		{name: "server error", status: http.StatusInternalServerError, wantErr: "unexpected status code: 500"},
+		{name: "not found", status: http.StatusNotFound, wantErr: "unexpected status code: 404"},
+		{name: "malformed json", status: http.StatusOK, body: gzipped(t, `{"data": {`), wantErr: "unmarshal"},
+		{name: "html error page", status: http.StatusOK, body: []byte("<!DOCTYPE html><html>busy</html>"), wantErr: "expected gzip"},
+	}
+}
+
+func TestFetchAllSets(t *testing.T) {
+	const allSets = `{
+		"DOM": {"code": "DOM", "name": "Dominaria", "totalSetSize": 2, "cards": [
+			{"uuid": "a", "name": "Llanowar Elves", "layout": "normal", "number": "168"},
+			{"uuid": "b", "name": "Shivan Fire", "layout": "normal", "number": "142"}
+		]},
+		"ISD": {"code": "ISD", "name": "Innistrad", "cards": [
+			{"uuid": "c", "name": "Delver of Secrets // Insectile Aberration", "faceName": "Delver of Secrets", "side": "a", "layout": "transform", "number": "51a", "otherFaceIds": ["d"]},
+			{"uuid": "d", "name": "Delver of Secrets // Insectile Aberration", "faceName": "Insectile Aberration", "side": "b", "layout": "transform", "number": "51b", "otherFaceIds": ["c"]}
+		]}
+	}`
+	tests := append([]fetchCase{{name: "ok", status: http.StatusOK, body: gzipped(t, allSets)}}, errorCases(t)...)
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := stubFetcher(func(file string) *http.Response {
+				if file != "AllSets.json.gz" {
+					t.Errorf("unexpected request for %s", file)
+				}
+				return response(tt.status, tt.body)
+			})
+
+			sets, stats, err := f.FetchAllSetsWithStats()
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("error = %v, want one containing %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("FetchAllSetsWithStats: %v", err)
+			}
+			if len(sets) != 2 || stats.Records != 2 || len(sets["DOM"].Cards) != 2 {
+				t.Fatalf("got %d sets (%d records), DOM with %d cards", len(sets), stats.Records, len(sets["DOM"].Cards))
+			}
+			if stats.BytesDownloaded != int64(len(tt.body)) {
+				t.Errorf("BytesDownloaded = %d, want %d", stats.BytesDownloaded, len(tt.body))
+			}
+			for _, card := range sets["ISD"].Cards {
+				if len(card.Faces) != 2 || card.Faces[0].Name != "Delver of Secrets" || card.Faces[1].Name != "Insectile Aberration" {
+					t.Errorf("%s faces = %+v", card.UUID, card.Faces)
+				}
+			}
+			if sets["DOM"].ProcessedAt.IsZero() || sets["DOM"].Cards[0].ProcessedAt.IsZero() {
+				t.Error("ProcessedAt not set")
+			}
+		})
+	}
+}
+
+func TestFetchAtomicCards(t *testing.T) {
+	const atomicCards = `{
+		"meta": {"version": "5.2.2", "date": "2024-01-01"},
+		"data": {
+			"Lightning Bolt": [{"name": "Lightning Bolt", "type": "Instant", "layout": "normal"}],
+			"Fire // Ice": [
+				{"name": "Fire // Ice", "faceName": "Fire", "side": "a", "type": "Instant", "layout": "split"},
+				{"name": "Fire // Ice", "faceName": "Ice", "side": "b", "type": "Instant", "layout": "split"}
+			],
+			"Empty": []
+		}
+	}`
+	tests := append([]fetchCase{{name: "ok", status: http.StatusOK, body: gzipped(t, atomicCards)}}, errorCases(t)...)
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := stubFetcher(func(file string) *http.Response {
+				if file != "AtomicCards.json.gz" {
+					t.Errorf("unexpected request for %s", file)
+				}
+				return response(tt.status, tt.body)
+			})
+
+			cards, err := f.FetchAtomicCards()
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("error = %v, want one containing %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("FetchAtomicCards: %v", err)
+			}
+			if len(cards) != 2 {
+				t.Fatalf("got %d cards, want 2", len(cards))
+			}
+			bolt, ok := cards[AtomicCardUUID("Lightning Bolt")]
+			if !ok || bolt.Name != "Lightning Bolt" {
+				t.Errorf("Lightning Bolt not keyed by its derived UUID: %+v", cards)
+			}
+			split := cards[AtomicCardUUID("Fire // Ice")]
+			if len(split.Faces) != 2 || split.Faces[0].Name != "Fire" || split.Faces[1].Name != "Ice" {
+				t.Errorf("Fire // Ice faces = %+v", split.Faces)
+			}
+			if meta := f.LastMeta(); meta.Version != "5.2.2" {
+				t.Errorf("LastMeta = %+v", meta)
+			}
+		})
+	}
+}
+
+func TestFetchPrices(t *testing.T) {
+	const allPrices = `{
+		"meta": {"version": "5.2.2", "date": "2024-01-02"},
+		"data": {
+			"card-1": {"paper": {"tcgplayer": {"retail": {
+				"normal": {"2024-01-01": 1.5, "2024-01-02": 1.75},
+				"foil": {"2024-01-01": 4}
+			}}}},
+			"card-2": {"mtgo": {"cardhoarder": {"retail": {"normal": {"2024-01-01": 0.02, "2024-01-02": "n/a"}}}}},
+			"card-3": {"paper": "unexpected"}
+		}
+	}`
+	tests := append([]fetchCase{{name: "ok", status: http.StatusOK, body: gzipped(t, allPrices)}}, errorCases(t)...)
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := stubFetcher(func(file string) *http.Response {
+				if file != "AllPrices.json.gz" {
+					t.Errorf("unexpected request for %s", file)
+				}
+				return response(tt.status, tt.body)
+			})
+
+			prices, err := f.FetchPrices()
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("error = %v, want one containing %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("FetchPrices: %v", err)
+			}
+
+			want := []PriceData{
+				{CardUUID: "card-1", Format: "paper", Source: "tcgplayer", Type: "retail", Finish: "normal", Date: "2024-01-01", Price: 1.5},
+				{CardUUID: "card-1", Format: "paper", Source: "tcgplayer", Type: "retail", Finish: "foil", Foil: true, Date: "2024-01-01", Price: 4},
+				{CardUUID: "card-1", Format: "paper", Source: "tcgplayer", Type: "retail", Finish: "normal", Date: "2024-01-02", Price: 1.75},
+				{CardUUID: "card-2", Format: "mtgo", Source: "cardhoarder", Type: "retail", Finish: "normal", Date: "2024-01-01", Price: 0.02},
+			}
+			sortPrices(prices)
+			sortPrices(want)
+			if len(prices) != len(want) {
+				t.Fatalf("got %d price records, want %d: %+v", len(prices), len(want), prices)
+			}
+			for i := range want {
+				if prices[i] != want[i] {
+					t.Errorf("record %d = %+v, want %+v", i, prices[i], want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestFetchNetworkError(t *testing.T) {
+	f := NewMTGFetcherWithClient(quietLogger(), doerFunc(func(*http.Request) (*http.Response, error) {
+		return nil, errors.New("dial tcp: connection refused")
+	}))
+	if _, err := f.FetchAllSets(); err == nil || !strings.Contains(err.Error(), "connection refused") {
+		t.Errorf("FetchAllSets error = %v, want the network error", err)
+	}
+	if _, err := f.FetchMeta(); err == nil {
+		t.Error("FetchMeta succeeded without a network")
+	}
+}
+
+func sortPrices(prices []PriceData) {
+	sort.Slice(prices, func(i, j int) bool {
+		a, b := prices[i], prices[j]
+		if a.CardUUID != b.CardUUID {
+			return a.CardUUID < b.CardUUID
+		}
+		if a.Date != b.Date {
+			return a.Date < b.Date
+		}
+		return a.Finish > b.Finish
+	})
+}
+
+func TestFetchAtomicCardsCountsDuplicateKeys(t *testing.T) {
+	// Both cards carry the same explicit UUID, so they derive the same key
+	const atomicCards = `{
+		"meta": {"version": "5.2.2", "date": "2024-01-01"},
+		"data": {
+			"Lightning Bolt": [{"name": "Lightning Bolt", "uuid": "shared-id", "layout": "normal"}],
+			"Chain Lightning": [{"name": "Chain Lightning", "uuid": "shared-id", "layout": "normal"}],
+			"Shock": [{"name": "Shock", "layout": "normal"}]
+		}
+	}`
+	f := stubFetcher(func(string) *http.Response {
+		return response(http.StatusOK, gzipped(t, atomicCards))
+	})
+
+	cards, stats, err := f.FetchAtomicCardsWithStats()
+	if err != nil {
+		t.Fatalf("FetchAtomicCardsWithStats: %v", err)
+	}
+	if stats.DuplicateKeys != 1 {
+		t.Errorf("DuplicateKeys = %d, want 1", stats.DuplicateKeys)
+	}
+	if len(cards) != 2 || stats.Records != 2 {
+		t.Fatalf("got %d cards (%d records), want the collision dropped and 2 kept", len(cards), stats.Records)
+	}
+	if kept := cards["shared-id"].Name; kept != "Chain Lightning" {
+		t.Errorf("card under the shared key = %q, want Chain Lightning, the first name alphabetically", kept)
+	}
+	if _, ok := cards[AtomicCardUUID("Shock")]; !ok {
+		t.Error("card with a distinct key was dropped")
+	}
+}
@@ -0,0 +1,100 @@
+package fetcher
+
+import (
+	"net/http"
+	"testing"
+)
+
+// finishPrices has one date priced in every finish, plus a date repeated
+// within one object, the only way nested AllPrices data can repeat a record
+const finishPrices = `{
+	"meta": {"version": "5.2.2", "date": "2024-01-01"},
+	"data": {
+		"card-1": {"paper": {"tcgplayer": {"retail": {
+			"normal": {"2024-01-01": 1.5},
+			"foil": {"2024-01-01": 4},
+			"etched": {"2024-01-01": 9, "2024-01-01": 10}
+		}}}}
+	}
+}`
+
+func TestFetchPricesKeepsOneRecordPerFinish(t *testing.T) {
+	for _, dedupe := range []bool{false, true} {
+		f := stubFetcher(func(string) *http.Response {
+			return response(http.StatusOK, gzipped(t, finishPrices))
+		})
+		f.DedupeDuplicates = dedupe
+
+		// Go's map iteration order varies, so fetch several times to catch
+		// records that survive only in some orders
+		for run := 0; run < 20; run++ {
+			prices, err := f.FetchPrices()
+			if err != nil {
+				t.Fatalf("dedupe=%v: FetchPrices: %v", dedupe, err)
+			}
+			sortPrices(prices)
+
+			want := []PriceData{
+				{CardUUID: "card-1", Format: "paper", Source: "tcgplayer", Type: "retail", Finish: "normal", Date: "2024-01-01", Price: 1.5},
+				{CardUUID: "card-1", Format: "paper", Source: "tcgplayer", Type: "retail", Finish: "foil", Foil: true, Date: "2024-01-01", Price: 4},
+				{CardUUID: "card-1", Format: "paper", Source: "tcgplayer", Type: "retail", Finish: "etched", Date: "2024-01-01", Price: 10},
+			}
+			if len(prices) != len(want) {
+				t.Fatalf("dedupe=%v: got %d price records, want one per finish: %+v", dedupe, len(prices), prices)
+			}
+			for i := range want {
+				if prices[i] != want[i] {
+					t.Errorf("dedupe=%v: record %d = %+v, want %+v", dedupe, i, prices[i], want[i])
+				}
+			}
+		}
+	}
+}
+
+func TestDedupePricesLastWins(t *testing.T) {
+	first := PriceData{CardUUID: "a", Format: "paper", Source: "tcgplayer", Type: "retail", Finish: "normal", Date: "2024-01-01", Price: 1}
+	etched := first
+	etched.Finish, etched.Price = "etched", 3
+	later := first
+	later.Price = 2
+
+	deduped, removed := DedupePrices([]PriceData{first, etched, later})
+	if removed != 1 || len(deduped) != 2 {
+		t.Fatalf("DedupePrices kept %d and removed %d, want 2 and 1", len(deduped), removed)
+	}
+	if deduped[0] != later || deduped[1] != etched {
+		t.Errorf("DedupePrices = %+v, want the later normal price and the etched price", deduped)
+	}
+}
+
+func TestStreamPricesSendsAsItDecodes(t *testing.T) {
+	// The second card is cut off, so the first can only arrive if records are
+	// sent before the whole file is decoded
+	const truncated = `{
+		"meta": {"version": "5.2.2", "date": "2024-01-01"},
+		"data": {
+			"card-1": {"paper": {"tcgplayer": {"retail": {"normal": {"2024-01-01": 1.5}}}}},
+			"card-2": {"paper": {"tcgplayer": {"retail": {"normal": {`
+	f := stubFetcher(func(string) *http.Response {
+		return response(http.StatusOK, gzipped(t, truncated))
+	})
+
+	out := make(chan PriceData)
+	errc := make(chan error, 1)
+	go func() {
+		_, err := f.StreamPrices(out)
+		errc <- err
+	}()
+
+	var got []PriceData
+	for p := range out {
+		got = append(got, p)
+	}
+	if err := <-errc; err == nil {
+		t.Error("StreamPrices succeeded on a truncated file")
+	}
+	want := PriceData{CardUUID: "card-1", Format: "paper", Source: "tcgplayer", Type: "retail", Finish: "normal", Date: "2024-01-01", Price: 1.5}
+	if len(got) != 1 || got[0] != want {
+		t.Errorf("streamed %+v, want only %+v", got, want)
+	}
+}
@@ -0,0 +1,48 @@
+package fetcher
+
+import (
+	"io"
+	"time"
+)
+
+// ProgressFunc receives download progress; totalBytes is -1 when the size is unknown
+type ProgressFunc func(bytesRead, totalBytes int64)
+
+// progressInterval is the minimum time between progress callbacks
+const progressInterval = time.Second
+
+// progressReader counts bytes read and reports them at most once per progressInterval
+type progressReader struct {
+	r          io.Reader
+	total      int64
+	read       int64
+	report     ProgressFunc
+	lastReport time.Time
+}
+
+func (p *progressReader) Read(buf []byte) (int, error) {
+	n, err := p.r.Read(buf)
+	p.read += int64(n)
+	if err == io.EOF || time.Since(p.lastReport) >= progressInterval {
+		p.report(p.read, p.total)
+		p.lastReport = time.Now()
+	}
+	return n, err
+}
+
+// withProgress wraps r to report progress when the fetcher has a ProgressFunc
+func (f *MTGFetcher) withProgress(r io.Reader, total int64) io.Reader {
+	return f.withProgressFrom(r, 0, total)
+}
+
+// withProgressFrom is withProgress for a body that continues a download
+// already offset bytes in, such as a resumed Range request
+func (f *MTGFetcher) withProgressFrom(r io.Reader, offset, total int64) io.Reader {
+	if f.Progress == nil {
+		return r
+	}
+	if total <= 0 {
+		total = -1
+	}
+	return &progressReader{r: r, total: total, read: offset, report: f.Progress, lastReport: time.Now()}
+}
@@ -0,0 +1,95 @@
+package fetcher
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// Names of the MTGJSON files the fetcher reads
+const (
+	AllSetsFile     = "AllSets.json"
+	AtomicCardsFile = "AtomicCards.json"
+	AllPricesFile   = "AllPrices.json"
+)
+
+// compressionNone marks a replay file holding plain JSON
+const compressionNone = "none"
+
+// ReplayFile makes the fetcher read an MTGJSON file from a local copy instead
+// of downloading it. The file is matched by name, so AllPrices.json.gz replays
+// AllPrices.json, and decompressed according to its extension (.gz, .zst,
+// .bz2, or none for plain .json). It returns the MTGJSON file it replaces.
+func (f *MTGFetcher) ReplayFile(path string) (string, error) {
+	base := filepath.Base(path)
+	for _, name := range []string{AllSetsFile, AtomicCardsFile, AllPricesFile} {
+		if !strings.HasPrefix(base, strings.TrimSuffix(name, ".json")) {
+			continue
+		}
+		if _, err := os.Stat(path); err != nil {
+			return "", fmt.Errorf("failed to read replay file: %w", err)
+		}
+		if _, err := replayCompression(path); err != nil {
+			return "", err
+		}
+		if f.replay == nil {
+			f.replay = map[string]string{}
+		}
+		f.replay[name] = path
+		return name, nil
+	}
+	return "", fmt.Errorf("cannot tell which MTGJSON file %s is, expected a name starting with AllSets, AtomicCards or AllPrices", base)
+}
+
+// replayCompression picks the compression format of a replay file from its extension
+func replayCompression(path string) (string, error) {
+	ext := filepath.Ext(path)
+	if ext == ".json" {
+		return compressionNone, nil
+	}
+	for format, suffix := range compressionExtensions {
+		if ext == suffix {
+			return format, nil
+		}
+	}
+	return "", fmt.Errorf("unsupported replay file extension %q, expected .json, .gz, .zst or .bz2", ext)
+}
+
+// readReplay returns the decompressed contents of name's replay file; ok is
+// false when name is fetched over the network
+func (f *MTGFetcher) readReplay(name string) (data []byte, stats FetchStats, ok bool, err error) {
+	path, ok := f.replay[name]
+	if !ok {
+		return nil, stats, false, nil
+	}
+	start := time.Now()
+	f.logger.Infof("Replaying %s from %s", name, path)
+
+	format, err := replayCompression(path)
+	if err != nil {
+		return nil, stats, true, err
+	}
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, stats, true, fmt.Errorf("failed to open replay file: %w", err)
+	}
+	defer file.Close()
+
+	body := &countingReader{r: file}
+	var reader io.ReadCloser = io.NopCloser(body)
+	if format != compressionNone {
+		if reader, err = decompressAs(body, format, ""); err != nil {
+			return nil, stats, true, fmt.Errorf("%s: %w", path, err)
+		}
+	}
+	defer reader.Close()
+
+	if data, err = io.ReadAll(reader); err != nil {
+		return nil, stats, true, fmt.Errorf("failed to read replay file %s: %w", path, err)
+	}
+	stats.recordDownload(start, body, data)
+	return data, stats, true, nil
+}
@@ -0,0 +1,101 @@
+package fetcher
+
+import (
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/mtg/mtg-ingestor/internal/models"
+)
+
+// SnapshotStore keeps the cards published by the previous run, so the next
+// one can diff against them and publish only what changed
+type SnapshotStore interface {
+	// Load returns the saved cards keyed by UUID and the MTGJSON meta they came
+	// from; the map is nil when nothing has been saved yet
+	Load() (map[string]models.Card, Meta, error)
+	// Save replaces the snapshot
+	Save(cards map[string]models.Card, meta Meta) error
+}
+
+// FileSnapshotStore is a SnapshotStore writing gzipped JSON to a file
+type FileSnapshotStore struct {
+	path string
+}
+
+var _ SnapshotStore = (*FileSnapshotStore)(nil)
+
+// snapshotFile is the on-disk layout of a snapshot
+type snapshotFile struct {
+	Meta  Meta                   `json:"meta"`
+	Cards map[string]models.Card `json:"cards"`
+}
+
+// NewFileSnapshotStore creates a store for the snapshot at path
+func NewFileSnapshotStore(path string) *FileSnapshotStore {
+	return &FileSnapshotStore{path: path}
+}
+
+// Load reads the snapshot, returning no cards if the file doesn't exist
+func (s *FileSnapshotStore) Load() (map[string]models.Card, Meta, error) {
+	file, err := os.Open(s.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, Meta{}, nil
+	}
+	if err != nil {
+		return nil, Meta{}, fmt.Errorf("failed to open snapshot: %w", err)
+	}
+	defer file.Close()
+
+	gz, err := gzip.NewReader(file)
+	if err != nil {
+		return nil, Meta{}, fmt.Errorf("failed to read snapshot %s: %w", s.path, err)
+	}
+	defer gz.Close()
+
+	var snapshot snapshotFile
+	if err := json.NewDecoder(gz).Decode(&snapshot); err != nil {
+		return nil, Meta{}, fmt.Errorf("failed to parse snapshot %s: %w", s.path, err)
+	}
+	if snapshot.Cards == nil {
+		snapshot.Cards = map[string]models.Card{}
+	}
+	return snapshot.Cards, snapshot.Meta, nil
+}
+
+// Save writes the snapshot to a temporary file and renames it into place, so
+// an interrupted save leaves the previous snapshot intact
+func (s *FileSnapshotStore) Save(cards map[string]models.Card, meta Meta) error {
+	dir := filepath.Dir(s.path)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("failed to create snapshot directory: %w", err)
+	}
+	tmp, err := os.CreateTemp(dir, filepath.Base(s.path)+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("failed to create snapshot: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	gz := gzip.NewWriter(tmp)
+	if err := json.NewEncoder(gz).Encode(snapshotFile{Meta: meta, Cards: cards}); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write snapshot: %w", err)
+	}
+	if err := gz.Close(); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write snapshot: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write snapshot: %w", err)
+	}
+	if err := os.Chmod(tmp.Name(), 0644); err != nil {
+		return fmt.Errorf("failed to write snapshot: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), s.path); err != nil {
+		return fmt.Errorf("failed to replace snapshot: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,41 @@
+package fetcher
+
+import (
+	"io"
+	"time"
+)
+
+// FetchStats describes a single fetch, for logging and capacity planning
+type FetchStats struct {
+	// BytesDownloaded is the size of the archive as transferred
+	BytesDownloaded int64 `json:"bytes_downloaded"`
+	// DecompressedBytes is the size of the JSON inside the archive
+	DecompressedBytes int64 `json:"decompressed_bytes"`
+	// DownloadDuration covers the request, transfer and decompression
+	DownloadDuration time.Duration `json:"download_duration"`
+	// ParseDuration covers unmarshalling and post-processing the JSON
+	ParseDuration time.Duration `json:"parse_duration"`
+	// Records is the number of sets, cards or price records returned
+	Records int `json:"records"`
+	// DuplicateKeys counts records dropped because their key was already taken
+	DuplicateKeys int `json:"duplicate_keys,omitempty"`
+}
+
+// recordDownload fills in the download half of the stats once an archive is read
+func (s *FetchStats) recordDownload(start time.Time, body *countingReader, data []byte) {
+	s.DownloadDuration = time.Since(start)
+	s.BytesDownloaded = body.n
+	s.DecompressedBytes = int64(len(data))
+}
+
+// countingReader counts the bytes read through it
+type countingReader struct {
+	r io.Reader
+	n int64
+}
+
+func (c *countingReader) Read(buf []byte) (int, error) {
+	n, err := c.r.Read(buf)
+	c.n += int64(n)
+	return n, err
+}
@@ -0,0 +1,155 @@
+package kafka
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/confluentinc/confluent-kafka-go/v2/schemaregistry"
+	"github.com/confluentinc/confluent-kafka-go/v2/schemaregistry/serde"
+	"github.com/confluentinc/confluent-kafka-go/v2/schemaregistry/serde/avro"
+	"github.com/mtg/mtg-ingestor/internal/models"
+)
+
+// The Avro records below are the schemas registered for card and set events.
+// Field names follow the JSON events; nested objects like prices and faces
+// are left out so the schemas stay stable as MTGJSON grows.
+
+// AvroCard is the Avro schema for a card
+type AvroCard struct {
+	UUID          string            `json:"uuid"`
+	Name          string            `json:"name"`
+	ManaCost      string            `json:"manaCost"`
+	ConvertedMana float64           `json:"convertedManaCost"`
+	Type          string            `json:"type"`
+	Text          string            `json:"text"`
+	Power         string            `json:"power"`
+	Toughness     string            `json:"toughness"`
+	Colors        []string          `json:"colors"`
+	ColorIdentity []string          `json:"colorIdentity"`
+	SetCode       string            `json:"setCode"`
+	Rarity        string            `json:"rarity"`
+	Artist        string            `json:"artist"`
+	Number        string            `json:"number"`
+	Layout        string            `json:"layout"`
+	Legalities    map[string]string `json:"legalities"`
+	Subtypes      []string          `json:"subtypes"`
+	Supertypes    []string          `json:"supertypes"`
+	Types         []string          `json:"types"`
+	Keywords      []string          `json:"keywords"`
+	FaceName      string            `json:"faceName"`
+	Side          string            `json:"side"`
+	OtherFaceIDs  []string          `json:"otherFaceIds"`
+	ProcessedAt   time.Time         `json:"processedAt"`
+}
+
+// AvroSet is the Avro schema for a set, without its cards
+type AvroSet struct {
+	Code         string    `json:"code"`
+	Name         string    `json:"name"`
+	Type         string    `json:"type"`
+	ReleaseDate  string    `json:"releaseDate"`
+	BaseSetSize  int       `json:"baseSetSize"`
+	TotalSetSize int       `json:"totalSetSize"`
+	IsOnlineOnly bool      `json:"isOnlineOnly"`
+	IsFoilOnly   bool      `json:"isFoilOnly"`
+	ProcessedAt  time.Time `json:"processedAt"`
+}
+
+// AvroCardEvent is the Avro schema for card.created events
+type AvroCardEvent struct {
+	EventType string    `json:"eventType"`
+	EventID   string    `json:"eventId"`
+	Timestamp time.Time `json:"timestamp"`
+	Source    string    `json:"source"`
+	Version   string    `json:"version"`
+	Card      AvroCard  `json:"card"`
+}
+
+// AvroSetEvent is the Avro schema for set.created events
+type AvroSetEvent struct {
+	EventType string    `json:"eventType"`
+	EventID   string    `json:"eventId"`
+	Timestamp time.Time `json:"timestamp"`
+	Source    string    `json:"source"`
+	Version   string    `json:"version"`
+	Set       AvroSet   `json:"set"`
+}
+
+// newAvroSerializer creates a Schema Registry backed Avro serializer for event values
+func newAvroSerializer(config ProducerConfig) (*avro.GenericSerializer, error) {
+	srConfig := schemaregistry.NewConfig(config.SchemaRegistryURL)
+	if config.SchemaRegistryUsername != "" {
+		srConfig = schemaregistry.NewConfigWithAuthentication(config.SchemaRegistryURL,
+			config.SchemaRegistryUsername, config.SchemaRegistryPassword)
+	}
+
+	client, err := schemaregistry.NewClient(srConfig)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create schema registry client: %w", err)
+	}
+
+	serializer, err := avro.NewGenericSerializer(client, serde.ValueSerde, avro.NewSerializerConfig())
+	if err != nil {
+		return nil, fmt.Errorf("failed to create avro serializer: %w", err)
+	}
+	return serializer, nil
+}
+
+func toAvroCardEvent(event models.CardEvent) AvroCardEvent {
+	card := event.Card
+	return AvroCardEvent{
+		EventType: event.EventType,
+		EventID:   event.EventID,
+		Timestamp: event.Timestamp,
+		Source:    event.Source,
+		Version:   event.Version,
+		Card: AvroCard{
+			UUID:          card.UUID,
+			Name:          card.Name,
+			ManaCost:      card.ManaCost,
+			ConvertedMana: card.ConvertedMana,
+			Type:          card.Type,
+			Text:          card.Text,
+			Power:         card.Power,
+			Toughness:     card.Toughness,
+			Colors:        card.Colors,
+			ColorIdentity: card.ColorIdentity,
+			SetCode:       card.SetCode,
+			Rarity:        card.Rarity,
+			Artist:        card.Artist,
+			Number:        card.Number,
+			Layout:        card.Layout,
+			Legalities:    card.Legalities,
+			Subtypes:      card.Subtypes,
+			Supertypes:    card.Supertypes,
+			Types:         card.Types,
+			Keywords:      card.Keywords,
+			FaceName:      card.FaceName,
+			Side:          card.Side,
+			OtherFaceIDs:  card.OtherFaceIDs,
+			ProcessedAt:   card.ProcessedAt,
+		},
+	}
+}
+
+func toAvroSetEvent(event models.SetEvent) AvroSetEvent {
+	set := event.Set
+	return AvroSetEvent{
+		EventType: event.EventType,
+		EventID:   event.EventID,
+		Timestamp: event.Timestamp,
+		Source:    event.Source,
+		Version:   event.Version,
+		Set: AvroSet{
+			Code:         set.Code,
+			Name:         set.Name,
+			Type:         set.Type,
+			ReleaseDate:  set.ReleaseDate,
+			BaseSetSize:  set.BaseSetSize,
+			TotalSetSize: set.TotalSetSize,
+			IsOnlineOnly: set.IsOnlineOnly,
+			IsFoilOnly:   set.IsFoilOnly,
+			ProcessedAt:  set.ProcessedAt,
+		},
+	}
+}
@@ -0,0 +1,98 @@
+package kafka
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/confluentinc/confluent-kafka-go/v2/kafka"
+)
+
+// defaultCompression is used for topics without a TopicCompression override
+const defaultCompression = "snappy"
+
+// supportedCompression lists the compression.type values Kafka accepts
+var supportedCompression = map[string]bool{
+	"none":   true,
+	"gzip":   true,
+	"snappy": true,
+	"lz4":    true,
+	"zstd":   true,
+}
+
+// validateTopicCompression checks that every override names a known topic and codec
+func validateTopicCompression(overrides map[string]string, topics map[string]string) error {
+	for name, codec := range overrides {
+		if _, ok := topics[name]; !ok {
+			return fmt.Errorf("compression override for unknown topic %q", name)
+		}
+		if !supportedCompression[codec] {
+			return fmt.Errorf("unsupported compression %q for topic %q", codec, name)
+		}
+	}
+	return nil
+}
+
+// newCodecProducers creates an extra librdkafka producer for each override codec
+// other than the default, since compression.type applies to a whole producer
+func newCodecProducers(config ProducerConfig, tuning tuning) (map[string]*kafka.Producer, error) {
+	codecs := map[string]bool{}
+	for _, codec := range config.TopicCompression {
+		if codec != defaultCompression {
+			codecs[codec] = true
+		}
+	}
+
+	producers := make(map[string]*kafka.Producer, len(codecs))
+	for codec := range codecs {
+		p, err := kafka.NewProducer(newConfigMap(config, tuning, codec))
+		if err != nil {
+			for _, created := range producers {
+				created.Close()
+			}
+			return nil, fmt.Errorf("failed to create %s producer: %w", codec, err)
+		}
+		producers[codec] = p
+	}
+	return producers, nil
+}
+
+// producerFor returns the librdkafka producer configured with the topic's compression
+func (p *Producer) producerFor(topic string) *kafka.Producer {
+	if codec, ok := p.topicCodecs[topic]; ok {
+		if codecProducer, ok := p.codecProducers[codec]; ok {
+			return codecProducer
+		}
+	}
+	return p.producer
+}
+
+// allProducers returns the default producer followed by the per-codec ones
+func (p *Producer) allProducers() []*kafka.Producer {
+	codecs := make([]string, 0, len(p.codecProducers))
+	for codec := range p.codecProducers {
+		codecs = append(codecs, codec)
+	}
+	sort.Strings(codecs)
+
+	producers := []*kafka.Producer{p.producer}
+	for _, codec := range codecs {
+		producers = append(producers, p.codecProducers[codec])
+	}
+	return producers
+}
+
+// flushAll flushes every producer within a shared timeout, returning the
+// number of messages still undelivered
+func (p *Producer) flushAll(timeoutMs int) int {
+	deadline := time.Now().Add(time.Duration(timeoutMs) * time.Millisecond)
+	remaining := 0
+	for _, producer := range p.allProducers() {
+		left := int(time.Until(deadline).Milliseconds())
+		if left < 0 {
+			left = 0
+		}
+		remaining += producer.Flush(left)
+	}
+	return remaining
+}
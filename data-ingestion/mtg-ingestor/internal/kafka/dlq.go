@@ -0,0 +1,49 @@
+package kafka
+
+import (
+	"time"
+
+	"github.com/confluentinc/confluent-kafka-go/v2/kafka"
+)
+
+// Headers describing why a message was dead-lettered
+const (
+	dlqOriginalTopicHeader = "dlq.original.topic"
+	dlqErrorHeader         = "dlq.error"
+	dlqFailedAtHeader      = "dlq.failed.at"
+)
+
+// deadLetter routes a message that could not be delivered to the dead-letter
+// topic, keeping its key, value and headers and adding headers naming the
+// original topic and the error. Without a DLQ topic, or for a failed
+// dead-letter message itself, the message is only logged as before.
+func (p *Producer) deadLetter(msg *kafka.Message, cause error) {
+	topic := ""
+	if msg.TopicPartition.Topic != nil {
+		topic = *msg.TopicPartition.Topic
+	}
+	if p.dlqTopic == "" || topic == p.dlqTopic {
+		p.logger.Errorf("Dropping undeliverable message for %s: %v", topic, cause)
+		return
+	}
+
+	headers := append([]kafka.Header{}, msg.Headers...)
+	headers = append(headers,
+		kafka.Header{Key: dlqOriginalTopicHeader, Value: []byte(topic)},
+		kafka.Header{Key: dlqErrorHeader, Value: []byte(cause.Error())},
+		kafka.Header{Key: dlqFailedAtHeader, Value: []byte(time.Now().UTC().Format(time.RFC3339))},
+	)
+	dead := &kafka.Message{
+		TopicPartition: kafka.TopicPartition{Topic: &p.dlqTopic, Partition: kafka.PartitionAny},
+		Key:            msg.Key,
+		Value:          msg.Value,
+		Headers:        headers,
+	}
+
+	// A single attempt: the DLQ mustn't feed back into the retry path
+	if err := p.producerFor(p.dlqTopic).Produce(dead, nil); err != nil {
+		p.logger.Errorf("Failed to dead-letter message for %s (%v): %v", topic, cause, err)
+		return
+	}
+	p.logger.Warnf("Dead-lettered message for %s to %s: %v", topic, p.dlqTopic, cause)
+}
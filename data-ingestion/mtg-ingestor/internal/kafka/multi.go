@@ -0,0 +1,110 @@
+package kafka
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/mtg/mtg-ingestor/internal/models"
+	"github.com/mtg/mtg-ingestor/internal/sink"
+	"github.com/sirupsen/logrus"
+)
+
+// Publisher is the publishing surface shared by Producer and MultiProducer:
+// a sink.Sink that can also publish price changes, set summaries and card deltas
+type Publisher interface {
+	sink.Sink
+	sink.PriceChangeSink
+	sink.SetSummarySink
+	sink.CardDeltaSink
+}
+
+var (
+	_ Publisher = (*Producer)(nil)
+	_ Publisher = (*MultiProducer)(nil)
+)
+
+// MultiProducer tees every event to a primary and one or more secondary publishers,
+// e.g. to keep two clusters in sync during a migration
+type MultiProducer struct {
+	primary     Publisher
+	secondaries []Publisher
+	logger      *logrus.Logger
+	// FailOnSecondaryError makes a secondary failure fail the publish call
+	// instead of only being logged as a warning
+	FailOnSecondaryError bool
+}
+
+// NewMultiProducer creates a MultiProducer fanning out to primary and secondaries
+func NewMultiProducer(logger *logrus.Logger, primary Publisher, secondaries ...Publisher) *MultiProducer {
+	return &MultiProducer{
+		primary:     primary,
+		secondaries: secondaries,
+		logger:      logger,
+	}
+}
+
+// PublishCard publishes a card event to every cluster
+func (m *MultiProducer) PublishCard(card models.Card) error {
+	return m.fanOut("card", func(p Publisher) error { return p.PublishCard(card) })
+}
+
+// PublishSet publishes a set event to every cluster
+func (m *MultiProducer) PublishSet(set models.Set) error {
+	return m.fanOut("set", func(p Publisher) error { return p.PublishSet(set) })
+}
+
+// PublishPrice publishes a price event to every cluster
+func (m *MultiProducer) PublishPrice(price interface{}) error {
+	return m.fanOut("price", func(p Publisher) error { return p.PublishPrice(price) })
+}
+
+// PublishPriceChange publishes a price change event to every cluster
+func (m *MultiProducer) PublishPriceChange(change models.PriceChange) error {
+	return m.fanOut("price change", func(p Publisher) error { return p.PublishPriceChange(change) })
+}
+
+// PublishSetSummary publishes a set summary event to every cluster
+func (m *MultiProducer) PublishSetSummary(summary models.SetSummary) error {
+	return m.fanOut("set summary", func(p Publisher) error { return p.PublishSetSummary(summary) })
+}
+
+// PublishCardDelta publishes a card delta event to every cluster
+func (m *MultiProducer) PublishCardDelta(delta models.CardDelta) error {
+	return m.fanOut("card delta", func(p Publisher) error { return p.PublishCardDelta(delta) })
+}
+
+// Flush flushes every cluster and returns the total number of undelivered messages
+func (m *MultiProducer) Flush(timeoutMs int) int {
+	remaining := m.primary.Flush(timeoutMs)
+	for _, s := range m.secondaries {
+		remaining += s.Flush(timeoutMs)
+	}
+	return remaining
+}
+
+// Close closes every underlying publisher
+func (m *MultiProducer) Close() {
+	m.primary.Close()
+	for _, s := range m.secondaries {
+		s.Close()
+	}
+}
+
+func (m *MultiProducer) fanOut(kind string, publish func(Publisher) error) error {
+	if err := publish(m.primary); err != nil {
+		return fmt.Errorf("primary: %w", err)
+	}
+
+	var errs []error
+	for i, s := range m.secondaries {
+		if err := publish(s); err != nil {
+			if m.FailOnSecondaryError {
+				errs = append(errs, fmt.Errorf("secondary %d: %w", i, err))
+			} else {
+				m.logger.Warnf("Failed to publish %s to secondary cluster %d: %v", kind, i, err)
+			}
+		}
+	}
+
+	return errors.Join(errs...)
+}
@@ -0,0 +1,98 @@
+package kafka
+
+import (
+	"errors"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/mtg/mtg-ingestor/internal/models"
+	"github.com/mtg/mtg-ingestor/internal/sink"
+	"github.com/sirupsen/logrus"
+)
+
+// failingPublisher records nothing and fails every publish
+type failingPublisher struct {
+	sink.NoopSink
+	undelivered int
+}
+
+func (f failingPublisher) PublishCard(models.Card) error { return errors.New("broker down") }
+func (f failingPublisher) Flush(int) int                 { return f.undelivered }
+
+func quietLogger() *logrus.Logger {
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+	return logger
+}
+
+func TestMultiProducerTeesToEveryCluster(t *testing.T) {
+	primary, secondary := sink.NewInMemorySink(), sink.NewInMemorySink()
+	m := NewMultiProducer(quietLogger(), primary, secondary)
+
+	if err := m.PublishCard(models.Card{UUID: "a"}); err != nil {
+		t.Fatalf("PublishCard: %v", err)
+	}
+	if err := m.PublishSet(models.Set{Code: "DOM"}); err != nil {
+		t.Fatalf("PublishSet: %v", err)
+	}
+	if err := m.PublishCardDelta(models.CardDelta{Kind: models.CardAdded, Card: models.Card{UUID: "a"}}); err != nil {
+		t.Fatalf("PublishCardDelta: %v", err)
+	}
+
+	for name, s := range map[string]*sink.InMemorySink{"primary": primary, "secondary": secondary} {
+		if len(s.Cards()) != 1 || len(s.Sets()) != 1 || len(s.CardDeltas()) != 1 {
+			t.Errorf("%s got %d cards, %d sets, %d deltas, want one of each",
+				name, len(s.Cards()), len(s.Sets()), len(s.CardDeltas()))
+		}
+	}
+}
+
+func TestMultiProducerSecondaryFailures(t *testing.T) {
+	tests := []struct {
+		name          string
+		failOnSecond  bool
+		wantErr       bool
+		wantPrimaries int
+	}{
+		{name: "logged by default", wantPrimaries: 1},
+		{name: "fail on secondary error", failOnSecond: true, wantErr: true, wantPrimaries: 1},
+	}
+
+	for _, tt := range tests {
+		primary := sink.NewInMemorySink()
+		m := NewMultiProducer(quietLogger(), primary, failingPublisher{})
+		m.FailOnSecondaryError = tt.failOnSecond
+
+		err := m.PublishCard(models.Card{UUID: "a"})
+		if (err != nil) != tt.wantErr {
+			t.Errorf("%s: error = %v, wantErr %v", tt.name, err, tt.wantErr)
+		}
+		if err != nil && !strings.Contains(err.Error(), "secondary 0") {
+			t.Errorf("%s: error %q doesn't name the secondary", tt.name, err)
+		}
+		if len(primary.Cards()) != tt.wantPrimaries {
+			t.Errorf("%s: primary got %d cards, want %d", tt.name, len(primary.Cards()), tt.wantPrimaries)
+		}
+	}
+}
+
+func TestMultiProducerPrimaryFailureSkipsSecondaries(t *testing.T) {
+	secondary := sink.NewInMemorySink()
+	m := NewMultiProducer(quietLogger(), failingPublisher{}, secondary)
+
+	err := m.PublishCard(models.Card{UUID: "a"})
+	if err == nil || !strings.HasPrefix(err.Error(), "primary:") {
+		t.Errorf("error = %v, want a primary error", err)
+	}
+	if len(secondary.Cards()) != 0 {
+		t.Error("card was teed to the secondary after the primary failed")
+	}
+}
+
+func TestMultiProducerFlushSumsUndelivered(t *testing.T) {
+	m := NewMultiProducer(quietLogger(), failingPublisher{undelivered: 2}, failingPublisher{undelivered: 3})
+	if got := m.Flush(100); got != 5 {
+		t.Errorf("Flush = %d, want 5", got)
+	}
+}
@@ -2,67 +2,235 @@ package kafka
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
+	"sync"
 	"time"
 
 	"github.com/confluentinc/confluent-kafka-go/v2/kafka"
+	"github.com/confluentinc/confluent-kafka-go/v2/schemaregistry/serde/avro"
 	"github.com/google/uuid"
+	"github.com/mtg/mtg-ingestor/internal/deck"
+	"github.com/mtg/mtg-ingestor/internal/fetcher"
 	"github.com/mtg/mtg-ingestor/internal/models"
 	"github.com/sirupsen/logrus"
 )
 
 type Producer struct {
-	producer *kafka.Producer
-	logger   *logrus.Logger
-	topics   map[string]string
+	producer    *kafka.Producer
+	logger      *logrus.Logger
+	topics      map[string]string
+	concurrency int
+	limiter     *rateLimiter
+
+	queueFullTimeout time.Duration
+	avroSerializer   *avro.GenericSerializer
+	partitionBy      string
+	stripRulings     bool
+	priceBatchSize   int
+	dlqTopic         string
+
+	// codecProducers holds an extra producer per non-default codec and
+	// topicCodecs maps topic names to their codec
+	codecProducers map[string]*kafka.Producer
+	topicCodecs    map[string]string
 }
 
+// Card partitioning strategies for ProducerConfig.PartitionBy
+const (
+	PartitionByUUID = "uuid"
+	PartitionBySet  = "set"
+)
+
 type ProducerConfig struct {
 	Brokers       string
 	CardsTopic    string
 	SetsTopic     string
 	PricesTopic   string
+	// PriceChangesTopic receives price.changed events; defaults to PricesTopic
+	PriceChangesTopic string
+	// SetSummariesTopic receives set.summary events; defaults to SetsTopic
+	SetSummariesTopic string
+	// CardDeltasTopic receives card.added, card.removed and card.changed events;
+	// defaults to CardsTopic
+	CardDeltasTopic string
+	DecksTopic        string
+	DeckCardsTopic    string
 	Logger        *logrus.Logger
+	// QueueBufferingMaxMessages and QueueBufferingMaxKbytes size librdkafka's
+	// local queue; zero keeps the librdkafka defaults
+	QueueBufferingMaxMessages int
+	QueueBufferingMaxKbytes   int
+	// PublishConcurrency bounds the workers publishing a set's cards; defaults to 8
+	PublishConcurrency int
+	// EnableIdempotence stops broker retries from duplicating messages
+	EnableIdempotence bool
+	// PublishRateLimit caps card publishes per second within PublishSet; zero disables it
+	PublishRateLimit float64
+	// QueueFullTimeout bounds how long a publish blocks on a full local queue; defaults to 30s
+	QueueFullTimeout time.Duration
+	// SchemaRegistryURL switches card and set events to Avro; empty keeps JSON
+	SchemaRegistryURL      string
+	SchemaRegistryUsername string
+	SchemaRegistryPassword string
+	// PartitionBy picks the card message key: "uuid" (default) spreads cards
+	// evenly, "set" keys by set code so a set's cards share a partition
+	PartitionBy string
+	// StripRulings drops card rulings from card events to keep the cards topic lean
+	StripRulings bool
+	// PriceBatchSize is the number of records PublishPriceBatch packs into one
+	// message; defaults to 500
+	PriceBatchSize int
+	// TopicCompression overrides compression.type per topic, keyed by stream
+	// name ("cards", "prices", ...); unlisted topics use snappy
+	TopicCompression map[string]string
+	// DLQTopic receives events that could not be delivered, with headers naming
+	// the original topic and error; empty drops them after logging
+	DLQTopic string
+	// Acks is the acknowledgement level: "0", "1" or "all" (default)
+	Acks string
+	// Retries is how often a failed send is retried; nil keeps 10
+	Retries *int
+	// RetryBackoff is the wait between retries; defaults to 100ms
+	RetryBackoff time.Duration
+	// Linger is how long messages wait to fill a batch; nil keeps 10ms
+	Linger *time.Duration
+	// BatchSize is the largest batch in bytes; defaults to 16384
+	BatchSize int
 }
 
 func NewProducer(config ProducerConfig) (*Producer, error) {
-	p, err := kafka.NewProducer(&kafka.ConfigMap{
-		"bootstrap.servers":  config.Brokers,
-		"client.id":         "mtg-ingestor",
-		"acks":             "all",
-		"retries":          10,
-		"retry.backoff.ms": 100,
-		"compression.type": "snappy",
-		"linger.ms":       10,
-		"batch.size":      16384,
-	})
+	partitionBy := config.PartitionBy
+	switch partitionBy {
+	case "":
+		partitionBy = PartitionByUUID
+	case PartitionByUUID, PartitionBySet:
+	default:
+		return nil, fmt.Errorf("unknown partition strategy: %q", config.PartitionBy)
+	}
+
+	priceChangesTopic := config.PriceChangesTopic
+	if priceChangesTopic == "" {
+		priceChangesTopic = config.PricesTopic
+	}
+	setSummariesTopic := config.SetSummariesTopic
+	if setSummariesTopic == "" {
+		setSummariesTopic = config.SetsTopic
+	}
+	cardDeltasTopic := config.CardDeltasTopic
+	if cardDeltasTopic == "" {
+		cardDeltasTopic = config.CardsTopic
+	}
+	topics := map[string]string{
+		"cards":         config.CardsTopic,
+		"card_deltas":   cardDeltasTopic,
+		"sets":          config.SetsTopic,
+		"set_summaries": setSummariesTopic,
+		"prices":        config.PricesTopic,
+		"price_changes": priceChangesTopic,
+		"decks":         config.DecksTopic,
+		"deck_cards":    config.DeckCardsTopic,
+	}
+	if err := validateTopicCompression(config.TopicCompression, topics); err != nil {
+		return nil, err
+	}
+	tuning, err := resolveTuning(config)
+	if err != nil {
+		return nil, err
+	}
+
+	p, err := kafka.NewProducer(newConfigMap(config, tuning, defaultCompression))
 
 	if err != nil {
 		return nil, fmt.Errorf("failed to create producer: %w", err)
 	}
+	codecProducers, err := newCodecProducers(config, tuning)
+	if err != nil {
+		p.Close()
+		return nil, err
+	}
+
+	concurrency := config.PublishConcurrency
+	if concurrency <= 0 {
+		concurrency = 8
+	}
 
 	producer := &Producer{
-		producer: p,
-		logger:   config.Logger,
-		topics: map[string]string{
-			"cards":  config.CardsTopic,
-			"sets":   config.SetsTopic,
-			"prices": config.PricesTopic,
-		},
+		producer:    p,
+		logger:      config.Logger,
+		concurrency: concurrency,
+		partitionBy: partitionBy,
+		topics:      topics,
+	}
+
+	producer.codecProducers = codecProducers
+	producer.topicCodecs = make(map[string]string, len(config.TopicCompression))
+	for name, codec := range config.TopicCompression {
+		producer.topicCodecs[topics[name]] = codec
 	}
 
-	// Start delivery report handler
-	go producer.handleDeliveryReports()
+	producer.stripRulings = config.StripRulings
+	producer.dlqTopic = config.DLQTopic
+	producer.priceBatchSize = config.PriceBatchSize
+	if producer.priceBatchSize <= 0 {
+		producer.priceBatchSize = 500
+	}
+	producer.queueFullTimeout = config.QueueFullTimeout
+	if producer.queueFullTimeout <= 0 {
+		producer.queueFullTimeout = 30 * time.Second
+	}
+	if config.SchemaRegistryURL != "" {
+		producer.avroSerializer, err = newAvroSerializer(config)
+		if err != nil {
+			producer.Close()
+			return nil, err
+		}
+	}
+	if config.PublishRateLimit > 0 {
+		producer.limiter = newRateLimiter(config.PublishRateLimit)
+	}
+
+	// Start delivery report handlers
+	for _, kp := range producer.allProducers() {
+		go producer.handleDeliveryReports(kp)
+	}
 
 	return producer, nil
 }
 
-func (p *Producer) handleDeliveryReports() {
-	for e := range p.producer.Events() {
+// newConfigMap builds the librdkafka configuration for a producer
+func newConfigMap(config ProducerConfig, tuning tuning, compression string) *kafka.ConfigMap {
+	configMap := &kafka.ConfigMap{
+		"bootstrap.servers":  config.Brokers,
+		"client.id":         "mtg-ingestor",
+		"acks":             tuning.acks,
+		"retries":          tuning.retries,
+		"retry.backoff.ms": int(tuning.retryBackoff.Milliseconds()),
+		"compression.type": compression,
+		"linger.ms":       int(tuning.linger.Milliseconds()),
+		"batch.size":      tuning.batchSize,
+	}
+
+	if config.EnableIdempotence {
+		configMap.SetKey("enable.idempotence", true)
+	}
+	if config.QueueBufferingMaxMessages > 0 {
+		configMap.SetKey("queue.buffering.max.messages", config.QueueBufferingMaxMessages)
+	}
+	if config.QueueBufferingMaxKbytes > 0 {
+		configMap.SetKey("queue.buffering.max.kbytes", config.QueueBufferingMaxKbytes)
+	}
+
+	return configMap
+}
+
+func (p *Producer) handleDeliveryReports(producer *kafka.Producer) {
+	for e := range producer.Events() {
 		switch ev := e.(type) {
 		case *kafka.Message:
 			if ev.TopicPartition.Error != nil {
 				p.logger.Errorf("Delivery failed: %v", ev.TopicPartition.Error)
+				p.deadLetter(ev, ev.TopicPartition.Error)
 			} else {
 				p.logger.Debugf("Delivered message to %v", ev.TopicPartition)
 			}
@@ -72,6 +240,159 @@ func (p *Producer) handleDeliveryReports() {
 
 // PublishCard publishes a card event to Kafka
 func (p *Producer) PublishCard(card models.Card) error {
+	msg, err := p.newCardMessage(card)
+	if err != nil {
+		return err
+	}
+
+	if err := p.produce(msg, nil); err != nil {
+		return fmt.Errorf("failed to produce card message: %w", err)
+	}
+
+	return nil
+}
+
+// PublishCardSync publishes a card event and blocks until the broker acknowledges it
+func (p *Producer) PublishCardSync(card models.Card, timeout time.Duration) error {
+	msg, err := p.newCardMessage(card)
+	if err != nil {
+		return err
+	}
+
+	if err := p.produceSync(msg, timeout); err != nil {
+		return fmt.Errorf("failed to deliver card message: %w", err)
+	}
+
+	return nil
+}
+
+// PublishSet publishes a set event to Kafka
+func (p *Producer) PublishSet(set models.Set) error {
+	msg, err := p.newSetMessage(set)
+	if err != nil {
+		return err
+	}
+
+	if err := p.produce(msg, nil); err != nil {
+		return fmt.Errorf("failed to produce set message: %w", err)
+	}
+
+	// Publish each card in the set
+	return p.publishCards(set.Cards, p.PublishCard)
+}
+
+// PublishSetSync publishes a set event and its cards, blocking until each is acknowledged
+func (p *Producer) PublishSetSync(set models.Set, timeout time.Duration) error {
+	msg, err := p.newSetMessage(set)
+	if err != nil {
+		return err
+	}
+
+	if err := p.produceSync(msg, timeout); err != nil {
+		return fmt.Errorf("failed to deliver set message: %w", err)
+	}
+
+	return p.publishCards(set.Cards, func(card models.Card) error {
+		return p.PublishCardSync(card, timeout)
+	})
+}
+
+// publishCards fans card publishes out over a bounded worker pool and joins any errors.
+// With PartitionBy "uuid" each card has its own key, so per-card ordering is
+// unaffected; with "set" the cards of a set may land in any relative order.
+func (p *Producer) publishCards(cards []models.Card, publish func(models.Card) error) error {
+	jobs := make(chan models.Card)
+	errs := make(chan error, len(cards))
+
+	var wg sync.WaitGroup
+	for w := 0; w < p.concurrency && w < len(cards); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for card := range jobs {
+				if p.limiter != nil {
+					p.limiter.Wait()
+				}
+				if err := publish(card); err != nil {
+					p.logger.Errorf("Failed to publish card %s: %v", card.Name, err)
+					errs <- fmt.Errorf("card %s: %w", card.UUID, err)
+				}
+			}
+		}()
+	}
+
+	for _, card := range cards {
+		jobs <- card
+	}
+	close(jobs)
+	wg.Wait()
+	close(errs)
+
+	var failed []error
+	for err := range errs {
+		failed = append(failed, err)
+	}
+	return errors.Join(failed...)
+}
+
+// PublishPrice publishes individual price data to Kafka
+func (p *Producer) PublishPrice(price interface{}) error {
+	msg, err := p.newPriceMessage(price)
+	if err != nil {
+		return err
+	}
+
+	if err := p.produce(msg, nil); err != nil {
+		return fmt.Errorf("failed to produce price message: %w", err)
+	}
+
+	return nil
+}
+
+// PublishPriceSync publishes price data and blocks until the broker acknowledges it
+func (p *Producer) PublishPriceSync(price interface{}, timeout time.Duration) error {
+	msg, err := p.newPriceMessage(price)
+	if err != nil {
+		return err
+	}
+
+	if err := p.produceSync(msg, timeout); err != nil {
+		return fmt.Errorf("failed to deliver price message: %w", err)
+	}
+
+	return nil
+}
+
+// produceSync produces a message with a dedicated delivery channel and waits for the report
+func (p *Producer) produceSync(msg *kafka.Message, timeout time.Duration) error {
+	deliveryChan := make(chan kafka.Event, 1)
+	if err := p.produce(msg, deliveryChan); err != nil {
+		return err
+	}
+
+	select {
+	case e := <-deliveryChan:
+		m, ok := e.(*kafka.Message)
+		if !ok {
+			return fmt.Errorf("unexpected delivery event: %v", e)
+		}
+		if m.TopicPartition.Error != nil {
+			p.deadLetter(m, m.TopicPartition.Error)
+			return m.TopicPartition.Error
+		}
+		p.logger.Debugf("Delivered message to %v", m.TopicPartition)
+		return nil
+	case <-time.After(timeout):
+		return fmt.Errorf("timed out after %v waiting for delivery report", timeout)
+	}
+}
+
+func (p *Producer) newCardMessage(card models.Card) (*kafka.Message, error) {
+	// Rulings are verbose and often unused downstream
+	if p.stripRulings {
+		card.Rulings = nil
+	}
+
 	event := models.CardEvent{
 		KafkaEvent: models.KafkaEvent{
 			EventType: "card.created",
@@ -83,31 +404,38 @@ func (p *Producer) PublishCard(card models.Card) error {
 		Card: card,
 	}
 
-	data, err := json.Marshal(event)
+	topic := p.topics["cards"]
+	var data []byte
+	var err error
+	if p.avroSerializer != nil {
+		data, err = p.avroSerializer.Serialize(topic, toAvroCardEvent(event))
+	} else {
+		data, err = json.Marshal(event)
+	}
 	if err != nil {
-		return fmt.Errorf("failed to marshal card event: %w", err)
+		return nil, fmt.Errorf("failed to marshal card event: %w", err)
 	}
 
-	topic := p.topics["cards"]
-	err = p.producer.Produce(&kafka.Message{
+	return &kafka.Message{
 		TopicPartition: kafka.TopicPartition{Topic: &topic, Partition: kafka.PartitionAny},
-		Key:            []byte(card.UUID),
+		Key:            []byte(p.cardKey(card)),
 		Value:          data,
 		Headers: []kafka.Header{
 			{Key: "eventType", Value: []byte("card.created")},
 			{Key: "source", Value: []byte("mtgjson")},
 		},
-	}, nil)
+	}, nil
+}
 
-	if err != nil {
-		return fmt.Errorf("failed to produce card message: %w", err)
+// cardKey returns the message key for a card under the configured partitioning
+func (p *Producer) cardKey(card models.Card) string {
+	if p.partitionBy == PartitionBySet && card.SetCode != "" {
+		return card.SetCode
 	}
-
-	return nil
+	return card.UUID
 }
 
-// PublishSet publishes a set event to Kafka
-func (p *Producer) PublishSet(set models.Set) error {
+func (p *Producer) newSetMessage(set models.Set) (*kafka.Message, error) {
 	// Create set event without cards (cards are published separately)
 	setCopy := set
 	setCopy.Cards = nil
@@ -123,13 +451,19 @@ func (p *Producer) PublishSet(set models.Set) error {
 		Set: setCopy,
 	}
 
-	data, err := json.Marshal(event)
+	topic := p.topics["sets"]
+	var data []byte
+	var err error
+	if p.avroSerializer != nil {
+		data, err = p.avroSerializer.Serialize(topic, toAvroSetEvent(event))
+	} else {
+		data, err = json.Marshal(event)
+	}
 	if err != nil {
-		return fmt.Errorf("failed to marshal set event: %w", err)
+		return nil, fmt.Errorf("failed to marshal set event: %w", err)
 	}
 
-	topic := p.topics["sets"]
-	err = p.producer.Produce(&kafka.Message{
+	return &kafka.Message{
 		TopicPartition: kafka.TopicPartition{Topic: &topic, Partition: kafka.PartitionAny},
 		Key:            []byte(set.Code),
 		Value:          data,
@@ -137,24 +471,10 @@ func (p *Producer) PublishSet(set models.Set) error {
 			{Key: "eventType", Value: []byte("set.created")},
 			{Key: "source", Value: []byte("mtgjson")},
 		},
-	}, nil)
-
-	if err != nil {
-		return fmt.Errorf("failed to produce set message: %w", err)
-	}
-
-	// Publish each card in the set
-	for _, card := range set.Cards {
-		if err := p.PublishCard(card); err != nil {
-			p.logger.Errorf("Failed to publish card %s: %v", card.Name, err)
-		}
-	}
-
-	return nil
+	}, nil
 }
 
-// PublishPrice publishes individual price data to Kafka
-func (p *Producer) PublishPrice(price interface{}) error {
+func (p *Producer) newPriceMessage(price interface{}) (*kafka.Message, error) {
 	event := map[string]interface{}{
 		"eventType": "price.updated",
 		"eventId":   uuid.New().String(),
@@ -166,7 +486,7 @@ func (p *Producer) PublishPrice(price interface{}) error {
 
 	data, err := json.Marshal(event)
 	if err != nil {
-		return fmt.Errorf("failed to marshal price event: %w", err)
+		return nil, fmt.Errorf("failed to marshal price event: %w", err)
 	}
 
 	topic := p.topics["prices"]
@@ -179,7 +499,7 @@ func (p *Producer) PublishPrice(price interface{}) error {
 		}
 	}
 	
-	err = p.producer.Produce(&kafka.Message{
+	return &kafka.Message{
 		TopicPartition: kafka.TopicPartition{Topic: &topic, Partition: kafka.PartitionAny},
 		Key:            []byte(key),
 		Value:          data,
@@ -187,21 +507,317 @@ func (p *Producer) PublishPrice(price interface{}) error {
 			{Key: "eventType", Value: []byte("price.updated")},
 			{Key: "source", Value: []byte("mtgjson")},
 		},
+	}, nil
+}
+
+// PublishPriceBatch publishes price records packed PriceBatchSize to a
+// price.batch message, trading per-record messages for fewer, larger ones
+// that batch consumers unpack. Each message is keyed by its first card UUID.
+func (p *Producer) PublishPriceBatch(prices []fetcher.PriceData) error {
+	var errs []error
+	for start := 0; start < len(prices); start += p.priceBatchSize {
+		batch := prices[start:min(start+p.priceBatchSize, len(prices))]
+		msg, err := p.newPriceBatchMessage(batch)
+		if err == nil {
+			err = p.produce(msg, nil)
+		}
+		if err != nil {
+			errs = append(errs, fmt.Errorf("failed to produce price batch at record %d: %w", start, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (p *Producer) newPriceBatchMessage(batch []fetcher.PriceData) (*kafka.Message, error) {
+	event := map[string]interface{}{
+		"eventType": "price.batch",
+		"eventId":   uuid.New().String(),
+		"timestamp": time.Now(),
+		"source":    "mtgjson",
+		"version":   "v5",
+		"count":     len(batch),
+		"data":      batch,
+	}
+
+	data, err := json.Marshal(event)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal price batch event: %w", err)
+	}
+
+	topic := p.topics["prices"]
+	key := fmt.Sprintf("price-batch-%s-%s", batch[0].CardUUID, batch[0].Date)
+
+	return &kafka.Message{
+		TopicPartition: kafka.TopicPartition{Topic: &topic, Partition: kafka.PartitionAny},
+		Key:            []byte(key),
+		Value:          data,
+		Headers: []kafka.Header{
+			{Key: "eventType", Value: []byte("price.batch")},
+			{Key: "source", Value: []byte("mtgjson")},
+		},
+	}, nil
+}
+
+// PublishPriceChange publishes a price delta event to Kafka
+func (p *Producer) PublishPriceChange(change models.PriceChange) error {
+	event := models.PriceChangeEvent{
+		KafkaEvent: models.KafkaEvent{
+			EventType: "price.changed",
+			EventID:   uuid.New().String(),
+			Timestamp: time.Now(),
+			Source:    "mtgjson",
+			Version:   "v5",
+		},
+		Change: change,
+	}
+
+	data, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("failed to marshal price change event: %w", err)
+	}
+
+	topic := p.topics["price_changes"]
+	err = p.produce(&kafka.Message{
+		TopicPartition: kafka.TopicPartition{Topic: &topic, Partition: kafka.PartitionAny},
+		Key:            []byte(change.CardUUID),
+		Value:          data,
+		Headers: []kafka.Header{
+			{Key: "eventType", Value: []byte("price.changed")},
+			{Key: "source", Value: []byte("mtgjson")},
+		},
 	}, nil)
 
 	if err != nil {
-		return fmt.Errorf("failed to produce price message: %w", err)
+		return fmt.Errorf("failed to produce price change message: %w", err)
+	}
+
+	return nil
+}
+
+// PublishSetSummary publishes a set's color identity and rarity rollup keyed by set code
+func (p *Producer) PublishSetSummary(summary models.SetSummary) error {
+	event := models.SetSummaryEvent{
+		KafkaEvent: models.KafkaEvent{
+			EventType: "set.summary",
+			EventID:   uuid.New().String(),
+			Timestamp: time.Now(),
+			Source:    "mtgjson",
+			Version:   "v5",
+		},
+		Summary: summary,
+	}
+
+	data, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("failed to marshal set summary event: %w", err)
+	}
+
+	topic := p.topics["set_summaries"]
+	err = p.produce(&kafka.Message{
+		TopicPartition: kafka.TopicPartition{Topic: &topic, Partition: kafka.PartitionAny},
+		Key:            []byte(summary.SetCode),
+		Value:          data,
+		Headers: []kafka.Header{
+			{Key: "eventType", Value: []byte("set.summary")},
+			{Key: "source", Value: []byte("mtgjson")},
+		},
+	}, nil)
+
+	if err != nil {
+		return fmt.Errorf("failed to produce set summary message: %w", err)
+	}
+
+	return nil
+}
+
+// PublishCardDelta publishes a card.added, card.removed or card.changed event
+// keyed by card UUID. Removals carry no card data.
+func (p *Producer) PublishCardDelta(delta models.CardDelta) error {
+	eventType := "card." + delta.Kind
+	event := models.CardDeltaEvent{
+		KafkaEvent: models.KafkaEvent{
+			EventType: eventType,
+			EventID:   uuid.New().String(),
+			Timestamp: time.Now(),
+			Source:    "mtgjson",
+			Version:   "v5",
+		},
+		CardUUID: delta.Card.UUID,
+		Changes:  delta.Changes,
+	}
+	if delta.Kind != models.CardRemoved {
+		card := delta.Card
+		if p.stripRulings {
+			card.Rulings = nil
+		}
+		event.Card = &card
+	}
+
+	data, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("failed to marshal card delta event: %w", err)
+	}
+
+	topic := p.topics["card_deltas"]
+	err = p.produce(&kafka.Message{
+		TopicPartition: kafka.TopicPartition{Topic: &topic, Partition: kafka.PartitionAny},
+		Key:            []byte(delta.Card.UUID),
+		Value:          data,
+		Headers: []kafka.Header{
+			{Key: "eventType", Value: []byte(eventType)},
+			{Key: "source", Value: []byte("mtgjson")},
+		},
+	}, nil)
+
+	if err != nil {
+		return fmt.Errorf("failed to produce card delta message: %w", err)
+	}
+
+	return nil
+}
+
+// PublishDeck publishes a deck.ingested event keyed by deck ID
+func (p *Producer) PublishDeck(event deck.DeckEvent) error {
+	d, ok := event.Data.(*deck.Deck)
+	if !ok {
+		return fmt.Errorf("deck event %s has no deck data", event.EventID)
+	}
+	return p.PublishDeckEvent(p.topics["decks"], d.ID, event)
+}
+
+// PublishDeckCard publishes a deck.card event keyed by deck ID and card name
+func (p *Producer) PublishDeckCard(event deck.DeckEvent) error {
+	data, ok := event.Data.(map[string]interface{})
+	if !ok {
+		return fmt.Errorf("deck card event %s has no card data", event.EventID)
+	}
+	deckID, _ := data["deck_id"].(string)
+	cardName, _ := data["card_name"].(string)
+	return p.PublishDeckEvent(p.topics["deck_cards"], deck.DeckCardKey(deckID, cardName), event)
+}
+
+// PublishDeckEvent publishes a deck event to the given topic
+func (p *Producer) PublishDeckEvent(topic, key string, event deck.DeckEvent) error {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("failed to marshal deck event: %w", err)
+	}
+
+	msg := &kafka.Message{
+		TopicPartition: kafka.TopicPartition{Topic: &topic, Partition: kafka.PartitionAny},
+		Key:            []byte(key),
+		Value:          data,
+		Headers: []kafka.Header{
+			{Key: "eventType", Value: []byte(event.EventType)},
+			{Key: "source", Value: []byte(event.Source)},
+		},
+	}
+
+	if err := p.produce(msg, nil); err != nil {
+		return fmt.Errorf("failed to produce deck message: %w", err)
 	}
 
 	return nil
 }
 
+// produce enqueues msg, blocking while librdkafka's local queue is full so
+// records aren't dropped under load. Flushing drains the queue between
+// attempts; after queueFullTimeout the ErrQueueFull error is returned.
+// A message that can't be enqueued is dead-lettered.
+func (p *Producer) produce(msg *kafka.Message, deliveryChan chan kafka.Event) error {
+	producer := p.producerFor(*msg.TopicPartition.Topic)
+	deadline := time.Now().Add(p.queueFullTimeout)
+	for {
+		err := producer.Produce(msg, deliveryChan)
+		if err == nil {
+			return nil
+		}
+		if kafkaErr, ok := err.(kafka.Error); !ok || kafkaErr.Code() != kafka.ErrQueueFull {
+			p.deadLetter(msg, err)
+			return err
+		}
+		if time.Now().After(deadline) {
+			err = fmt.Errorf("local queue still full after %v: %w", p.queueFullTimeout, err)
+			p.deadLetter(msg, err)
+			return err
+		}
+		producer.Flush(100)
+	}
+}
+
+// PublishSetDeletion publishes a tombstone for a removed set so compacted topics drop it
+func (p *Producer) PublishSetDeletion(code string) error {
+	if err := p.publishTombstone(p.topics["sets"], code, "set.deleted"); err != nil {
+		return fmt.Errorf("failed to produce set tombstone: %w", err)
+	}
+	return nil
+}
+
+// PublishCardDeletion publishes a tombstone for a removed card so compacted topics drop it
+func (p *Producer) PublishCardDeletion(uuid string) error {
+	if err := p.publishTombstone(p.topics["cards"], uuid, "card.deleted"); err != nil {
+		return fmt.Errorf("failed to produce card tombstone: %w", err)
+	}
+	return nil
+}
+
+// PublishDeckDeletion publishes a tombstone for a removed deck so compacted topics drop it
+func (p *Producer) PublishDeckDeletion(deckID string) error {
+	if err := p.publishTombstone(p.topics["decks"], deckID, "deck.deleted"); err != nil {
+		return fmt.Errorf("failed to produce deck tombstone: %w", err)
+	}
+	return nil
+}
+
+// publishTombstone produces a message with a nil value for the given key
+func (p *Producer) publishTombstone(topic, key, eventType string) error {
+	return p.produce(&kafka.Message{
+		TopicPartition: kafka.TopicPartition{Topic: &topic, Partition: kafka.PartitionAny},
+		Key:            []byte(key),
+		Value:          nil,
+		Headers: []kafka.Header{
+			{Key: "eventType", Value: []byte(eventType)},
+			{Key: "source", Value: []byte("mtgjson")},
+		},
+	}, nil)
+}
+
 // Flush waits for all messages to be delivered
 func (p *Producer) Flush(timeoutMs int) int {
-	return p.producer.Flush(timeoutMs)
+	return p.flushAll(timeoutMs)
+}
+
+// flushAttemptTimeout bounds each Flush call made by FlushWithRetry
+const flushAttemptTimeout = 5 * time.Second
+
+// flushRetryBackoff is the pause between FlushWithRetry attempts, giving the
+// client time to refresh metadata after a leader election
+const flushRetryBackoff = 250 * time.Millisecond
+
+// FlushWithRetry flushes in chunks until every message is delivered or
+// totalTimeout elapses. Messages left undelivered by one attempt are usually
+// waiting on a partition leader election, so they get further attempts before
+// being reported. It returns the number still undelivered and, if any remain,
+// an error.
+func (p *Producer) FlushWithRetry(totalTimeout time.Duration) (int, error) {
+	deadline := time.Now().Add(totalTimeout)
+	for attempt := 1; ; attempt++ {
+		timeout := min(flushAttemptTimeout, time.Until(deadline))
+		remaining := p.flushAll(int(max(timeout, 0).Milliseconds()))
+		if remaining == 0 {
+			return 0, nil
+		}
+		if time.Until(deadline) <= flushRetryBackoff {
+			return remaining, fmt.Errorf("%d messages not delivered after %d flush attempts in %v", remaining, attempt, totalTimeout)
+		}
+		p.logger.Debugf("%d messages still in flight after flush attempt %d, retrying", remaining, attempt)
+		time.Sleep(flushRetryBackoff)
+	}
 }
 
 // Close closes the producer
 func (p *Producer) Close() {
-	p.producer.Close()
+	for _, producer := range p.allProducers() {
+		producer.Close()
+	}
 }
\ No newline at end of file
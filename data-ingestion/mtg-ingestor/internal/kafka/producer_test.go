@@ -0,0 +1,121 @@
+package kafka
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/mtg/mtg-ingestor/internal/models"
+)
+
+func TestNewConfigMapQueueBuffering(t *testing.T) {
+	tests := []struct {
+		name         string
+		config       ProducerConfig
+		wantMessages interface{}
+		wantKbytes   interface{}
+	}{
+		{name: "librdkafka defaults", config: ProducerConfig{}},
+		{
+			name:         "configured limits",
+			config:       ProducerConfig{QueueBufferingMaxMessages: 500000, QueueBufferingMaxKbytes: 2097152},
+			wantMessages: 500000,
+			wantKbytes:   2097152,
+		},
+	}
+
+	for _, tt := range tests {
+		tuning, err := resolveTuning(tt.config)
+		if err != nil {
+			t.Fatalf("%s: resolveTuning: %v", tt.name, err)
+		}
+		configMap := newConfigMap(tt.config, tuning, "snappy")
+
+		messages, _ := configMap.Get("queue.buffering.max.messages", nil)
+		kbytes, _ := configMap.Get("queue.buffering.max.kbytes", nil)
+		if messages != tt.wantMessages || kbytes != tt.wantKbytes {
+			t.Errorf("%s: queue.buffering.max.messages/kbytes = %v/%v, want %v/%v",
+				tt.name, messages, kbytes, tt.wantMessages, tt.wantKbytes)
+		}
+		if compression, _ := configMap.Get("compression.type", nil); compression != "snappy" {
+			t.Errorf("%s: compression.type = %v, want snappy", tt.name, compression)
+		}
+	}
+}
+
+// testCards returns n distinct cards shaped like a typical set's
+func testCards(n int) []models.Card {
+	cards := make([]models.Card, n)
+	for i := range cards {
+		cards[i] = models.Card{
+			UUID:     fmt.Sprintf("card-%04d", i),
+			Name:     fmt.Sprintf("Card %d", i),
+			SetCode:  "DOM",
+			ManaCost: "{1}{R}",
+			Type:     "Creature — Goblin",
+			Types:    []string{"Creature"},
+			Text:     strings.Repeat("Haste. ", 10),
+		}
+	}
+	return cards
+}
+
+func TestPublishCardsPublishesEveryCard(t *testing.T) {
+	p := &Producer{logger: quietLogger(), concurrency: 4}
+	cards := testCards(50)
+
+	var mu sync.Mutex
+	seen := map[string]int{}
+	err := p.publishCards(cards, func(card models.Card) error {
+		mu.Lock()
+		defer mu.Unlock()
+		seen[card.UUID]++
+		if card.UUID == "card-0007" {
+			return errors.New("queue full")
+		}
+		return nil
+	})
+
+	if len(seen) != len(cards) {
+		t.Errorf("published %d distinct cards, want %d", len(seen), len(cards))
+	}
+	for uuid, n := range seen {
+		if n != 1 {
+			t.Errorf("card %s published %d times", uuid, n)
+		}
+	}
+	if err == nil || !strings.Contains(err.Error(), "card-0007") {
+		t.Errorf("publishCards error = %v, want the failed card", err)
+	}
+}
+
+// BenchmarkPublishSetCards measures PublishSet's card fan-out, building each
+// card's message as PublishCard does, at several worker pool sizes
+func BenchmarkPublishSetCards(b *testing.B) {
+	cards := testCards(300)
+	for _, concurrency := range []int{1, 4, 16} {
+		b.Run(fmt.Sprintf("workers=%d", concurrency), func(b *testing.B) {
+			p := &Producer{
+				logger:      quietLogger(),
+				topics:      map[string]string{"cards": "mtg.cards"},
+				concurrency: concurrency,
+				partitionBy: PartitionByUUID,
+			}
+			publish := func(card models.Card) error {
+				_, err := p.newCardMessage(card)
+				return err
+			}
+
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				if err := p.publishCards(cards, publish); err != nil {
+					b.Fatal(err)
+				}
+			}
+			b.ReportMetric(float64(b.N*len(cards))/b.Elapsed().Seconds(), "cards/s")
+		})
+	}
+}
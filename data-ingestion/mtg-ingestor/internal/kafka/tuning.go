@@ -0,0 +1,77 @@
+package kafka
+
+import (
+	"fmt"
+	"time"
+)
+
+// Producer tuning defaults, favouring durability over latency
+const (
+	defaultAcks         = "all"
+	defaultRetries      = 10
+	defaultRetryBackoff = 100 * time.Millisecond
+	defaultLinger       = 10 * time.Millisecond
+	defaultBatchSize    = 16384
+)
+
+// Ranges librdkafka accepts for the tuning settings
+const (
+	maxRetryBackoff = 5 * time.Minute
+	maxLinger       = 15 * time.Minute
+	maxBatchSize    = 2147483647
+)
+
+// validAcks lists the acks values librdkafka accepts; -1 is an alias of all
+var validAcks = map[string]bool{"0": true, "1": true, "all": true, "-1": true}
+
+// tuning is the resolved librdkafka durability/latency settings of a producer
+type tuning struct {
+	acks         string
+	retries      int
+	retryBackoff time.Duration
+	linger       time.Duration
+	batchSize    int
+}
+
+// resolveTuning applies the defaults to the tuning fields of config and
+// rejects values librdkafka would refuse or that contradict each other
+func resolveTuning(config ProducerConfig) (tuning, error) {
+	t := tuning{
+		acks:         config.Acks,
+		retries:      defaultRetries,
+		retryBackoff: config.RetryBackoff,
+		linger:       defaultLinger,
+		batchSize:    config.BatchSize,
+	}
+	if t.acks == "" {
+		t.acks = defaultAcks
+	}
+	if config.Retries != nil {
+		t.retries = *config.Retries
+	}
+	if t.retryBackoff == 0 {
+		t.retryBackoff = defaultRetryBackoff
+	}
+	if config.Linger != nil {
+		t.linger = *config.Linger
+	}
+	if t.batchSize == 0 {
+		t.batchSize = defaultBatchSize
+	}
+
+	switch {
+	case !validAcks[t.acks]:
+		return t, fmt.Errorf("invalid acks %q, expected 0, 1 or all", t.acks)
+	case config.EnableIdempotence && t.acks != "all" && t.acks != "-1":
+		return t, fmt.Errorf("acks %q is incompatible with idempotent delivery, which needs acks=all", t.acks)
+	case t.retries < 0:
+		return t, fmt.Errorf("invalid retries %d, expected 0 or more", t.retries)
+	case t.retryBackoff < time.Millisecond || t.retryBackoff > maxRetryBackoff:
+		return t, fmt.Errorf("invalid retry backoff %v, expected between 1ms and %v", t.retryBackoff, maxRetryBackoff)
+	case t.linger < 0 || t.linger > maxLinger:
+		return t, fmt.Errorf("invalid linger %v, expected between 0 and %v", t.linger, maxLinger)
+	case t.batchSize < 1 || t.batchSize > maxBatchSize:
+		return t, fmt.Errorf("invalid batch size %d, expected 1 or more bytes", t.batchSize)
+	}
+	return t, nil
+}
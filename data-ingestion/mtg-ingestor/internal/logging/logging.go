@@ -0,0 +1,47 @@
+package logging
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/sirupsen/logrus"
+)
+
+// nopCloser is returned when the output needs no closing
+type nopCloser struct{}
+
+func (nopCloser) Close() error { return nil }
+
+// Configure sets the logger's format, "json" or "text", and output, "stdout",
+// "stderr" or a file path. A file is appended to, creating it and its parent
+// directories as needed; the returned Closer closes it.
+func Configure(logger *logrus.Logger, format, output string) (io.Closer, error) {
+	switch format {
+	case "", "json":
+		logger.SetFormatter(&logrus.JSONFormatter{})
+	case "text":
+		logger.SetFormatter(&logrus.TextFormatter{FullTimestamp: true})
+	default:
+		return nil, fmt.Errorf("unknown log format %q, expected json or text", format)
+	}
+
+	switch output {
+	case "", "stderr":
+		logger.SetOutput(os.Stderr)
+	case "stdout":
+		logger.SetOutput(os.Stdout)
+	default:
+		if err := os.MkdirAll(filepath.Dir(output), 0o755); err != nil {
+			return nil, fmt.Errorf("failed to create log directory: %w", err)
+		}
+		file, err := os.OpenFile(output, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open log file: %w", err)
+		}
+		logger.SetOutput(file)
+		return file, nil
+	}
+	return nopCloser{}, nil
+}
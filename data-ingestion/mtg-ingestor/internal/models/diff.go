@@ -0,0 +1,136 @@
+package models
+
+import (
+	"sort"
+	"strings"
+)
+
+// Kinds of CardDelta
+const (
+	CardAdded   = "added"
+	CardRemoved = "removed"
+	CardChanged = "changed"
+)
+
+// FieldChange is a field's value in the old and new MTGJSON versions
+type FieldChange struct {
+	Old string `json:"old"`
+	New string `json:"new"`
+}
+
+// CardChange lists the compared fields that differ for one card, keyed by
+// "text", "rarity" or "legalities.<format>"
+type CardChange struct {
+	UUID    string                 `json:"uuid"`
+	Name    string                 `json:"name"`
+	Changes map[string]FieldChange `json:"changes"`
+}
+
+// CardDiff is the difference between two sets of cards keyed by UUID
+type CardDiff struct {
+	Added   []string     `json:"added"`
+	Removed []string     `json:"removed"`
+	Changed []CardChange `json:"changed"`
+}
+
+// IsEmpty reports whether the two versions had the same cards
+func (d CardDiff) IsEmpty() bool {
+	return len(d.Added) == 0 && len(d.Removed) == 0 && len(d.Changed) == 0
+}
+
+// CardDelta is one card's entry in a CardDiff as published downstream. Card is
+// the new version, or the old one for a removed card.
+type CardDelta struct {
+	Kind    string                 `json:"kind"`
+	Card    Card                   `json:"card"`
+	Changes map[string]FieldChange `json:"changes,omitempty"`
+}
+
+// CardDeltaEvent is a Kafka event for a card added, removed or changed between
+// MTGJSON versions
+type CardDeltaEvent struct {
+	KafkaEvent
+	CardUUID string                 `json:"card_uuid"`
+	Card     *Card                  `json:"card,omitempty"`
+	Changes  map[string]FieldChange `json:"changes,omitempty"`
+}
+
+// DiffCards compares two versions of the cards, keyed by UUID, and reports the
+// cards added, removed and those whose text, rarity or legalities changed.
+// Each list is sorted by UUID.
+func DiffCards(old, new map[string]Card) CardDiff {
+	var diff CardDiff
+	for uuid, card := range new {
+		before, ok := old[uuid]
+		if !ok {
+			diff.Added = append(diff.Added, uuid)
+			continue
+		}
+		if changes := cardChanges(before, card); len(changes) > 0 {
+			diff.Changed = append(diff.Changed, CardChange{UUID: uuid, Name: card.Name, Changes: changes})
+		}
+	}
+	for uuid := range old {
+		if _, ok := new[uuid]; !ok {
+			diff.Removed = append(diff.Removed, uuid)
+		}
+	}
+
+	sort.Strings(diff.Added)
+	sort.Strings(diff.Removed)
+	sort.Slice(diff.Changed, func(i, j int) bool { return diff.Changed[i].UUID < diff.Changed[j].UUID })
+	return diff
+}
+
+// Deltas expands the diff into one CardDelta per card, taking card data from
+// the versions it was computed from: additions, then changes, then removals
+func (d CardDiff) Deltas(old, new map[string]Card) []CardDelta {
+	deltas := make([]CardDelta, 0, len(d.Added)+len(d.Changed)+len(d.Removed))
+	for _, uuid := range d.Added {
+		deltas = append(deltas, CardDelta{Kind: CardAdded, Card: new[uuid]})
+	}
+	for _, change := range d.Changed {
+		deltas = append(deltas, CardDelta{Kind: CardChanged, Card: new[change.UUID], Changes: change.Changes})
+	}
+	for _, uuid := range d.Removed {
+		deltas = append(deltas, CardDelta{Kind: CardRemoved, Card: old[uuid]})
+	}
+	return deltas
+}
+
+// cardChanges compares the fields downstream consumers care about
+func cardChanges(old, new Card) map[string]FieldChange {
+	changes := map[string]FieldChange{}
+	if old.Text != new.Text {
+		changes["text"] = FieldChange{Old: old.Text, New: new.Text}
+	}
+	if old.Rarity != new.Rarity {
+		changes["rarity"] = FieldChange{Old: old.Rarity, New: new.Rarity}
+	}
+
+	formats := map[string]bool{}
+	for format := range old.Legalities {
+		formats[strings.ToLower(format)] = true
+	}
+	for format := range new.Legalities {
+		formats[strings.ToLower(format)] = true
+	}
+	for format := range formats {
+		before, after := legality(old, format), legality(new, format)
+		if before != after {
+			changes["legalities."+format] = FieldChange{Old: before, New: after}
+		}
+	}
+	return changes
+}
+
+// legality returns the card's status in the format, compared case-insensitively
+// on the key since MTGJSON keys are lowercase
+func legality(card Card, format string) string {
+	for key, status := range card.Legalities {
+		if strings.EqualFold(key, format) {
+			return status
+		}
+	}
+	return ""
+}
@@ -1,6 +1,11 @@
 package models
 
-import "time"
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+)
 
 // Card represents an MTG card from MTGJSON
 type Card struct {
@@ -9,6 +14,7 @@ type Card struct {
 	ManaCost        string                 `json:"manaCost,omitempty"`
 	ConvertedMana   float64                `json:"convertedManaCost"`
 	Type            string                 `json:"type"`
+	ParsedType      ParsedType             `json:"parsedType"`
 	Text            string                 `json:"text,omitempty"`
 	Power           string                 `json:"power,omitempty"`
 	Toughness       string                 `json:"toughness,omitempty"`
@@ -19,15 +25,171 @@ type Card struct {
 	Artist          string                 `json:"artist,omitempty"`
 	Number          string                 `json:"number"`
 	Layout          string                 `json:"layout"`
-	Prices          map[string]interface{} `json:"prices,omitempty"`
+	Prices          *CardPrices            `json:"prices,omitempty"`
 	Legalities      map[string]string      `json:"legalities,omitempty"`
 	Subtypes        []string               `json:"subtypes,omitempty"`
 	Supertypes      []string               `json:"supertypes,omitempty"`
 	Types           []string               `json:"types,omitempty"`
 	Keywords        []string               `json:"keywords,omitempty"`
+	FaceName        string                 `json:"faceName,omitempty"`
+	Side            string                 `json:"side,omitempty"`
+	OtherFaceIDs    []string               `json:"otherFaceIds,omitempty"`
+	Faces           []CardFace             `json:"faces,omitempty"`
+	ForeignData     []ForeignEntry         `json:"foreignData,omitempty"`
+	Rulings         []Ruling               `json:"rulings,omitempty"`
+	Identifiers     *CardIdentifiers       `json:"identifiers,omitempty"`
 	ProcessedAt     time.Time              `json:"processedAt"`
 }
 
+// CardFace is one face of a double-faced, split or otherwise multi-face card
+type CardFace struct {
+	Name      string   `json:"name"`
+	Side      string   `json:"side,omitempty"`
+	ManaCost  string   `json:"manaCost,omitempty"`
+	Type      string   `json:"type"`
+	Text      string   `json:"text,omitempty"`
+	Power     string   `json:"power,omitempty"`
+	Toughness string   `json:"toughness,omitempty"`
+	Colors    []string `json:"colors,omitempty"`
+}
+
+// ForeignEntry is a card's printed name and text in another language
+type ForeignEntry struct {
+	Language   string `json:"language"`
+	Name       string `json:"name"`
+	Type       string `json:"type,omitempty"`
+	Text       string `json:"text,omitempty"`
+	FlavorText string `json:"flavorText,omitempty"`
+}
+
+// CardIdentifiers are a card's IDs in external services
+type CardIdentifiers struct {
+	ScryfallID             string `json:"scryfallId,omitempty"`
+	ScryfallOracleID       string `json:"scryfallOracleId,omitempty"`
+	ScryfallIllustrationID string `json:"scryfallIllustrationId,omitempty"`
+	MultiverseID           string `json:"multiverseId,omitempty"`
+	MTGOID                 string `json:"mtgoId,omitempty"`
+	TCGPlayerProductID     string `json:"tcgplayerProductId,omitempty"`
+	CardKingdomID          string `json:"cardKingdomId,omitempty"`
+}
+
+// scryfallImageSizes are the image versions Scryfall's CDN serves
+var scryfallImageSizes = map[string]string{
+	"small":  ".jpg",
+	"normal": ".jpg",
+	"large":  ".jpg",
+	"png":    ".png",
+}
+
+// ImageURL returns the Scryfall CDN URL of the card's front image in the
+// given size (small, normal, large or png; anything else means normal), or ""
+// when the card has no Scryfall ID
+func (c Card) ImageURL(size string) string {
+	if c.Identifiers == nil || len(c.Identifiers.ScryfallID) < 2 {
+		return ""
+	}
+	ext, ok := scryfallImageSizes[size]
+	if !ok {
+		size, ext = "normal", ".jpg"
+	}
+	id := c.Identifiers.ScryfallID
+	return fmt.Sprintf("https://cards.scryfall.io/%s/front/%c/%c/%s%s", size, id[0], id[1], id, ext)
+}
+
+// Ruling is an official rules clarification for a card
+type Ruling struct {
+	Date string `json:"date"`
+	Text string `json:"text"`
+}
+
+// multiFaceLayouts are the MTGJSON layouts whose cards have more than one face
+var multiFaceLayouts = map[string]bool{
+	"transform":       true,
+	"modal_dfc":       true,
+	"split":           true,
+	"flip":            true,
+	"adventure":       true,
+	"meld":            true,
+	"reversible_card": true,
+}
+
+// IsMultiFaceLayout reports whether cards with the given layout have several faces
+func IsMultiFaceLayout(layout string) bool {
+	return multiFaceLayouts[layout]
+}
+
+// UnmarshalJSON decodes a card, dropping faces for single-faced layouts so
+// ordinary cards never carry a faces array, and parses the type line
+func (c *Card) UnmarshalJSON(data []byte) error {
+	type cardAlias Card
+	var alias cardAlias
+	if err := json.Unmarshal(data, &alias); err != nil {
+		return err
+	}
+	*c = Card(alias)
+	if !IsMultiFaceLayout(c.Layout) {
+		c.Faces = nil
+	}
+	if c.Type != "" {
+		c.ParsedType = ParseTypeLine(c.Type)
+	}
+	return nil
+}
+
+// IsMultiFaced reports whether the card has more than one face
+func (c Card) IsMultiFaced() bool {
+	return IsMultiFaceLayout(c.Layout) && len(c.Faces) > 1
+}
+
+// FaceNames returns the individual face names, e.g. ["Fire", "Ice"] for "Fire // Ice"
+func (c Card) FaceNames() []string {
+	if len(c.Faces) > 0 {
+		names := make([]string, 0, len(c.Faces))
+		for _, f := range c.Faces {
+			names = append(names, f.Name)
+		}
+		return names
+	}
+	return strings.Split(c.Name, " // ")
+}
+
+// Face returns the face described by this card record
+func (c Card) Face() CardFace {
+	name := c.FaceName
+	if name == "" {
+		name = c.Name
+	}
+	return CardFace{
+		Name:      name,
+		Side:      c.Side,
+		ManaCost:  c.ManaCost,
+		Type:      c.Type,
+		Text:      c.Text,
+		Power:     c.Power,
+		Toughness: c.Toughness,
+		Colors:    c.Colors,
+	}
+}
+
+// IsLegalIn reports whether the card is legal or restricted in the format, e.g. "standard"
+func (c Card) IsLegalIn(format string) bool {
+	switch strings.ToLower(c.Legalities[strings.ToLower(format)]) {
+	case "legal", "restricted":
+		return true
+	}
+	return false
+}
+
+// LocalizedName returns the card's name in the given language, e.g. "German"
+func (c Card) LocalizedName(lang string) (string, bool) {
+	for _, entry := range c.ForeignData {
+		if strings.EqualFold(entry.Language, lang) && entry.Name != "" {
+			return entry.Name, true
+		}
+	}
+	return "", false
+}
+
 // Set represents an MTG set from MTGJSON
 type Set struct {
 	Code         string    `json:"code"`
@@ -36,10 +198,22 @@ type Set struct {
 	ReleaseDate  string    `json:"releaseDate"`
 	BaseSetSize  int       `json:"baseSetSize"`
 	TotalSetSize int       `json:"totalSetSize"`
+	IsOnlineOnly bool      `json:"isOnlineOnly,omitempty"`
+	IsFoilOnly   bool      `json:"isFoilOnly,omitempty"`
 	Cards        []Card    `json:"cards"`
 	ProcessedAt  time.Time `json:"processedAt"`
 }
 
+// ParseReleaseDate parses the set's YYYY-MM-DD release date
+func (s Set) ParseReleaseDate() (time.Time, error) {
+	return time.Parse("2006-01-02", s.ReleaseDate)
+}
+
+// IsDigitalOnly reports whether the set only exists in digital form (Arena, MTGO)
+func (s Set) IsDigitalOnly() bool {
+	return s.IsOnlineOnly
+}
+
 // KafkaEvent represents an event to be published to Kafka
 type KafkaEvent struct {
 	EventType   string      `json:"eventType"`
@@ -60,4 +234,24 @@ type CardEvent struct {
 type SetEvent struct {
 	KafkaEvent
 	Set Set `json:"set"`
-}
\ No newline at end of file
+}
+
+// PriceChange is the difference between two consecutive price observations
+type PriceChange struct {
+	CardUUID  string  `json:"card_uuid"`
+	Format    string  `json:"format"`
+	Source    string  `json:"source"`
+	Type      string  `json:"type"`
+	Finish    string  `json:"finish"`
+	Foil      bool    `json:"foil"`
+	OldPrice  float64 `json:"old_price"`
+	NewPrice  float64 `json:"new_price"`
+	PctChange float64 `json:"pct_change"`
+	Date      string  `json:"date"`
+}
+
+// PriceChangeEvent is a Kafka event for a card price delta
+type PriceChangeEvent struct {
+	KafkaEvent
+	Change PriceChange `json:"change"`
+}
@@ -0,0 +1,64 @@
+package models
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestSetIsDigitalOnly(t *testing.T) {
+	tests := []struct {
+		name string
+		json string
+		want bool
+	}{
+		{"paper set", `{"code": "DOM", "name": "Dominaria"}`, false},
+		{"online only", `{"code": "VMA", "name": "Vintage Masters", "isOnlineOnly": true}`, true},
+		{"foil only paper", `{"code": "PR2", "name": "Promos", "isFoilOnly": true}`, false},
+	}
+
+	for _, tt := range tests {
+		var set Set
+		if err := json.Unmarshal([]byte(tt.json), &set); err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		if got := set.IsDigitalOnly(); got != tt.want {
+			t.Errorf("%s: IsDigitalOnly() = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestCardFaces(t *testing.T) {
+	front := Card{
+		Name:     "Delver of Secrets // Insectile Aberration",
+		FaceName: "Delver of Secrets",
+		Side:     "a",
+		ManaCost: "{U}",
+		Type:     "Creature — Human Wizard",
+		Layout:   "transform",
+		Power:    "1",
+	}
+	face := front.Face()
+	if face.Name != "Delver of Secrets" || face.Side != "a" || face.ManaCost != "{U}" || face.Power != "1" {
+		t.Errorf("Face() = %+v", face)
+	}
+
+	// Without a face name the card name is the face
+	if got := (Card{Name: "Lightning Bolt"}).Face().Name; got != "Lightning Bolt" {
+		t.Errorf("Face().Name = %q, want Lightning Bolt", got)
+	}
+
+	if front.IsMultiFaced() {
+		t.Error("card without attached faces reported as multi-faced")
+	}
+	if names := front.FaceNames(); len(names) != 2 || names[1] != "Insectile Aberration" {
+		t.Errorf("FaceNames() from the name = %q", names)
+	}
+
+	front.Faces = []CardFace{face, {Name: "Insectile Aberration", Side: "b"}}
+	if !front.IsMultiFaced() {
+		t.Error("transform card with two faces not reported as multi-faced")
+	}
+	if names := front.FaceNames(); len(names) != 2 || names[0] != "Delver of Secrets" || names[1] != "Insectile Aberration" {
+		t.Errorf("FaceNames() = %q", names)
+	}
+}
@@ -0,0 +1,98 @@
+package models
+
+import (
+	"encoding/json"
+	"sort"
+)
+
+// CardPrices holds a card's price history by marketplace, in MTGJSON's shape.
+// Keys other than paper/mtgo, and marketplaces that don't parse, are preserved
+// in Raw so they survive a round-trip.
+type CardPrices struct {
+	Paper map[string]SourcePrices    `json:"paper,omitempty"`
+	MTGO  map[string]SourcePrices    `json:"mtgo,omitempty"`
+	Raw   map[string]json.RawMessage `json:"-"`
+}
+
+// PriceHistory maps a finish (normal, foil, etched) to its prices by date (YYYY-MM-DD)
+type PriceHistory map[string]map[string]float64
+
+// SourcePrices is one provider's (tcgplayer, cardkingdom, ...) retail and
+// buylist prices. Fields are in MTGJSON's key order so re-marshalled prices
+// come out byte for byte as they were read.
+type SourcePrices struct {
+	Buylist  PriceHistory `json:"buylist,omitempty"`
+	Currency string       `json:"currency,omitempty"`
+	Retail   PriceHistory `json:"retail,omitempty"`
+}
+
+// RetailNormal returns the latest non-foil retail price, or 0 if there is none
+func (s SourcePrices) RetailNormal() float64 { return latestPrice(s.Retail["normal"]) }
+
+// RetailFoil returns the latest foil retail price, or 0 if there is none
+func (s SourcePrices) RetailFoil() float64 { return latestPrice(s.Retail["foil"]) }
+
+// BuylistNormal returns the latest non-foil buylist price, or 0 if there is none
+func (s SourcePrices) BuylistNormal() float64 { return latestPrice(s.Buylist["normal"]) }
+
+// BuylistFoil returns the latest foil buylist price, or 0 if there is none
+func (s SourcePrices) BuylistFoil() float64 { return latestPrice(s.Buylist["foil"]) }
+
+// UnmarshalJSON parses MTGJSON's nested date-keyed prices, keeping unknown
+// keys and unparseable marketplaces raw
+func (p *CardPrices) UnmarshalJSON(data []byte) error {
+	var raw map[string]json.RawMessage
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+
+	*p = CardPrices{}
+	for key, value := range raw {
+		var target *map[string]SourcePrices
+		switch key {
+		case "paper":
+			target = &p.Paper
+		case "mtgo":
+			target = &p.MTGO
+		}
+		if target != nil {
+			if err := json.Unmarshal(value, target); err == nil {
+				continue
+			}
+			*target = nil
+		}
+		if p.Raw == nil {
+			p.Raw = map[string]json.RawMessage{}
+		}
+		p.Raw[key] = value
+	}
+	return nil
+}
+
+// MarshalJSON writes paper/mtgo alongside any keys kept in Raw
+func (p CardPrices) MarshalJSON() ([]byte, error) {
+	out := make(map[string]interface{}, len(p.Raw)+2)
+	for key, value := range p.Raw {
+		out[key] = value
+	}
+	if len(p.Paper) > 0 {
+		out["paper"] = p.Paper
+	}
+	if len(p.MTGO) > 0 {
+		out["mtgo"] = p.MTGO
+	}
+	return json.Marshal(out)
+}
+
+// latestPrice returns the price for the most recent date (YYYY-MM-DD sorts chronologically)
+func latestPrice(byDate map[string]float64) float64 {
+	if len(byDate) == 0 {
+		return 0
+	}
+	dates := make([]string, 0, len(byDate))
+	for date := range byDate {
+		dates = append(dates, date)
+	}
+	sort.Strings(dates)
+	return byDate[dates[len(dates)-1]]
+}
@@ -0,0 +1,86 @@
+package models
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+)
+
+// mtgjsonPrices is a card's prices as MTGJSON publishes them, compacted
+const mtgjsonPrices = `{"mtgo":{"cardhoarder":{"currency":"USD","retail":{"normal":{"2024-01-01":0.02}}}},` +
+	`"paper":{"cardkingdom":{"buylist":{"foil":{"2024-01-01":1.1},"normal":{"2024-01-01":0.5}},"currency":"USD","retail":{"foil":{"2024-01-01":3.49},"normal":{"2024-01-01":1.49}}},` +
+	`"tcgplayer":{"currency":"USD","retail":{"etched":{"2024-01-01":7.5,"2024-01-02":8},"normal":{"2024-01-01":1.2,"2024-01-02":1.25}}}},` +
+	`"unknown":{"anything":[1,2]}}`
+
+func TestCardPricesRoundTrip(t *testing.T) {
+	var prices CardPrices
+	if err := json.Unmarshal([]byte(mtgjsonPrices), &prices); err != nil {
+		t.Fatalf("Unmarshal: %v", err)
+	}
+	out, err := json.Marshal(prices)
+	if err != nil {
+		t.Fatalf("Marshal: %v", err)
+	}
+	if !bytes.Equal(out, []byte(mtgjsonPrices)) {
+		t.Errorf("round trip changed the prices:\n got %s\nwant %s", out, mtgjsonPrices)
+	}
+
+	// Prices nested in a card keep their shape too
+	var card Card
+	if err := json.Unmarshal([]byte(`{"name":"Lightning Bolt","prices":`+mtgjsonPrices+`}`), &card); err != nil {
+		t.Fatalf("Unmarshal card: %v", err)
+	}
+	var decoded struct {
+		Prices json.RawMessage `json:"prices"`
+	}
+	data, err := json.Marshal(card)
+	if err != nil {
+		t.Fatalf("Marshal card: %v", err)
+	}
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatalf("Unmarshal card event: %v", err)
+	}
+	if !bytes.Equal(decoded.Prices, []byte(mtgjsonPrices)) {
+		t.Errorf("card round trip changed the prices:\n got %s\nwant %s", decoded.Prices, mtgjsonPrices)
+	}
+}
+
+func TestSourcePricesLatest(t *testing.T) {
+	var prices CardPrices
+	if err := json.Unmarshal([]byte(mtgjsonPrices), &prices); err != nil {
+		t.Fatalf("Unmarshal: %v", err)
+	}
+
+	tcg := prices.Paper["tcgplayer"]
+	if got := tcg.RetailNormal(); got != 1.25 {
+		t.Errorf("RetailNormal = %v, want the latest date's 1.25", got)
+	}
+	if got := tcg.Retail["etched"]["2024-01-02"]; got != 8 {
+		t.Errorf("etched retail = %v, want 8", got)
+	}
+	if got := tcg.RetailFoil(); got != 0 {
+		t.Errorf("RetailFoil without foil prices = %v, want 0", got)
+	}
+
+	ck := prices.Paper["cardkingdom"]
+	if ck.BuylistNormal() != 0.5 || ck.BuylistFoil() != 1.1 || ck.RetailFoil() != 3.49 {
+		t.Errorf("cardkingdom prices = %+v", ck)
+	}
+	if prices.MTGO["cardhoarder"].Currency != "USD" {
+		t.Errorf("mtgo prices = %+v", prices.MTGO)
+	}
+}
+
+func TestCardPricesKeepsUnparseableMarketplaceRaw(t *testing.T) {
+	const odd = `{"paper":["not","an","object"]}`
+	var prices CardPrices
+	if err := json.Unmarshal([]byte(odd), &prices); err != nil {
+		t.Fatalf("Unmarshal: %v", err)
+	}
+	if prices.Paper != nil {
+		t.Errorf("Paper = %+v, want nil", prices.Paper)
+	}
+	if out, err := json.Marshal(prices); err != nil || string(out) != odd {
+		t.Errorf("Marshal = %s, %v, want %s", out, err, odd)
+	}
+}
@@ -0,0 +1,62 @@
+package models
+
+// Color identity buckets used by SetSummary besides the five colors
+const (
+	ColorIdentityColorless  = "colorless"
+	ColorIdentityMulticolor = "multicolor"
+)
+
+// SetSummary rolls up a set's cards by color identity and rarity, so
+// dashboards don't have to re-aggregate card events
+type SetSummary struct {
+	SetCode     string `json:"set_code"`
+	SetName     string `json:"set_name"`
+	ReleaseDate string `json:"release_date,omitempty"`
+	TotalCards  int    `json:"total_cards"`
+	// ByColorIdentity counts cards per color (W, U, B, R, G), colorless and multicolor
+	ByColorIdentity map[string]int `json:"by_color_identity"`
+	// ByRarity counts cards per rarity, e.g. common or mythic
+	ByRarity map[string]int `json:"by_rarity"`
+}
+
+// SetSummaryEvent is a Kafka event carrying a set's rollup
+type SetSummaryEvent struct {
+	KafkaEvent
+	Summary SetSummary `json:"summary"`
+}
+
+// SummarizeSet counts the set's cards by color identity and rarity. The back
+// faces of multi-face cards are listed as cards of their own and are skipped,
+// so each card counts once.
+func SummarizeSet(set Set) SetSummary {
+	summary := SetSummary{
+		SetCode:         set.Code,
+		SetName:         set.Name,
+		ReleaseDate:     set.ReleaseDate,
+		ByColorIdentity: map[string]int{},
+		ByRarity:        map[string]int{},
+	}
+
+	for _, card := range set.Cards {
+		if card.Side != "" && card.Side != "a" {
+			continue
+		}
+		summary.TotalCards++
+
+		switch len(card.ColorIdentity) {
+		case 0:
+			summary.ByColorIdentity[ColorIdentityColorless]++
+		case 1:
+			summary.ByColorIdentity[card.ColorIdentity[0]]++
+		default:
+			summary.ByColorIdentity[ColorIdentityMulticolor]++
+		}
+
+		rarity := card.Rarity
+		if rarity == "" {
+			rarity = "unknown"
+		}
+		summary.ByRarity[rarity]++
+	}
+	return summary
+}
@@ -0,0 +1,75 @@
+package models
+
+import "strings"
+
+// ParsedType is a type line split into its parts, e.g. "Legendary Creature — Elf Warrior"
+// gives Supertypes [Legendary], CardTypes [Creature] and Subtypes [Elf Warrior]
+type ParsedType struct {
+	Supertypes []string `json:"supertypes,omitempty"`
+	CardTypes  []string `json:"cardTypes,omitempty"`
+	Subtypes   []string `json:"subtypes,omitempty"`
+}
+
+// knownSupertypes are the words before the dash that are supertypes rather than card types
+var knownSupertypes = map[string]bool{
+	"Basic":     true,
+	"Legendary": true,
+	"Ongoing":   true,
+	"Snow":      true,
+	"World":     true,
+}
+
+// typeSeparators split a type line into types and subtypes; exports without
+// the em-dash use a spaced hyphen, as hyphenated subtypes like
+// "Assembly-Worker" exist
+var typeSeparators = []string{"—", " - "}
+
+// ParseTypeLine splits a type line into supertypes, card types and subtypes.
+// Each half of a split card's "Instant // Sorcery" line is parsed and merged.
+// Words before the dash that aren't known supertypes count as card types.
+func ParseTypeLine(line string) ParsedType {
+	var parsed ParsedType
+	for _, half := range strings.Split(line, "//") {
+		types, subtypes := splitTypeLine(half)
+		for _, word := range strings.Fields(types) {
+			if knownSupertypes[word] {
+				parsed.Supertypes = appendUnique(parsed.Supertypes, word)
+			} else {
+				parsed.CardTypes = appendUnique(parsed.CardTypes, word)
+			}
+		}
+		for _, word := range strings.Fields(subtypes) {
+			parsed.Subtypes = appendUnique(parsed.Subtypes, word)
+		}
+	}
+	return parsed
+}
+
+// Has reports whether the card type (e.g. "Creature") is in the parsed line
+func (t ParsedType) Has(cardType string) bool {
+	for _, ct := range t.CardTypes {
+		if strings.EqualFold(ct, cardType) {
+			return true
+		}
+	}
+	return false
+}
+
+// splitTypeLine returns the parts of a type line before and after its dash
+func splitTypeLine(line string) (string, string) {
+	for _, sep := range typeSeparators {
+		if types, subtypes, found := strings.Cut(line, sep); found {
+			return types, subtypes
+		}
+	}
+	return line, ""
+}
+
+func appendUnique(list []string, value string) []string {
+	for _, existing := range list {
+		if existing == value {
+			return list
+		}
+	}
+	return append(list, value)
+}
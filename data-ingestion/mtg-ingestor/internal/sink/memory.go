@@ -0,0 +1,140 @@
+package sink
+
+import (
+	"sync"
+
+	"github.com/mtg/mtg-ingestor/internal/models"
+)
+
+// InMemorySink records everything published to it, for tests and local runs
+// without Kafka
+type InMemorySink struct {
+	mu           sync.Mutex
+	cards        []models.Card
+	sets         []models.Set
+	prices       []interface{}
+	priceChanges []models.PriceChange
+	setSummaries []models.SetSummary
+	cardDeltas   []models.CardDelta
+}
+
+// NewInMemorySink creates an empty InMemorySink
+func NewInMemorySink() *InMemorySink {
+	return &InMemorySink{}
+}
+
+// PublishCard records a card
+func (s *InMemorySink) PublishCard(card models.Card) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.cards = append(s.cards, card)
+	return nil
+}
+
+// PublishSet records a set and, like the Kafka producer, each of its cards
+func (s *InMemorySink) PublishSet(set models.Set) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.sets = append(s.sets, set)
+	s.cards = append(s.cards, set.Cards...)
+	return nil
+}
+
+// PublishPrice records a price
+func (s *InMemorySink) PublishPrice(price interface{}) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.prices = append(s.prices, price)
+	return nil
+}
+
+// PublishPriceChange records a price change
+func (s *InMemorySink) PublishPriceChange(change models.PriceChange) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.priceChanges = append(s.priceChanges, change)
+	return nil
+}
+
+// PublishSetSummary records a set summary
+func (s *InMemorySink) PublishSetSummary(summary models.SetSummary) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.setSummaries = append(s.setSummaries, summary)
+	return nil
+}
+
+// PublishCardDelta records a card delta
+func (s *InMemorySink) PublishCardDelta(delta models.CardDelta) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.cardDeltas = append(s.cardDeltas, delta)
+	return nil
+}
+
+// Flush is a no-op; everything is recorded immediately
+func (s *InMemorySink) Flush(timeoutMs int) int {
+	return 0
+}
+
+// Close is a no-op
+func (s *InMemorySink) Close() {}
+
+// Cards returns a copy of the recorded cards
+func (s *InMemorySink) Cards() []models.Card {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]models.Card(nil), s.cards...)
+}
+
+// Sets returns a copy of the recorded sets
+func (s *InMemorySink) Sets() []models.Set {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]models.Set(nil), s.sets...)
+}
+
+// Prices returns a copy of the recorded prices
+func (s *InMemorySink) Prices() []interface{} {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]interface{}(nil), s.prices...)
+}
+
+// PriceChanges returns a copy of the recorded price changes
+func (s *InMemorySink) PriceChanges() []models.PriceChange {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]models.PriceChange(nil), s.priceChanges...)
+}
+
+// SetSummaries returns a copy of the recorded set summaries
+func (s *InMemorySink) SetSummaries() []models.SetSummary {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]models.SetSummary(nil), s.setSummaries...)
+}
+
+// CardDeltas returns a copy of the recorded card deltas
+func (s *InMemorySink) CardDeltas() []models.CardDelta {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]models.CardDelta(nil), s.cardDeltas...)
+}
+
+// NoopSink discards everything published to it
+type NoopSink struct{}
+
+func (NoopSink) PublishCard(card models.Card) error                 { return nil }
+func (NoopSink) PublishSet(set models.Set) error                    { return nil }
+func (NoopSink) PublishPrice(price interface{}) error               { return nil }
+func (NoopSink) PublishPriceChange(change models.PriceChange) error { return nil }
+func (NoopSink) PublishSetSummary(summary models.SetSummary) error  { return nil }
+func (NoopSink) PublishCardDelta(delta models.CardDelta) error      { return nil }
+func (NoopSink) Flush(timeoutMs int) int                            { return 0 }
+func (NoopSink) Close()                                             {}
+
+var (
+	_ Sink = (*InMemorySink)(nil)
+	_ Sink = NoopSink{}
+)
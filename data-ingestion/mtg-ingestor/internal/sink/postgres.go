@@ -0,0 +1,295 @@
+package sink
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/lib/pq"
+	"github.com/mtg/mtg-ingestor/internal/models"
+	"github.com/sirupsen/logrus"
+)
+
+// PostgresSink upserts cards and sets into the sql/schema.sql tables instead
+// of publishing them to Kafka. Rows are buffered and written in batches.
+type PostgresSink struct {
+	mu        sync.Mutex
+	db        *sql.DB
+	logger    *logrus.Logger
+	batchSize int
+
+	cards []models.Card
+	sets  []models.Set
+}
+
+// ErrPricesUnsupported is returned for every price published to the Postgres sink
+var ErrPricesUnsupported = errors.New("postgres sink does not store prices")
+
+// maxBindParameters is the most placeholders Postgres accepts in one statement
+const maxBindParameters = 65535
+
+// cardColumns are the columns of the cards upsert, the widest batch written
+var cardColumns = []string{
+	"uuid", "name", "mana_cost", "converted_mana_cost", "type", "text", "power", "toughness",
+	"colors", "color_identity", "set_code", "rarity", "artist", "number", "layout", "processed_at",
+}
+
+// maxBatchSize is the largest BatchSize whose card upserts stay within
+// Postgres's bind parameter limit
+var maxBatchSize = maxBindParameters / len(cardColumns)
+
+// PostgresConfig holds the connection settings for a PostgresSink
+type PostgresConfig struct {
+	DSN string
+	// BatchSize is the number of rows per INSERT; defaults to 500 and is
+	// capped so a card batch stays within Postgres's bind parameter limit
+	BatchSize int
+	Logger    *logrus.Logger
+}
+
+// NewPostgresSink opens the database and verifies the connection
+func NewPostgresSink(config PostgresConfig) (*PostgresSink, error) {
+	db, err := sql.Open("postgres", config.DSN)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open postgres: %w", err)
+	}
+	if err := db.Ping(); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to connect to postgres: %w", err)
+	}
+
+	batchSize := config.BatchSize
+	if batchSize <= 0 {
+		batchSize = 500
+	}
+	if batchSize > maxBatchSize {
+		config.Logger.Warnf("Postgres batch size %d exceeds the bind parameter limit, using %d", batchSize, maxBatchSize)
+		batchSize = maxBatchSize
+	}
+
+	return &PostgresSink{db: db, logger: config.Logger, batchSize: batchSize}, nil
+}
+
+// PublishCard buffers a card, writing the batch once it is full. A failed
+// write returns a BatchError counting every buffered card it lost.
+func (s *PostgresSink) PublishCard(card models.Card) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.cards = append(s.cards, card)
+	if len(s.cards) < s.batchSize {
+		return nil
+	}
+	return s.flushCards()
+}
+
+// PublishSet buffers a set and its cards. A failed write returns a
+// BatchError counting every buffered row it lost.
+func (s *PostgresSink) PublishSet(set models.Set) error {
+	s.mu.Lock()
+	s.sets = append(s.sets, set)
+	var err error
+	if len(s.sets) >= s.batchSize {
+		err = s.flushSets()
+	}
+	s.mu.Unlock()
+
+	lost := 0
+	if err != nil {
+		lost = FailedRecords(err)
+	}
+	for _, card := range set.Cards {
+		if cardErr := s.PublishCard(card); cardErr != nil {
+			lost += FailedRecords(cardErr)
+			err = errors.Join(err, cardErr)
+		}
+	}
+	if err != nil {
+		return &BatchError{Records: lost, Err: err}
+	}
+	return nil
+}
+
+// PublishPrice always fails: the Postgres sink does not store prices yet
+func (s *PostgresSink) PublishPrice(price interface{}) error {
+	return ErrPricesUnsupported
+}
+
+// Flush writes any buffered rows and returns how many could not be written.
+// The timeout is unused; it exists to match the Kafka producer.
+func (s *PostgresSink) Flush(timeoutMs int) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	failed := 0
+	if n := len(s.sets); n > 0 {
+		if err := s.flushSets(); err != nil {
+			s.logger.Errorf("Failed to write %d sets: %v", n, err)
+			failed += n
+		}
+	}
+	if n := len(s.cards); n > 0 {
+		if err := s.flushCards(); err != nil {
+			s.logger.Errorf("Failed to write %d cards: %v", n, err)
+			failed += n
+		}
+	}
+	return failed
+}
+
+// Close closes the database handle
+func (s *PostgresSink) Close() {
+	s.db.Close()
+}
+
+// flushCards upserts the buffered cards; callers hold s.mu. The buffer is
+// cleared either way, so a failure is a BatchError counting the lost cards.
+func (s *PostgresSink) flushCards() error {
+	cards := s.cards
+	s.cards = nil
+	if err := s.writeCards(cards); err != nil {
+		return &BatchError{Records: len(cards), Err: err}
+	}
+	return nil
+}
+
+func (s *PostgresSink) writeCards(cards []models.Card) error {
+
+	rows := make([][]interface{}, 0, len(cards))
+	for _, c := range cards {
+		rows = append(rows, []interface{}{
+			c.UUID, c.Name, c.ManaCost, c.ConvertedMana, c.Type, c.Text, c.Power, c.Toughness,
+			pq.Array(c.Colors), pq.Array(c.ColorIdentity), c.SetCode, c.Rarity, c.Artist,
+			c.Number, c.Layout, c.ProcessedAt,
+		})
+	}
+
+	if err := s.upsert("cards", "uuid", cardColumns, rows); err != nil {
+		return err
+	}
+	return s.replaceKeywords(cards)
+}
+
+// replaceKeywords rewrites the card_keywords rows of the given cards
+func (s *PostgresSink) replaceKeywords(cards []models.Card) error {
+	uuids := make([]string, 0, len(cards))
+	var args []interface{}
+	for _, c := range cards {
+		uuids = append(uuids, c.UUID)
+		for _, keyword := range c.Keywords {
+			args = append(args, c.UUID, keyword)
+		}
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin keyword update: %w", err)
+	}
+	defer tx.Rollback()
+	if _, err := tx.Exec("DELETE FROM card_keywords WHERE card_uuid = ANY($1)", pq.Array(uuids)); err != nil {
+		return fmt.Errorf("failed to clear card keywords: %w", err)
+	}
+	// Cards average several keywords, so split the rows to stay within the bind parameter limit
+	for len(args) > 0 {
+		chunk := args[:min(len(args), maxBindParameters-maxBindParameters%2)]
+		args = args[len(chunk):]
+
+		var query strings.Builder
+		for i := 0; i < len(chunk); i += 2 {
+			if i > 0 {
+				query.WriteString(", ")
+			}
+			fmt.Fprintf(&query, "($%d, $%d)", i+1, i+2)
+		}
+		if _, err := tx.Exec("INSERT INTO card_keywords (card_uuid, keyword) VALUES "+query.String()+
+			" ON CONFLICT DO NOTHING", chunk...); err != nil {
+			return fmt.Errorf("failed to insert card keywords: %w", err)
+		}
+	}
+	return tx.Commit()
+}
+
+// flushSets upserts the buffered sets; callers hold s.mu. The buffer is
+// cleared either way, so a failure is a BatchError counting the lost sets.
+func (s *PostgresSink) flushSets() error {
+	sets := s.sets
+	s.sets = nil
+	if err := s.writeSets(sets); err != nil {
+		return &BatchError{Records: len(sets), Err: err}
+	}
+	return nil
+}
+
+func (s *PostgresSink) writeSets(sets []models.Set) error {
+
+	rows := make([][]interface{}, 0, len(sets))
+	for _, set := range sets {
+		var releaseDate interface{}
+		if set.ReleaseDate != "" {
+			releaseDate = set.ReleaseDate
+		}
+		rows = append(rows, []interface{}{
+			set.Code, set.Name, set.Type, releaseDate, set.BaseSetSize, set.TotalSetSize, set.ProcessedAt,
+		})
+	}
+
+	return s.upsert("sets", "code", []string{
+		"code", "name", "type", "release_date", "base_set_size", "total_set_size", "processed_at",
+	}, rows)
+}
+
+// upsert writes rows with a single INSERT ... ON CONFLICT DO UPDATE. The key
+// must be the first column; rows repeating a key keep the last one, since
+// Postgres rejects a statement that updates the same row twice.
+func (s *PostgresSink) upsert(table, key string, columns []string, rows [][]interface{}) error {
+	seen := make(map[interface{}]int, len(rows))
+	unique := rows[:0]
+	for _, row := range rows {
+		if i, ok := seen[row[0]]; ok {
+			unique[i] = row
+			continue
+		}
+		seen[row[0]] = len(unique)
+		unique = append(unique, row)
+	}
+	rows = unique
+	if len(rows) == 0 {
+		return nil
+	}
+
+	var query strings.Builder
+	fmt.Fprintf(&query, "INSERT INTO %s (%s) VALUES ", table, strings.Join(columns, ", "))
+
+	args := make([]interface{}, 0, len(rows)*len(columns))
+	for i, row := range rows {
+		if i > 0 {
+			query.WriteString(", ")
+		}
+		query.WriteString("(")
+		for j := range row {
+			if j > 0 {
+				query.WriteString(", ")
+			}
+			fmt.Fprintf(&query, "$%d", len(args)+j+1)
+		}
+		query.WriteString(")")
+		args = append(args, row...)
+	}
+
+	updates := make([]string, 0, len(columns))
+	for _, col := range columns {
+		if col != key {
+			updates = append(updates, fmt.Sprintf("%s = EXCLUDED.%s", col, col))
+		}
+	}
+	updates = append(updates, "updated_at = CURRENT_TIMESTAMP")
+	fmt.Fprintf(&query, " ON CONFLICT (%s) DO UPDATE SET %s", key, strings.Join(updates, ", "))
+
+	if _, err := s.db.Exec(query.String(), args...); err != nil {
+		return fmt.Errorf("failed to upsert %d %s: %w", len(rows), table, err)
+	}
+	s.logger.Debugf("Upserted %d rows into %s", len(rows), table)
+	return nil
+}
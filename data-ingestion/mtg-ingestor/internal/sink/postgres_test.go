@@ -0,0 +1,98 @@
+package sink
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"testing"
+
+	"github.com/mtg/mtg-ingestor/internal/models"
+	"github.com/sirupsen/logrus"
+)
+
+// unreachableSink returns a PostgresSink whose every write fails to connect
+func unreachableSink(t *testing.T, batchSize int) *PostgresSink {
+	t.Helper()
+	db, err := sql.Open("postgres", "host=127.0.0.1 port=1 sslmode=disable connect_timeout=1")
+	if err != nil {
+		t.Fatalf("sql.Open: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+	return &PostgresSink{db: db, logger: logger, batchSize: batchSize}
+}
+
+func TestPostgresSinkCountsLostBatch(t *testing.T) {
+	s := unreachableSink(t, 3)
+
+	for i := 0; i < 2; i++ {
+		if err := s.PublishCard(models.Card{UUID: fmt.Sprint(i)}); err != nil {
+			t.Fatalf("buffered card %d: %v", i, err)
+		}
+	}
+	err := s.PublishCard(models.Card{UUID: "2"})
+	if err == nil {
+		t.Fatal("PublishCard of a full batch succeeded without a database")
+	}
+	if n := FailedRecords(err); n != 3 {
+		t.Errorf("FailedRecords = %d, want the whole batch of 3", n)
+	}
+	if len(s.cards) != 0 {
+		t.Errorf("%d cards left buffered after the failed write", len(s.cards))
+	}
+}
+
+func TestPostgresSinkCountsLostSetRows(t *testing.T) {
+	s := unreachableSink(t, 2)
+
+	// The set fills the set batch and its three cards fill one card batch
+	if err := s.PublishSet(models.Set{Code: "AAA"}); err != nil {
+		t.Fatalf("buffered set: %v", err)
+	}
+	err := s.PublishSet(models.Set{Code: "BBB", Cards: []models.Card{{UUID: "a"}, {UUID: "b"}, {UUID: "c"}}})
+	if err == nil {
+		t.Fatal("PublishSet succeeded without a database")
+	}
+	if n := FailedRecords(err); n != 4 {
+		t.Errorf("FailedRecords = %d, want 2 sets and 2 cards", n)
+	}
+	if len(s.cards) != 1 {
+		t.Errorf("%d cards buffered, want the one after the failed batch", len(s.cards))
+	}
+}
+
+func TestPostgresSinkRejectsPrices(t *testing.T) {
+	s := unreachableSink(t, 1)
+	if err := s.PublishPrice(map[string]interface{}{"card_uuid": "a"}); !errors.Is(err, ErrPricesUnsupported) {
+		t.Errorf("PublishPrice = %v, want ErrPricesUnsupported", err)
+	}
+	if _, ok := interface{}(s).(PriceChangeSink); ok {
+		t.Error("PostgresSink claims to store price changes")
+	}
+}
+
+func TestBatchSizeCap(t *testing.T) {
+	if params := maxBatchSize * len(cardColumns); params > maxBindParameters {
+		t.Errorf("a full card batch binds %d parameters, over the limit of %d", params, maxBindParameters)
+	}
+}
+
+func TestFailedRecords(t *testing.T) {
+	batch := &BatchError{Records: 7, Err: errors.New("connection reset")}
+	tests := []struct {
+		err  error
+		want int
+	}{
+		{errors.New("queue full"), 1},
+		{batch, 7},
+		{fmt.Errorf("set DOM: %w", batch), 7},
+	}
+	for _, tt := range tests {
+		if got := FailedRecords(tt.err); got != tt.want {
+			t.Errorf("FailedRecords(%v) = %d, want %d", tt.err, got, tt.want)
+		}
+	}
+}
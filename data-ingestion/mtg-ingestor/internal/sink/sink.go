@@ -0,0 +1,63 @@
+// Package sink defines where ingested MTGJSON data is written, so the driver
+// doesn't depend on Kafka directly.
+package sink
+
+import (
+	"errors"
+
+	"github.com/mtg/mtg-ingestor/internal/models"
+)
+
+// Sink receives ingested cards, sets and prices. The Kafka producer is the
+// default implementation.
+type Sink interface {
+	PublishCard(card models.Card) error
+	PublishSet(set models.Set) error
+	PublishPrice(price interface{}) error
+	// Flush waits for buffered records to be written and returns how many weren't
+	Flush(timeoutMs int) int
+	Close()
+}
+
+// PriceChangeSink is implemented by sinks that can also record price deltas
+type PriceChangeSink interface {
+	PublishPriceChange(change models.PriceChange) error
+}
+
+// SetSummarySink is implemented by sinks that can also record set rollups
+type SetSummarySink interface {
+	PublishSetSummary(summary models.SetSummary) error
+}
+
+// CardDeltaSink is implemented by sinks that can publish only the cards that
+// changed since the previous MTGJSON version
+type CardDeltaSink interface {
+	PublishCardDelta(delta models.CardDelta) error
+}
+
+var _ Sink = (*PostgresSink)(nil)
+
+// BatchError reports a failed write of several buffered records at once, so
+// callers can count every record the write lost rather than one
+type BatchError struct {
+	Records int
+	Err     error
+}
+
+func (e *BatchError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *BatchError) Unwrap() error {
+	return e.Err
+}
+
+// FailedRecords returns how many records a publish error lost: a BatchError's
+// record count, otherwise 1
+func FailedRecords(err error) int {
+	var batchErr *BatchError
+	if errors.As(err, &batchErr) {
+		return batchErr.Records
+	}
+	return 1
+}
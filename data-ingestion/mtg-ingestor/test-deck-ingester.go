@@ -5,6 +5,7 @@ import (
 	"flag"
 	"fmt"
 	"log"
+	"os"
 
 	"github.com/mtg/mtg-ingestor/internal/deck"
 	"github.com/sirupsen/logrus"
@@ -12,12 +13,54 @@ import (
 
 func main() {
 	dirPath := flag.String("dir", "../../decks", "Directory containing deck files")
+	recursive := flag.Bool("recursive", true, "Also ingest deck files in subdirectories")
+	export := flag.String("export", "", "Write parsed decks to stdout as json, csv or txt instead of the summary")
+	deckFormat := flag.String("deck-format", "auto", "Decklist format: auto, plain, arena, mtgo or dek")
+	diff := flag.Bool("diff", false, "Compare two deck files given as arguments: --diff fileA fileB")
+	nameDirective := flag.String("name-directive", deck.DefaultNameDirective, "Comment prefix of a line naming the deck; empty names decks after their file")
 	flag.Parse()
 
 	logger := logrus.New()
 	logger.SetLevel(logrus.InfoLevel)
 
 	ingester := deck.NewIngester(logger)
+	ingester.Recursive = *recursive
+	format, err := deck.ParseDeckFormat(*deckFormat)
+	if err != nil {
+		log.Fatal(err)
+	}
+	ingester.Format = format
+	ingester.NameDirective = *nameDirective
+
+	if *diff {
+		if flag.NArg() != 2 {
+			log.Fatal("--diff requires exactly two deck files")
+		}
+		logger.SetOutput(os.Stderr)
+		a, err := ingester.IngestFile(flag.Arg(0))
+		if err != nil {
+			log.Fatal(err)
+		}
+		b, err := ingester.IngestFile(flag.Arg(1))
+		if err != nil {
+			log.Fatal(err)
+		}
+		printDeckDiff(a, b, deck.DiffDecks(a, b))
+		return
+	}
+
+	if *export != "" {
+		// Keep stdout clean for the export
+		logger.SetOutput(os.Stderr)
+		decks, err := ingester.IngestDirectory(*dirPath)
+		if err != nil {
+			log.Fatal(err)
+		}
+		if err := deck.ExportDecks(decks, *export, os.Stdout); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
 
 	fmt.Printf("Ingesting decks from: %s\n\n", *dirPath)
 	decks, err := ingester.IngestDirectory(*dirPath)
@@ -69,4 +112,31 @@ func main() {
 			fmt.Printf("(Total %d card events would be created)\n", len(cardEvents))
 		}
 	}
-}
\ No newline at end of file
+}
+
+// printDeckDiff prints a +/- summary of the changes from deck a to deck b
+func printDeckDiff(a, b *deck.Deck, diff deck.DeckDiff) {
+	fmt.Printf("--- %s\n+++ %s\n", a.FilePath, b.FilePath)
+	if diff.IsEmpty() {
+		fmt.Println("No changes")
+		return
+	}
+	printBoardDiff("Main", diff.Main)
+	printBoardDiff("Sideboard", diff.Sideboard)
+}
+
+func printBoardDiff(board string, diff deck.BoardDiff) {
+	if diff.IsEmpty() {
+		return
+	}
+	fmt.Printf("\n%s:\n", board)
+	for _, card := range diff.Removed {
+		fmt.Printf("- %dx %s\n", card.Quantity, card.Name)
+	}
+	for _, card := range diff.Added {
+		fmt.Printf("+ %dx %s\n", card.Quantity, card.Name)
+	}
+	for _, change := range diff.Changed {
+		fmt.Printf("~ %s: %d -> %d (%+d)\n", change.Name, change.From, change.To, change.Delta)
+	}
+}
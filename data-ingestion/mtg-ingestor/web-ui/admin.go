@@ -0,0 +1,258 @@
+package main
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/mtg/mtg-ingestor/internal/fetcher"
+	"github.com/mtg/mtg-ingestor/internal/kafka"
+)
+
+// ingestPhases are the data types an on-demand ingestion can run
+var ingestPhases = []string{"sets", "cards", "prices"}
+
+// ingestJob describes an on-demand ingestion run
+type ingestJob struct {
+	ID         string         `json:"id"`
+	Status     string         `json:"status"` // running, completed, failed
+	Phases     []string       `json:"phases"`
+	StartedAt  time.Time      `json:"started_at"`
+	FinishedAt *time.Time     `json:"finished_at,omitempty"`
+	Published  map[string]int `json:"published"`
+	Errors     []string       `json:"errors,omitempty"`
+}
+
+// ingestRunner runs at most one ingestion at a time and remembers past jobs
+type ingestRunner struct {
+	mu     sync.Mutex
+	jobs   map[string]*ingestJob
+	active string
+}
+
+func newIngestRunner() *ingestRunner {
+	return &ingestRunner{jobs: map[string]*ingestJob{}}
+}
+
+// Start launches a job for the given phases, failing if one is already running
+func (r *ingestRunner) Start(phases []string) (*ingestJob, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.active != "" {
+		return nil, fmt.Errorf("ingestion %s is already running", r.active)
+	}
+
+	job := &ingestJob{
+		ID:        uuid.New().String(),
+		Status:    "running",
+		Phases:    phases,
+		StartedAt: time.Now(),
+		Published: map[string]int{},
+	}
+	r.jobs[job.ID] = job
+	r.active = job.ID
+
+	go func() {
+		r.runIngestion(job)
+
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		now := time.Now()
+		job.FinishedAt = &now
+		if len(job.Errors) > 0 {
+			job.Status = "failed"
+		} else {
+			job.Status = "completed"
+		}
+		r.active = ""
+	}()
+
+	return r.snapshot(job), nil
+}
+
+// Get returns a copy of the job with the given ID
+func (r *ingestRunner) Get(id string) (*ingestJob, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	job, ok := r.jobs[id]
+	if !ok {
+		return nil, false
+	}
+	return r.snapshot(job), true
+}
+
+// snapshot copies a job so callers can encode it without holding the lock
+func (r *ingestRunner) snapshot(job *ingestJob) *ingestJob {
+	c := *job
+	c.Published = make(map[string]int, len(job.Published))
+	for k, v := range job.Published {
+		c.Published[k] = v
+	}
+	c.Errors = append([]string(nil), job.Errors...)
+	return &c
+}
+
+func (r *ingestRunner) record(job *ingestJob, phase string, published int, err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	job.Published[phase] = published
+	if err != nil {
+		job.Errors = append(job.Errors, fmt.Sprintf("%s: %v", phase, err))
+	}
+}
+
+// runIngestion fetches the requested phases from MTGJSON and publishes them to Kafka
+func (r *ingestRunner) runIngestion(job *ingestJob) {
+	producer, err := kafka.NewProducer(kafka.ProducerConfig{
+		Brokers:     getEnv("KAFKA_BROKERS", "kafka:29092"),
+		CardsTopic:  getEnv("KAFKA_TOPIC_CARDS", "mtg.cards"),
+		SetsTopic:   getEnv("KAFKA_TOPIC_SETS", "mtg.sets"),
+		PricesTopic: getEnv("KAFKA_TOPIC_PRICES", "mtg.prices"),
+		Logger:      logger,
+	})
+	if err != nil {
+		r.record(job, "kafka", 0, err)
+		return
+	}
+	defer producer.Close()
+
+	mtgFetcher := fetcher.NewMTGFetcher(logger)
+	for _, phase := range job.Phases {
+		published := 0
+		var phaseErr error
+
+		switch phase {
+		case "sets":
+			sets, err := mtgFetcher.FetchAllSets()
+			if err != nil {
+				phaseErr = err
+				break
+			}
+			for _, set := range sets {
+				if err := producer.PublishSet(set); err == nil {
+					published++
+				}
+			}
+		case "cards":
+			cards, err := mtgFetcher.FetchAtomicCards()
+			if err != nil {
+				phaseErr = err
+				break
+			}
+			for _, card := range cards {
+				if err := producer.PublishCard(card); err == nil {
+					published++
+				}
+			}
+		case "prices":
+			prices, err := mtgFetcher.FetchPrices()
+			if err != nil {
+				phaseErr = err
+				break
+			}
+			for _, price := range prices {
+				if err := producer.PublishPrice(price); err == nil {
+					published++
+				}
+			}
+		}
+
+		r.record(job, phase, published, phaseErr)
+	}
+
+	if _, err := producer.FlushWithRetry(30 * time.Second); err != nil {
+		r.record(job, "flush", 0, err)
+	}
+}
+
+// AdminAuthMiddleware requires the ADMIN_TOKEN bearer token; admin routes are
+// disabled entirely when no token is configured
+func AdminAuthMiddleware(next http.HandlerFunc) http.HandlerFunc {
+	token := os.Getenv("ADMIN_TOKEN")
+	return func(w http.ResponseWriter, r *http.Request) {
+		if token == "" {
+			http.Error(w, "Admin API disabled", http.StatusNotFound)
+			return
+		}
+		provided, ok := bearerToken(r)
+		if !ok || subtle.ConstantTimeCompare([]byte(provided), []byte(token)) != 1 {
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+		next(w, r)
+	}
+}
+
+// AdminIngestHandler starts an ingestion (POST /api/admin/ingest) or reports
+// a job's status (GET /api/admin/ingest/{id})
+func (r *ingestRunner) AdminIngestHandler(w http.ResponseWriter, req *http.Request) {
+	id := strings.Trim(strings.TrimPrefix(req.URL.Path, "/api/admin/ingest"), "/")
+
+	switch {
+	case req.Method == http.MethodPost && id == "":
+		var body struct {
+			Phases []string `json:"phases"`
+		}
+		if req.ContentLength != 0 {
+			if err := json.NewDecoder(req.Body).Decode(&body); err != nil {
+				http.Error(w, "Invalid request body", http.StatusBadRequest)
+				return
+			}
+		}
+		phases, err := validatePhases(body.Phases)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		job, err := r.Start(phases)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusConflict)
+			return
+		}
+		requestLogger(req).Infof("Started ingestion %s for %v", job.ID, job.Phases)
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusAccepted)
+		json.NewEncoder(w).Encode(job)
+
+	case req.Method == http.MethodGet && id != "":
+		job, ok := r.Get(id)
+		if !ok {
+			http.Error(w, "Job not found", http.StatusNotFound)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(job)
+
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// validatePhases defaults to all phases and rejects unknown ones
+func validatePhases(phases []string) ([]string, error) {
+	if len(phases) == 0 {
+		return ingestPhases, nil
+	}
+	for _, phase := range phases {
+		valid := false
+		for _, known := range ingestPhases {
+			if phase == known {
+				valid = true
+				break
+			}
+		}
+		if !valid {
+			return nil, fmt.Errorf("unknown phase: %q", phase)
+		}
+	}
+	return phases, nil
+}
@@ -0,0 +1,98 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestAdminAuthMiddleware(t *testing.T) {
+	tests := []struct {
+		name   string
+		token  string
+		header string
+		want   int
+	}{
+		{name: "disabled without a token", token: "", header: "Bearer secret", want: http.StatusNotFound},
+		{name: "missing credentials", token: "secret", header: "", want: http.StatusUnauthorized},
+		{name: "wrong token", token: "secret", header: "Bearer wrong", want: http.StatusUnauthorized},
+		{name: "token without the Bearer scheme", token: "secret", header: "secret", want: http.StatusUnauthorized},
+		{name: "valid token", token: "secret", header: "Bearer secret", want: http.StatusNoContent},
+	}
+
+	for _, tt := range tests {
+		t.Setenv("ADMIN_TOKEN", tt.token)
+		handler := AdminAuthMiddleware(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusNoContent)
+		})
+
+		req := httptest.NewRequest(http.MethodPost, "/api/admin/ingest", nil)
+		if tt.header != "" {
+			req.Header.Set("Authorization", tt.header)
+		}
+		rec := httptest.NewRecorder()
+		handler(rec, req)
+		if rec.Code != tt.want {
+			t.Errorf("%s: status = %d, want %d", tt.name, rec.Code, tt.want)
+		}
+	}
+}
+
+func TestAdminIngestHandlerRejectsRequests(t *testing.T) {
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		body   string
+		active string
+		want   int
+	}{
+		{name: "unknown phase", method: http.MethodPost, path: "/api/admin/ingest", body: `{"phases": ["decks"]}`, want: http.StatusBadRequest},
+		{name: "malformed body", method: http.MethodPost, path: "/api/admin/ingest", body: `{"phases":`, want: http.StatusBadRequest},
+		{name: "already running", method: http.MethodPost, path: "/api/admin/ingest", body: `{"phases": ["sets"]}`, active: "job-1", want: http.StatusConflict},
+		{name: "unknown job", method: http.MethodGet, path: "/api/admin/ingest/missing", want: http.StatusNotFound},
+		{name: "listing jobs", method: http.MethodGet, path: "/api/admin/ingest", want: http.StatusMethodNotAllowed},
+		{name: "deleting a job", method: http.MethodDelete, path: "/api/admin/ingest/job-1", want: http.StatusMethodNotAllowed},
+	}
+
+	for _, tt := range tests {
+		runner := newIngestRunner()
+		runner.active = tt.active
+
+		req := httptest.NewRequest(tt.method, tt.path, strings.NewReader(tt.body))
+		rec := httptest.NewRecorder()
+		runner.AdminIngestHandler(rec, req)
+		if rec.Code != tt.want {
+			t.Errorf("%s: status = %d, want %d", tt.name, rec.Code, tt.want)
+		}
+	}
+}
+
+func TestAdminIngestHandlerReportsJob(t *testing.T) {
+	runner := newIngestRunner()
+	runner.jobs["job-1"] = &ingestJob{ID: "job-1", Status: "completed", Phases: []string{"sets"}, Published: map[string]int{"sets": 3}}
+
+	req := httptest.NewRequest(http.MethodGet, "/api/admin/ingest/job-1", nil)
+	rec := httptest.NewRecorder()
+	runner.AdminIngestHandler(rec, req)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d", rec.Code, http.StatusOK)
+	}
+	if body := rec.Body.String(); !strings.Contains(body, `"status":"completed"`) || !strings.Contains(body, `"sets":3`) {
+		t.Errorf("body = %s", body)
+	}
+}
+
+func TestValidatePhases(t *testing.T) {
+	if phases, err := validatePhases(nil); err != nil || len(phases) != len(ingestPhases) {
+		t.Errorf("validatePhases(nil) = %v, %v, want every phase", phases, err)
+	}
+	if phases, err := validatePhases([]string{"prices"}); err != nil || len(phases) != 1 || phases[0] != "prices" {
+		t.Errorf("validatePhases(prices) = %v, %v", phases, err)
+	}
+	if _, err := validatePhases([]string{"sets", "decks"}); err == nil {
+		t.Error("validatePhases accepted an unknown phase")
+	}
+}
@@ -0,0 +1,111 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/confluentinc/confluent-kafka-go/v2/kafka"
+)
+
+// healthTimeout bounds each dependency probe so /healthz can't hang
+var healthTimeout = 2 * time.Second
+
+// loadHealthConfig resolves the probe timeout from the environment
+func loadHealthConfig() error {
+	timeout, err := time.ParseDuration(getEnv("HEALTH_TIMEOUT", "2s"))
+	if err != nil {
+		return fmt.Errorf("invalid HEALTH_TIMEOUT: %w", err)
+	}
+	healthTimeout = timeout
+	return nil
+}
+
+// dependencyStatus is the result of probing a single dependency
+type dependencyStatus struct {
+	Status    string  `json:"status"`
+	LatencyMs float64 `json:"latency_ms"`
+	Error     string  `json:"error,omitempty"`
+}
+
+func probe(check func() error) dependencyStatus {
+	start := time.Now()
+	err := check()
+	status := dependencyStatus{
+		Status:    "online",
+		LatencyMs: float64(time.Since(start).Microseconds()) / 1000,
+	}
+	if err != nil {
+		status.Status = "offline"
+		status.Error = err.Error()
+	}
+	return status
+}
+
+// checkKafka requests cluster metadata from the brokers
+func checkKafka() error {
+	admin, err := kafka.NewAdminClient(&kafka.ConfigMap{
+		"bootstrap.servers": getEnv("KAFKA_BROKERS", "kafka:29092"),
+	})
+	if err != nil {
+		return err
+	}
+	defer admin.Close()
+
+	_, err = admin.GetMetadata(nil, false, int(healthTimeout.Milliseconds()))
+	return err
+}
+
+// checkKSQL fetches the KSQL server's /info endpoint
+func checkKSQL() error {
+	ctx, cancel := context.WithTimeout(context.Background(), healthTimeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, ksqlBaseURL+"/info", nil)
+	if err != nil {
+		return err
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// HealthHandler probes Kafka and KSQL, returning 200 only when both respond
+func HealthHandler(w http.ResponseWriter, r *http.Request) {
+	var kafkaStatus, ksqlStatus dependencyStatus
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		kafkaStatus = probe(checkKafka)
+	}()
+	go func() {
+		defer wg.Done()
+		ksqlStatus = probe(checkKSQL)
+	}()
+	wg.Wait()
+
+	status := "ok"
+	code := http.StatusOK
+	if kafkaStatus.Status != "online" || ksqlStatus.Status != "online" {
+		status = "unavailable"
+		code = http.StatusServiceUnavailable
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"status": status,
+		"kafka":  kafkaStatus,
+		"ksql":   ksqlStatus,
+	})
+}
@@ -0,0 +1,182 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+const (
+	// wsWriteWait bounds a single write to a client
+	wsWriteWait = 10 * time.Second
+	// wsPongWait is how long a client may stay silent before it is dropped
+	wsPongWait = 60 * time.Second
+	// wsPingPeriod must be shorter than wsPongWait
+	wsPingPeriod = wsPongWait * 9 / 10
+)
+
+var wsUpgrader = websocket.Upgrader{
+	// The dashboard is served with permissive CORS, so accept any origin here too
+	CheckOrigin: func(r *http.Request) bool { return true },
+}
+
+// statsHub polls the stats cache once per interval and pushes changes to
+// every connected WebSocket client
+type statsHub struct {
+	mu       sync.Mutex
+	clients  map[*statsClient]struct{}
+	interval time.Duration
+	last     []byte
+}
+
+// statsClient is one connected WebSocket; send holds at most the latest update
+type statsClient struct {
+	conn *websocket.Conn
+	send chan []byte
+}
+
+// liveStats is the shared hub behind /ws/stats
+var liveStats *statsHub
+
+func newStatsHub(interval time.Duration) *statsHub {
+	return &statsHub{clients: map[*statsClient]struct{}{}, interval: interval}
+}
+
+// Run polls the stats backend, broadcasting only when counts change
+func (h *statsHub) Run() {
+	ticker := time.NewTicker(h.interval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		h.mu.Lock()
+		idle := len(h.clients) == 0
+		h.mu.Unlock()
+		if idle {
+			continue
+		}
+
+		payload, err := statsPayload()
+		if err != nil {
+			logger.Errorf("Error polling stats for WebSocket clients: %v", err)
+			continue
+		}
+		h.broadcast(payload)
+	}
+}
+
+// broadcast sends payload to every client if it differs from the last update
+func (h *statsHub) broadcast(payload map[string]interface{}) {
+	// fetched_at changes on every refresh, so compare the counts only
+	counts := make(map[string]interface{}, len(payload))
+	for k, v := range payload {
+		if k != "fetched_at" {
+			counts[k] = v
+		}
+	}
+	key, _ := json.Marshal(counts)
+	msg, err := json.Marshal(payload)
+	if err != nil {
+		return
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if string(key) == string(h.last) {
+		return
+	}
+	h.last = key
+	for c := range h.clients {
+		c.push(msg)
+	}
+}
+
+// push replaces any unsent update with msg so slow clients only get the latest counts
+func (c *statsClient) push(msg []byte) {
+	select {
+	case <-c.send:
+	default:
+	}
+	select {
+	case c.send <- msg:
+	default:
+	}
+}
+
+func (h *statsHub) add(c *statsClient) {
+	h.mu.Lock()
+	h.clients[c] = struct{}{}
+	h.mu.Unlock()
+}
+
+func (h *statsHub) remove(c *statsClient) {
+	h.mu.Lock()
+	delete(h.clients, c)
+	h.mu.Unlock()
+}
+
+// ServeHTTP upgrades the request and streams stats until the client disconnects
+func (h *statsHub) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	conn, err := wsUpgrader.Upgrade(w, r, nil)
+	if err != nil {
+		// Upgrade has already written an HTTP error
+		return
+	}
+
+	client := &statsClient{conn: conn, send: make(chan []byte, 1)}
+	h.add(client)
+
+	// New clients get the current counts straight away instead of waiting for a change
+	if payload, err := statsPayload(); err == nil {
+		if msg, err := json.Marshal(payload); err == nil {
+			client.push(msg)
+		}
+	}
+
+	done := make(chan struct{})
+	go client.readLoop(done)
+	client.writeLoop(done)
+
+	h.remove(client)
+	conn.Close()
+}
+
+// readLoop consumes client frames so pongs and close messages are processed
+func (c *statsClient) readLoop(done chan<- struct{}) {
+	defer close(done)
+	c.conn.SetReadLimit(512)
+	c.conn.SetReadDeadline(time.Now().Add(wsPongWait))
+	c.conn.SetPongHandler(func(string) error {
+		return c.conn.SetReadDeadline(time.Now().Add(wsPongWait))
+	})
+	for {
+		if _, _, err := c.conn.ReadMessage(); err != nil {
+			return
+		}
+	}
+}
+
+// writeLoop sends updates and keepalive pings until the read side closes or a write fails
+func (c *statsClient) writeLoop(done <-chan struct{}) {
+	ticker := time.NewTicker(wsPingPeriod)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case msg := <-c.send:
+			c.conn.SetWriteDeadline(time.Now().Add(wsWriteWait))
+			if err := c.conn.WriteMessage(websocket.TextMessage, msg); err != nil {
+				return
+			}
+		case <-ticker.C:
+			c.conn.SetWriteDeadline(time.Now().Add(wsWriteWait))
+			if err := c.conn.WriteMessage(websocket.PingMessage, nil); err != nil {
+				return
+			}
+		case <-done:
+			return
+		}
+	}
+}
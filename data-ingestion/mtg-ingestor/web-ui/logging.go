@@ -0,0 +1,96 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+)
+
+// requestIDHeader carries the request ID to clients and to the KSQL server
+const requestIDHeader = "X-Request-ID"
+
+type requestIDKey struct{}
+
+// logger is the server's structured logger, matching the ingestors' JSON output
+var logger = newLogger()
+
+func newLogger() *logrus.Logger {
+	l := logrus.New()
+	l.SetFormatter(&logrus.JSONFormatter{})
+	level, err := logrus.ParseLevel(getEnv("LOG_LEVEL", "info"))
+	if err != nil {
+		level = logrus.InfoLevel
+	}
+	l.SetLevel(level)
+	return l
+}
+
+// requestID returns the ID assigned to the request by RequestIDMiddleware
+func requestID(r *http.Request) string {
+	id, _ := r.Context().Value(requestIDKey{}).(string)
+	return id
+}
+
+// requestLogger returns a logger entry tagged with the request's ID
+func requestLogger(r *http.Request) *logrus.Entry {
+	return logger.WithField("request_id", requestID(r))
+}
+
+// RequestIDMiddleware assigns each request an ID (reusing a valid incoming
+// X-Request-ID) and logs method, path, status and duration when it completes
+func RequestIDMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := r.Header.Get(requestIDHeader)
+		if _, err := uuid.Parse(id); err != nil {
+			id = uuid.New().String()
+		}
+		w.Header().Set(requestIDHeader, id)
+		r = r.WithContext(context.WithValue(r.Context(), requestIDKey{}, id))
+
+		start := time.Now()
+		rec := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+		next.ServeHTTP(rec, r)
+
+		logger.WithFields(logrus.Fields{
+			"request_id":  id,
+			"method":      r.Method,
+			"path":        r.URL.Path,
+			"status":      rec.status,
+			"duration_ms": time.Since(start).Milliseconds(),
+		}).Info("request completed")
+	})
+}
+
+// statusRecorder captures the response status for request logging
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (r *statusRecorder) WriteHeader(status int) {
+	r.status = status
+	r.ResponseWriter.WriteHeader(status)
+}
+
+// Flush lets streaming responses through the recorder
+func (r *statusRecorder) Flush() {
+	if flusher, ok := r.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+// Hijack lets WebSocket upgrades through the recorder
+func (r *statusRecorder) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	hijacker, ok := r.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, errors.New("response writer does not support hijacking")
+	}
+	r.status = http.StatusSwitchingProtocols
+	return hijacker.Hijack()
+}
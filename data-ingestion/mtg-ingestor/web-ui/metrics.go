@@ -0,0 +1,43 @@
+package main
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+var (
+	httpRequestsTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "mtg_dashboard_http_requests_total",
+		Help: "Total number of API requests by handler",
+	}, []string{"handler"})
+
+	httpRequestDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "mtg_dashboard_http_request_duration_seconds",
+		Help:    "API request duration by handler",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"handler"})
+
+	ksqlProxyDuration = promauto.NewHistogram(prometheus.HistogramOpts{
+		Name:    "mtg_dashboard_ksql_proxy_duration_seconds",
+		Help:    "Latency of requests forwarded to KSQL",
+		Buckets: prometheus.DefBuckets,
+	})
+
+	ksqlProxyErrorsTotal = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "mtg_dashboard_ksql_proxy_errors_total",
+		Help: "Total number of failed requests forwarded to KSQL",
+	})
+)
+
+// instrument counts requests and observes durations for the named handler
+func instrument(name string, next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		httpRequestsTotal.WithLabelValues(name).Inc()
+		next(w, r)
+		httpRequestDuration.WithLabelValues(name).Observe(time.Since(start).Seconds())
+	}
+}
@@ -0,0 +1,150 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var (
+	// maxQueryLimit caps the rows returned per page (KSQL_MAX_LIMIT)
+	maxQueryLimit = 1000
+	// maxQueryOffset is the largest offset a client may request (KSQL_MAX_OFFSET)
+	maxQueryOffset = 100000
+
+	limitClauseRegex = regexp.MustCompile(`(?i)\s+LIMIT\s+(\d+)\s*$`)
+)
+
+// pageRequest is the validated limit/offset for a proxied query
+type pageRequest struct {
+	Limit  int
+	Offset int
+}
+
+// loadPaginationConfig reads the pagination caps from the environment
+func loadPaginationConfig() error {
+	var err error
+	if maxQueryLimit, err = strconv.Atoi(getEnv("KSQL_MAX_LIMIT", strconv.Itoa(maxQueryLimit))); err != nil || maxQueryLimit <= 0 {
+		return fmt.Errorf("invalid KSQL_MAX_LIMIT")
+	}
+	if maxQueryOffset, err = strconv.Atoi(getEnv("KSQL_MAX_OFFSET", strconv.Itoa(maxQueryOffset))); err != nil || maxQueryOffset < 0 {
+		return fmt.Errorf("invalid KSQL_MAX_OFFSET")
+	}
+	return nil
+}
+
+// parsePageRequest reads limit and offset from the query string, clamping limit to the server maximum
+func parsePageRequest(query url.Values) (pageRequest, error) {
+	page := pageRequest{Limit: maxQueryLimit}
+
+	if v := query.Get("limit"); v != "" {
+		limit, err := strconv.Atoi(v)
+		if err != nil || limit <= 0 {
+			return page, fmt.Errorf("invalid limit: %q", v)
+		}
+		if limit < maxQueryLimit {
+			page.Limit = limit
+		}
+	}
+
+	if v := query.Get("offset"); v != "" {
+		offset, err := strconv.Atoi(v)
+		if err != nil || offset < 0 {
+			return page, fmt.Errorf("invalid offset: %q", v)
+		}
+		if offset > maxQueryOffset {
+			return page, fmt.Errorf("offset %d exceeds maximum of %d", offset, maxQueryOffset)
+		}
+		page.Offset = offset
+	}
+
+	return page, nil
+}
+
+// applyLimit rewrites a SELECT statement so KSQL returns at most enough rows for the
+// requested page plus one extra row used to detect truncation
+func applyLimit(statement string, page pageRequest) string {
+	stmt := strings.TrimSpace(statement)
+	if !strings.HasPrefix(strings.ToUpper(stmt), "SELECT") {
+		return statement
+	}
+
+	stmt = strings.TrimSpace(strings.TrimSuffix(stmt, ";"))
+	fetch := page.Offset + page.Limit + 1
+	if m := limitClauseRegex.FindStringSubmatch(stmt); m != nil {
+		if existing, err := strconv.Atoi(m[1]); err == nil && existing < fetch {
+			fetch = existing
+		}
+		stmt = limitClauseRegex.ReplaceAllString(stmt, "")
+	}
+
+	return fmt.Sprintf("%s LIMIT %d;", stmt, fetch)
+}
+
+// paginateResponse converts a KSQL /query response array into a single page of
+// rows and columns. ok is false when the payload isn't a query result.
+func paginateResponse(ksqlResponse []byte, page pageRequest) (map[string]interface{}, bool) {
+	var messages []map[string]json.RawMessage
+	if err := json.Unmarshal(ksqlResponse, &messages); err != nil {
+		return nil, false
+	}
+
+	var columns []string
+	var rows []json.RawMessage
+	for _, msg := range messages {
+		if raw, ok := msg["header"]; ok {
+			var header struct {
+				Schema string `json:"schema"`
+			}
+			if err := json.Unmarshal(raw, &header); err == nil {
+				columns = parseSchemaColumns(header.Schema)
+			}
+		}
+		if raw, ok := msg["row"]; ok {
+			var row struct {
+				Columns json.RawMessage `json:"columns"`
+			}
+			if err := json.Unmarshal(raw, &row); err == nil {
+				rows = append(rows, row.Columns)
+			}
+		}
+	}
+
+	response := map[string]interface{}{
+		"columns":   columns,
+		"truncated": false,
+	}
+
+	if page.Offset >= len(rows) {
+		rows = nil
+	} else {
+		rows = rows[page.Offset:]
+	}
+	if len(rows) > page.Limit {
+		rows = rows[:page.Limit]
+		response["truncated"] = true
+		response["next_offset"] = page.Offset + page.Limit
+	}
+	if rows == nil {
+		rows = []json.RawMessage{}
+	}
+	response["rows"] = rows
+
+	return response, true
+}
+
+// parseSchemaColumns extracts column names from a KSQL schema string such as "`NAME` STRING, `CMC` DOUBLE"
+func parseSchemaColumns(schema string) []string {
+	var columns []string
+	for _, field := range strings.Split(schema, ", ") {
+		name := strings.Fields(field)
+		if len(name) == 0 {
+			continue
+		}
+		columns = append(columns, strings.Trim(name[0], "`"))
+	}
+	return columns
+}
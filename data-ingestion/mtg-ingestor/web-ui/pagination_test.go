@@ -0,0 +1,73 @@
+package main
+
+import (
+	"net/url"
+	"testing"
+)
+
+func TestParsePageRequestClampsLimit(t *testing.T) {
+	tests := []struct {
+		query   string
+		want    pageRequest
+		wantErr bool
+	}{
+		{query: "", want: pageRequest{Limit: maxQueryLimit}},
+		{query: "limit=10&offset=20", want: pageRequest{Limit: 10, Offset: 20}},
+		{query: "limit=999999", want: pageRequest{Limit: maxQueryLimit}},
+		{query: "limit=0", wantErr: true},
+		{query: "limit=abc", wantErr: true},
+		{query: "offset=-1", wantErr: true},
+		{query: "offset=100001", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		values, _ := url.ParseQuery(tt.query)
+		got, err := parsePageRequest(values)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("parsePageRequest(%q) error = %v, wantErr %v", tt.query, err, tt.wantErr)
+			continue
+		}
+		if !tt.wantErr && got != tt.want {
+			t.Errorf("parsePageRequest(%q) = %+v, want %+v", tt.query, got, tt.want)
+		}
+	}
+}
+
+func TestApplyLimit(t *testing.T) {
+	page := pageRequest{Limit: 10, Offset: 5}
+	tests := []struct {
+		name string
+		stmt string
+		want string
+	}{
+		{"bare select", "SELECT * FROM cards;", "SELECT * FROM cards LIMIT 16;"},
+		{"smaller existing limit kept", "SELECT * FROM cards LIMIT 3", "SELECT * FROM cards LIMIT 3;"},
+		{"larger existing limit clamped", "select * from cards limit 5000;", "select * from cards LIMIT 16;"},
+		{"not a select", "SHOW TABLES;", "SHOW TABLES;"},
+	}
+
+	for _, tt := range tests {
+		if got := applyLimit(tt.stmt, page); got != tt.want {
+			t.Errorf("%s: applyLimit(%q) = %q, want %q", tt.name, tt.stmt, got, tt.want)
+		}
+	}
+}
+
+func TestApplyLimitAfterStrippingComments(t *testing.T) {
+	page := pageRequest{Limit: 1000}
+	tests := []struct {
+		stmt string
+		want string
+	}{
+		{"SELECT * FROM cards -- x", "SELECT * FROM cards LIMIT 1001;"},
+		{"SELECT * FROM cards LIMIT 5000 /* all */", "SELECT * FROM cards LIMIT 1001;"},
+		{"/* lead */ SELECT * FROM cards", "SELECT * FROM cards LIMIT 1001;"},
+		{"SELECT * FROM cards WHERE name = '--x'", "SELECT * FROM cards WHERE name = '--x' LIMIT 1001;"},
+	}
+
+	for _, tt := range tests {
+		if got := applyLimit(stripSQLComments(tt.stmt), page); got != tt.want {
+			t.Errorf("applyLimit(stripSQLComments(%q)) = %q, want %q", tt.stmt, got, tt.want)
+		}
+	}
+}
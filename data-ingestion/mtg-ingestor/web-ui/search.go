@@ -0,0 +1,245 @@
+package main
+
+import (
+	"database/sql"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// searchResult is a card returned by SearchHandler
+type searchResult struct {
+	Name     string   `json:"name"`
+	Type     string   `json:"type"`
+	Rarity   string   `json:"rarity"`
+	Set      string   `json:"set"`
+	Keywords []string `json:"keywords,omitempty"`
+	Score    float64  `json:"score"`
+}
+
+// indexedCard is a card name with its precomputed trigrams and lowercased keywords
+type indexedCard struct {
+	searchResult
+	lower    string
+	trigrams map[string]struct{}
+	keywords map[string]bool
+}
+
+// hasKeywords reports whether the card has every keyword in want, which must be lowercase
+func (c indexedCard) hasKeywords(want []string) bool {
+	for _, keyword := range want {
+		if !c.keywords[keyword] {
+			return false
+		}
+	}
+	return true
+}
+
+// cardSearchIndex holds card names in memory for prefix and fuzzy search,
+// reloading them from Postgres once the TTL expires
+type cardSearchIndex struct {
+	mu       sync.Mutex
+	db       *sql.DB
+	ttl      time.Duration
+	cards    []indexedCard
+	loadedAt time.Time
+}
+
+var (
+	// search is the shared card index used by SearchHandler
+	search *cardSearchIndex
+	// searchDefaultLimit and searchMaxLimit bound the number of search results
+	searchDefaultLimit = 20
+	searchMaxLimit     = 50
+	// searchMinSimilarity is the trigram similarity below which fuzzy matches are dropped
+	searchMinSimilarity = 0.3
+)
+
+// newCardSearchIndex creates a search index over the cards table
+func newCardSearchIndex(db *sql.DB) (*cardSearchIndex, error) {
+	ttl, err := time.ParseDuration(getEnv("SEARCH_INDEX_TTL", "10m"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid SEARCH_INDEX_TTL: %w", err)
+	}
+	if v := getEnv("SEARCH_MAX_RESULTS", ""); v != "" {
+		if searchMaxLimit, err = strconv.Atoi(v); err != nil || searchMaxLimit <= 0 {
+			return nil, fmt.Errorf("invalid SEARCH_MAX_RESULTS: %q", v)
+		}
+		searchDefaultLimit = min(searchDefaultLimit, searchMaxLimit)
+	}
+	return &cardSearchIndex{db: db, ttl: ttl}, nil
+}
+
+// searchLimit reads the limit query parameter, clamped to searchMaxLimit;
+// ok is false when it isn't a positive number
+func searchLimit(r *http.Request) (limit int, ok bool) {
+	v := r.URL.Query().Get("limit")
+	if v == "" {
+		return searchDefaultLimit, true
+	}
+	n, err := strconv.Atoi(v)
+	if err != nil || n <= 0 {
+		return 0, false
+	}
+	return min(n, searchMaxLimit), true
+}
+
+// snapshot returns the indexed cards, reloading them when stale. A failed
+// reload keeps serving the previous cards if there are any.
+func (idx *cardSearchIndex) snapshot() ([]indexedCard, error) {
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+
+	if idx.cards != nil && time.Since(idx.loadedAt) < idx.ttl {
+		return idx.cards, nil
+	}
+
+	cards, err := idx.load()
+	if err != nil {
+		logger.Errorf("Error loading search index: %v", err)
+		if idx.cards == nil {
+			return nil, err
+		}
+		return idx.cards, nil
+	}
+
+	idx.cards = cards
+	idx.loadedAt = time.Now()
+	return idx.cards, nil
+}
+
+func (idx *cardSearchIndex) load() ([]indexedCard, error) {
+	rows, err := idx.db.Query(`SELECT DISTINCT ON (name) name, COALESCE(type, ''), COALESCE(rarity, ''), COALESCE(set_code, '')
+		FROM cards ORDER BY name, processed_at DESC`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query cards: %w", err)
+	}
+	defer rows.Close()
+
+	keywords, err := idx.loadKeywords()
+	if err != nil {
+		return nil, err
+	}
+
+	var cards []indexedCard
+	for rows.Next() {
+		var c indexedCard
+		if err := rows.Scan(&c.Name, &c.Type, &c.Rarity, &c.Set); err != nil {
+			return nil, fmt.Errorf("failed to scan card: %w", err)
+		}
+		c.lower = strings.ToLower(c.Name)
+		c.trigrams = trigrams(c.lower)
+		c.Keywords = keywords[c.Name]
+		c.keywords = make(map[string]bool, len(c.Keywords))
+		for _, keyword := range c.Keywords {
+			c.keywords[strings.ToLower(keyword)] = true
+		}
+		cards = append(cards, c)
+	}
+	return cards, rows.Err()
+}
+
+// loadKeywords returns each card name's keyword abilities
+func (idx *cardSearchIndex) loadKeywords() (map[string][]string, error) {
+	rows, err := idx.db.Query(`SELECT DISTINCT c.name, k.keyword
+		FROM card_keywords k JOIN cards c ON c.uuid = k.card_uuid ORDER BY c.name, k.keyword`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query card keywords: %w", err)
+	}
+	defer rows.Close()
+
+	keywords := map[string][]string{}
+	for rows.Next() {
+		var name, keyword string
+		if err := rows.Scan(&name, &keyword); err != nil {
+			return nil, fmt.Errorf("failed to scan card keyword: %w", err)
+		}
+		keywords[name] = append(keywords[name], keyword)
+	}
+	return keywords, rows.Err()
+}
+
+// Search ranks cards against query: exact names first, then prefix matches,
+// then substring matches, then fuzzy matches by trigram similarity. Only
+// cards with every one of keywords are returned; an empty query returns all
+// such cards by name.
+func (idx *cardSearchIndex) Search(query string, keywords []string, limit int) ([]searchResult, error) {
+	cards, err := idx.snapshot()
+	if err != nil {
+		return nil, err
+	}
+
+	q := strings.ToLower(strings.TrimSpace(query))
+	qTrigrams := trigrams(q)
+	want := make([]string, len(keywords))
+	for i, keyword := range keywords {
+		want[i] = strings.ToLower(strings.TrimSpace(keyword))
+	}
+
+	var results []searchResult
+	for _, c := range cards {
+		if !c.hasKeywords(want) {
+			continue
+		}
+		if q == "" {
+			results = append(results, c.searchResult)
+			continue
+		}
+		similarity := trigramSimilarity(qTrigrams, c.trigrams)
+		var score float64
+		switch {
+		case c.lower == q:
+			score = 3
+		case strings.HasPrefix(c.lower, q):
+			score = 2 + similarity
+		case strings.Contains(c.lower, q):
+			score = 1 + similarity
+		case similarity >= searchMinSimilarity:
+			score = similarity
+		default:
+			continue
+		}
+		r := c.searchResult
+		r.Score = score
+		results = append(results, r)
+	}
+
+	sort.Slice(results, func(i, j int) bool {
+		if results[i].Score != results[j].Score {
+			return results[i].Score > results[j].Score
+		}
+		return results[i].Name < results[j].Name
+	})
+	if len(results) > limit {
+		results = results[:limit]
+	}
+	return results, nil
+}
+
+// trigrams returns the set of 3-character substrings of s padded with spaces
+func trigrams(s string) map[string]struct{} {
+	runes := []rune("  " + s + " ")
+	set := make(map[string]struct{}, len(runes))
+	for i := 0; i+3 <= len(runes); i++ {
+		set[string(runes[i:i+3])] = struct{}{}
+	}
+	return set
+}
+
+// trigramSimilarity is the Jaccard similarity of two trigram sets
+func trigramSimilarity(a, b map[string]struct{}) float64 {
+	if len(a) == 0 || len(b) == 0 {
+		return 0
+	}
+	shared := 0
+	for t := range a {
+		if _, ok := b[t]; ok {
+			shared++
+		}
+	}
+	return float64(shared) / float64(len(a)+len(b)-shared)
+}
@@ -2,12 +2,20 @@ package main
 
 import (
 	"bytes"
+	"context"
+	"crypto/subtle"
 	"encoding/json"
 	"fmt"
 	"io"
-	"log"
 	"net/http"
 	"os"
+	"os/signal"
+	"strings"
+	"sync/atomic"
+	"syscall"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/promhttp"
 )
 
 // CORSMiddleware adds CORS headers to responses
@@ -15,7 +23,7 @@ func CORSMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		w.Header().Set("Access-Control-Allow-Origin", "*")
 		w.Header().Set("Access-Control-Allow-Methods", "GET, POST, OPTIONS")
-		w.Header().Set("Access-Control-Allow-Headers", "Content-Type")
+		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
 		
 		if r.Method == "OPTIONS" {
 			w.WriteHeader(http.StatusOK)
@@ -26,34 +34,229 @@ func CORSMiddleware(next http.Handler) http.Handler {
 	})
 }
 
+// APIAuthMiddleware requires the API_TOKEN bearer token on /api/* routes when
+// it is set; without it the API stays open for local development. Admin routes
+// are skipped here since they check ADMIN_TOKEN themselves. /api/stream also
+// accepts the token as an access_token query parameter, see apiToken.
+func APIAuthMiddleware(next http.Handler) http.Handler {
+	token := os.Getenv("API_TOKEN")
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if token == "" || !strings.HasPrefix(r.URL.Path, "/api/") || strings.HasPrefix(r.URL.Path, "/api/admin/") {
+			next.ServeHTTP(w, r)
+			return
+		}
+		provided, ok := apiToken(r)
+		if !ok || subtle.ConstantTimeCompare([]byte(provided), []byte(token)) != 1 {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="mtg-api"`)
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// bearerToken returns the token of an "Authorization: Bearer <token>" header;
+// ok is false when the header is missing or uses another scheme
+func bearerToken(r *http.Request) (string, bool) {
+	return strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+}
+
+// streamPath is the Server-Sent Events route, see StreamHandler
+const streamPath = "/api/stream"
+
+// apiToken returns the token a request authenticates with: its bearer token
+// or, on streamPath, the access_token query parameter, since the browser
+// EventSource API can't set an Authorization header
+func apiToken(r *http.Request) (string, bool) {
+	if token, ok := bearerToken(r); ok {
+		return token, true
+	}
+	if r.URL.Path == streamPath && r.URL.Query().Has("access_token") {
+		return r.URL.Query().Get("access_token"), true
+	}
+	return "", false
+}
+
+// stats is the shared counts cache used by StatsHandler
+var stats *statsCache
+
+// inFlightRequests counts requests currently being served
+var inFlightRequests atomic.Int64
+
+// InFlightMiddleware tracks the number of in-flight requests
+func InFlightMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		inFlightRequests.Add(1)
+		defer inFlightRequests.Add(-1)
+		next.ServeHTTP(w, r)
+	})
+}
+
+// statsPayload builds the stats response shared by /api/stats and /ws/stats
+func statsPayload() (map[string]interface{}, error) {
+	counts, fetchedAt, stale, err := stats.Get()
+	if err != nil {
+		return nil, err
+	}
+
+	response := map[string]interface{}{
+		"stale":      stale,
+		"fetched_at": fetchedAt,
+	}
+	for key, n := range counts {
+		response[key] = n
+	}
+	return response, nil
+}
+
 // StatsHandler returns current statistics
 func StatsHandler(w http.ResponseWriter, r *http.Request) {
-	stats := map[string]interface{}{
-		"cards_count": 32385,
-		"prices_count": 53607349,
-		"sets_count": 2,
-		"kafka_status": "online",
-		"ksql_status": "online",
+	if stats == nil {
+		http.Error(w, "Statistics backend not configured", http.StatusServiceUnavailable)
+		return
+	}
+
+	response, err := statsPayload()
+	if err != nil {
+		http.Error(w, "Statistics backend unavailable", http.StatusServiceUnavailable)
+		return
 	}
 	
 	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(stats)
+	json.NewEncoder(w).Encode(response)
 }
 
-// SearchHandler handles card searches
+// SearchHandler handles card searches by name, optionally narrowed to cards
+// with every keyword ability given in repeated keyword parameters
 func SearchHandler(w http.ResponseWriter, r *http.Request) {
-	searchQuery := r.URL.Query().Get("q")
-	
-	// Sample response - in production would query KSQL
-	results := []map[string]string{
-		{"name": "Lightning Bolt - " + searchQuery, "type": "Instant", "rarity": "common"},
-		{"name": "Counterspell", "type": "Instant", "rarity": "common"},
+	searchQuery := strings.TrimSpace(r.URL.Query().Get("q"))
+	keywords := r.URL.Query()["keyword"]
+	if searchQuery == "" && len(keywords) == 0 {
+		http.Error(w, "Missing search query", http.StatusBadRequest)
+		return
+	}
+
+	limit, ok := searchLimit(r)
+	if !ok {
+		http.Error(w, "Invalid limit", http.StatusBadRequest)
+		return
+	}
+
+	if search == nil {
+		http.Error(w, "Search backend not configured", http.StatusServiceUnavailable)
+		return
+	}
+
+	results, err := search.Search(searchQuery, keywords, limit)
+	if err != nil {
+		http.Error(w, "Search backend unavailable", http.StatusServiceUnavailable)
+		return
+	}
+	if results == nil {
+		results = []searchResult{}
 	}
 	
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(results)
 }
 
+var (
+	// ksqlBaseURL is the KSQL server address, resolved once at startup
+	ksqlBaseURL = "http://ksqldb-server:8088"
+	// ksqlFallbackSample returns canned rows when KSQL is unreachable
+	ksqlFallbackSample = false
+)
+
+// loadKSQLConfig resolves the KSQL settings from the environment
+func loadKSQLConfig() {
+	if v := os.Getenv("KSQL_URL"); v != "" {
+		ksqlBaseURL = strings.TrimSuffix(v, "/")
+	} else if host := os.Getenv("KSQL_HOST"); host != "" {
+		ksqlBaseURL = fmt.Sprintf("http://%s:8088", host)
+	}
+	ksqlFallbackSample = os.Getenv("KSQL_FALLBACK_SAMPLE") == "true"
+}
+
+// readOnlyPrefixes are the statement keywords the KSQL proxy forwards
+var readOnlyPrefixes = []string{"SELECT", "DESCRIBE", "SHOW", "LIST"}
+
+// isReadOnlyStatement reports whether stmt is a single statement starting with
+// an allowed keyword, so DROP, TERMINATE and the like never reach KSQL
+func isReadOnlyStatement(stmt string) bool {
+	stmt = strings.TrimSpace(stripSQLComments(stmt))
+	stmt = strings.TrimSpace(strings.TrimSuffix(stmt, ";"))
+	if stmt == "" {
+		return false
+	}
+	inQuote := false
+	for _, c := range stmt {
+		if c == '\'' {
+			inQuote = !inQuote
+		} else if c == ';' && !inQuote {
+			return false
+		}
+	}
+	if inQuote {
+		// An unterminated string could hide where the statement really ends
+		return false
+	}
+
+	keyword := statementKeyword(stmt)
+	for _, prefix := range readOnlyPrefixes {
+		if keyword == prefix {
+			return true
+		}
+	}
+	return false
+}
+
+// statementKeyword returns the upper-cased first word of a comment-free statement
+func statementKeyword(stmt string) string {
+	fields := strings.Fields(stmt)
+	if len(fields) == 0 {
+		return ""
+	}
+	return strings.ToUpper(fields[0])
+}
+
+// ksqlEndpoint returns the KSQL REST path for an allowed statement: queries go
+// to /query, while DESCRIBE, SHOW and LIST are only accepted by /ksql
+func ksqlEndpoint(stmt string) string {
+	if statementKeyword(stripSQLComments(stmt)) == "SELECT" {
+		return "/query"
+	}
+	return "/ksql"
+}
+
+// stripSQLComments removes "--" line comments and "/* */" block comments
+func stripSQLComments(stmt string) string {
+	var b strings.Builder
+	inQuote := false
+	for i := 0; i < len(stmt); i++ {
+		c := stmt[i]
+		switch {
+		case c == '\'':
+			inQuote = !inQuote
+			b.WriteByte(c)
+		case !inQuote && strings.HasPrefix(stmt[i:], "--"):
+			for i < len(stmt) && stmt[i] != '\n' {
+				i++
+			}
+			b.WriteByte(' ')
+		case !inQuote && strings.HasPrefix(stmt[i:], "/*"):
+			end := strings.Index(stmt[i+2:], "*/")
+			if end < 0 {
+				return b.String()
+			}
+			i += end + 3
+			b.WriteByte(' ')
+		default:
+			b.WriteByte(c)
+		}
+	}
+	return b.String()
+}
+
 // QueryHandler proxies KSQL queries
 func QueryHandler(w http.ResponseWriter, r *http.Request) {
 	if r.Method != "POST" {
@@ -68,25 +271,66 @@ func QueryHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 	defer r.Body.Close()
+
+	page, err := parsePageRequest(r.URL.Query())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	// Inject or clamp a LIMIT so a bare SELECT can't dump the whole table
+	var request map[string]interface{}
+	if err := json.Unmarshal(body, &request); err != nil {
+		http.Error(w, "Invalid query request", http.StatusBadRequest)
+		return
+	}
+	statement, ok := request["ksql"].(string)
+	if !ok || strings.TrimSpace(statement) == "" {
+		http.Error(w, "Missing ksql statement", http.StatusBadRequest)
+		return
+	}
+	if !isReadOnlyStatement(statement) {
+		http.Error(w, "Only single SELECT, DESCRIBE, SHOW and LIST statements are allowed", http.StatusForbidden)
+		return
+	}
+	// Comments are dropped first so a trailing "--" can't swallow the appended LIMIT
+	request["ksql"] = applyLimit(strings.TrimSpace(stripSQLComments(statement)), page)
+	body, err = json.Marshal(request)
+	if err != nil {
+		http.Error(w, "Failed to encode query request", http.StatusInternalServerError)
+		return
+	}
 	
 	// Forward to KSQL server
-	// Use container name when running in Docker, localhost for local dev
-	ksqlHost := os.Getenv("KSQL_HOST")
-	if ksqlHost == "" {
-		ksqlHost = "ksqldb-server"
+	endpoint := ksqlEndpoint(statement)
+	ksqlURL := ksqlBaseURL + endpoint
+	ksqlStart := time.Now()
+	ksqlReq, err := http.NewRequestWithContext(r.Context(), http.MethodPost, ksqlURL, bytes.NewBuffer(body))
+	if err != nil {
+		http.Error(w, "Failed to build KSQL request", http.StatusInternalServerError)
+		return
 	}
-	ksqlURL := fmt.Sprintf("http://%s:8088/query", ksqlHost)
-	resp, err := http.Post(ksqlURL, "application/vnd.ksql.v1+json", bytes.NewBuffer(body))
+	ksqlReq.Header.Set("Content-Type", "application/vnd.ksql.v1+json")
+	ksqlReq.Header.Set(requestIDHeader, requestID(r))
+	resp, err := http.DefaultClient.Do(ksqlReq)
+	ksqlProxyDuration.Observe(time.Since(ksqlStart).Seconds())
 	if err != nil {
-		log.Printf("Error forwarding to KSQL: %v", err)
-		// Return sample data on error
+		ksqlProxyErrorsTotal.Inc()
+		requestLogger(r).Errorf("Error forwarding to KSQL: %v", err)
+		if !ksqlFallbackSample {
+			http.Error(w, "KSQL server unavailable", http.StatusBadGateway)
+			return
+		}
+		// Return sample data on error (opt-in via KSQL_FALLBACK_SAMPLE)
 		response := map[string]interface{}{
 			"rows": [][]interface{}{
 				{"Lightning Bolt", "Instant", "common", "LEA"},
 				{"Black Lotus", "Artifact", "mythic", "LEA"},
 			},
 			"columns": []string{"name", "type", "rarity", "set"},
+			"sample":  true,
 		}
+		setNames.enrich(response)
 		w.Header().Set("Content-Type", "application/json")
 		json.NewEncoder(w).Encode(response)
 		return
@@ -96,32 +340,129 @@ func QueryHandler(w http.ResponseWriter, r *http.Request) {
 	// Read KSQL response
 	ksqlResponse, err := io.ReadAll(resp.Body)
 	if err != nil {
+		ksqlProxyErrorsTotal.Inc()
 		http.Error(w, "Failed to read KSQL response", http.StatusInternalServerError)
 		return
 	}
 	
-	// Forward the response
+	// Return a single page of rows when this is a query result, otherwise forward as-is
 	w.Header().Set("Content-Type", "application/json")
+	if resp.StatusCode == http.StatusOK && endpoint == "/query" {
+		if paged, ok := paginateResponse(ksqlResponse, page); ok {
+			setNames.enrich(paged)
+			json.NewEncoder(w).Encode(paged)
+			return
+		}
+	}
+	w.WriteHeader(resp.StatusCode)
 	w.Write(ksqlResponse)
 }
 
 func main() {
+	db, err := openPostgres()
+	if err != nil {
+		logger.Warnf("Stats and search disabled: %v", err)
+	} else {
+		if stats, err = newStatsCache(db); err != nil {
+			logger.Warnf("Stats disabled: %v", err)
+		} else {
+			interval, err := time.ParseDuration(getEnv("STATS_PUSH_INTERVAL", "5s"))
+			if err != nil {
+				logger.Fatalf("Invalid STATS_PUSH_INTERVAL: %v", err)
+			}
+			liveStats = newStatsHub(interval)
+			go liveStats.Run()
+		}
+		if search, err = newCardSearchIndex(db); err != nil {
+			logger.Fatal(err)
+		}
+		if setNames, err = newSetIndex(db); err != nil {
+			logger.Fatal(err)
+		}
+		go setNames.Run()
+		if textSearch, err = newTextIndex(db); err != nil {
+			logger.Fatal(err)
+		}
+		go textSearch.Run()
+	}
+	if err := loadPaginationConfig(); err != nil {
+		logger.Fatal(err)
+	}
+	loadKSQLConfig()
+	if err := loadGzipConfig(); err != nil {
+		logger.Fatal(err)
+	}
+	if err := loadHealthConfig(); err != nil {
+		logger.Fatal(err)
+	}
+
 	// Serve static files
 	fs := http.FileServer(http.Dir("."))
 	http.Handle("/", fs)
 	
 	// API endpoints
-	http.HandleFunc("/api/stats", StatsHandler)
-	http.HandleFunc("/api/search", SearchHandler)
-	http.HandleFunc("/api/query", QueryHandler)
+	http.HandleFunc("/api/stats", instrument("stats", GzipMiddleware(StatsHandler)))
+	http.HandleFunc("/api/search", instrument("search", GzipMiddleware(SearchHandler)))
+	http.HandleFunc("/api/search/text", instrument("search_text", GzipMiddleware(TextSearchHandler)))
+	http.HandleFunc("/api/query", instrument("query", GzipMiddleware(QueryHandler)))
+	http.HandleFunc("/api/sets", instrument("sets", GzipMiddleware(SetsHandler)))
+	http.HandleFunc(streamPath, instrument("stream", StreamHandler))
+	if liveStats != nil {
+		http.Handle("/ws/stats", liveStats)
+	}
+
+	// Admin endpoints
+	runner := newIngestRunner()
+	http.HandleFunc("/api/admin/ingest", AdminAuthMiddleware(runner.AdminIngestHandler))
+	http.HandleFunc("/api/admin/ingest/", AdminAuthMiddleware(runner.AdminIngestHandler))
+	
+	// Readiness probe
+	http.HandleFunc("/healthz", HealthHandler)
+	
+	// Prometheus metrics
+	http.Handle("/metrics", promhttp.Handler())
 	
 	port := os.Getenv("PORT")
 	if port == "" {
 		port = "8090"
 	}
 	
+	shutdownTimeout, err := time.ParseDuration(getEnv("SHUTDOWN_TIMEOUT", "30s"))
+	if err != nil {
+		logger.Fatalf("Invalid SHUTDOWN_TIMEOUT: %v", err)
+	}
+
+	server := &http.Server{
+		Addr:    ":" + port,
+		Handler: InFlightMiddleware(RequestIDMiddleware(CORSMiddleware(APIAuthMiddleware(http.DefaultServeMux)))),
+	}
+
 	fmt.Printf("MTG Dashboard server starting on port %s\n", port)
 	fmt.Printf("Open http://localhost:%s to view the dashboard\n", port)
-	
-	log.Fatal(http.ListenAndServe(":"+port, CORSMiddleware(http.DefaultServeMux)))
+
+	serverErr := make(chan error, 1)
+	go func() {
+		serverErr <- server.ListenAndServe()
+	}()
+
+	// Drain in-flight requests on SIGINT/SIGTERM so rolling deploys don't cut off proxied queries
+	sigChan := make(chan os.Signal, 1)
+	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
+
+	select {
+	case err := <-serverErr:
+		logger.Fatal(err)
+	case sig := <-sigChan:
+		logger.Infof("Received %v, shutting down with %d in-flight requests (timeout %v)",
+			sig, inFlightRequests.Load(), shutdownTimeout)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+	if err := server.Shutdown(ctx); err != nil {
+		logger.Infof("Shutdown did not complete cleanly, %d requests still in flight: %v",
+			inFlightRequests.Load(), err)
+		return
+	}
+	logger.Info("Server stopped")
 }
\ No newline at end of file
@@ -0,0 +1,176 @@
+package main
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestIsReadOnlyStatement(t *testing.T) {
+	tests := []struct {
+		stmt string
+		want bool
+	}{
+		{"SELECT * FROM cards;", true},
+		{"  select * from cards  ", true},
+		{"DESCRIBE cards;", true},
+		{"SHOW STREAMS;", true},
+		{"LIST TOPICS;", true},
+		{"DROP STREAM cards;", false},
+		{"TERMINATE ALL;", false},
+		{"INSERT INTO cards VALUES ('x');", false},
+		{"", false},
+		{";", false},
+
+		// Multiple statements
+		{"SELECT 1; DROP STREAM x", false},
+		{"SELECT 1;DROP STREAM x;", false},
+		{"SHOW STREAMS; TERMINATE ALL;", false},
+
+		// Semicolons inside quotes don't end the statement
+		{"SELECT * FROM cards WHERE name = 'a;b';", true},
+		{"SELECT * FROM cards WHERE name = 'it''s; fine';", true},
+		{"SELECT * FROM cards WHERE name = 'a'; DROP STREAM cards;", false},
+		{"SELECT * FROM cards WHERE name = '; DROP STREAM cards;", false},
+
+		// Comments can't smuggle in another keyword
+		{"/* x */ DROP STREAM cards;", false},
+		{"-- x\nDROP STREAM cards;", false},
+		{"/* SELECT */ DROP STREAM cards;", false},
+		{"-- SELECT\nTERMINATE ALL;", false},
+		{"SELECT 1 -- ; DROP STREAM cards", true},
+		{"SELECT 1 /* ; DROP STREAM cards; */", true},
+		{"/* x */ SELECT * FROM cards;", true},
+
+		// An unterminated block comment swallows the rest, and only the
+		// stripped statement is forwarded
+		{"/* DROP STREAM cards;", false},
+		{"SELECT 1; /* DROP STREAM cards", true},
+		{"SELECT * FROM cards /* ; DROP STREAM cards", true},
+	}
+
+	for _, tt := range tests {
+		if got := isReadOnlyStatement(tt.stmt); got != tt.want {
+			t.Errorf("isReadOnlyStatement(%q) = %v, want %v", tt.stmt, got, tt.want)
+		}
+	}
+}
+
+func TestStripSQLComments(t *testing.T) {
+	tests := []struct {
+		stmt string
+		want string
+	}{
+		{"SELECT 1 -- trailing", "SELECT 1  "},
+		{"-- lead\nSELECT 1", " SELECT 1"},
+		{"SELECT /* inline */ 1", "SELECT   1"},
+		{"SELECT '--not a comment' /* but this is */", "SELECT '--not a comment'  "},
+		{"SELECT '/* kept */'", "SELECT '/* kept */'"},
+		{"SELECT 1 /* unterminated", "SELECT 1 "},
+	}
+
+	for _, tt := range tests {
+		if got := stripSQLComments(tt.stmt); got != tt.want {
+			t.Errorf("stripSQLComments(%q) = %q, want %q", tt.stmt, got, tt.want)
+		}
+	}
+}
+
+func TestQueryHandlerRoutesStatements(t *testing.T) {
+	var gotPath, gotKSQL string
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotPath = r.URL.Path
+		var body struct {
+			KSQL string `json:"ksql"`
+		}
+		json.NewDecoder(r.Body).Decode(&body)
+		gotKSQL = body.KSQL
+		if r.URL.Path == "/ksql" {
+			io.WriteString(w, `[{"@type":"streams","streams":[]}]`)
+			return
+		}
+		io.WriteString(w, `[{"header":{"schema":"`+"`NAME`"+` STRING"}},{"row":{"columns":["Lightning Bolt"]}}]`)
+	}))
+	defer upstream.Close()
+
+	previous := ksqlBaseURL
+	ksqlBaseURL = upstream.URL
+	defer func() { ksqlBaseURL = previous }()
+
+	tests := []struct {
+		stmt     string
+		wantCode int
+		wantPath string
+		wantKSQL string
+		wantBody string
+	}{
+		{"SELECT * FROM cards;", http.StatusOK, "/query", "SELECT * FROM cards LIMIT 1001;", `"rows":[["Lightning Bolt"]]`},
+		{"/* cards */ DESCRIBE cards;", http.StatusOK, "/ksql", "DESCRIBE cards;", `"@type":"streams"`},
+		{"SHOW STREAMS;", http.StatusOK, "/ksql", "SHOW STREAMS;", `"@type":"streams"`},
+		{"LIST TOPICS;", http.StatusOK, "/ksql", "LIST TOPICS;", `"@type":"streams"`},
+		{"/* x */ DROP STREAM cards;", http.StatusForbidden, "", "", "Only single SELECT"},
+	}
+
+	for _, tt := range tests {
+		gotPath, gotKSQL = "", ""
+		body, _ := json.Marshal(map[string]string{"ksql": tt.stmt})
+		req := httptest.NewRequest(http.MethodPost, "/api/query", strings.NewReader(string(body)))
+		rec := httptest.NewRecorder()
+		QueryHandler(rec, req)
+
+		if rec.Code != tt.wantCode {
+			t.Errorf("%q: status = %d, want %d", tt.stmt, rec.Code, tt.wantCode)
+		}
+		if gotPath != tt.wantPath || gotKSQL != tt.wantKSQL {
+			t.Errorf("%q: forwarded %q to %q, want %q to %q", tt.stmt, gotKSQL, gotPath, tt.wantKSQL, tt.wantPath)
+		}
+		if !strings.Contains(rec.Body.String(), tt.wantBody) {
+			t.Errorf("%q: response = %s, want it to contain %s", tt.stmt, rec.Body.String(), tt.wantBody)
+		}
+	}
+}
+
+func TestAPIAuthMiddleware(t *testing.T) {
+	tests := []struct {
+		name   string
+		token  string
+		path   string
+		header string
+		want   int
+	}{
+		{name: "open without a token", token: "", path: "/api/stats", want: http.StatusNoContent},
+		{name: "missing header", token: "secret", path: "/api/stats", want: http.StatusUnauthorized},
+		{name: "wrong token", token: "secret", path: "/api/stats", header: "Bearer wrong", want: http.StatusUnauthorized},
+		{name: "token without the Bearer scheme", token: "secret", path: "/api/stats", header: "secret", want: http.StatusUnauthorized},
+		{name: "other scheme", token: "secret", path: "/api/stats", header: "Basic secret", want: http.StatusUnauthorized},
+		{name: "correct token", token: "secret", path: "/api/stats", header: "Bearer secret", want: http.StatusNoContent},
+		{name: "admin routes check their own token", token: "secret", path: "/api/admin/ingest", want: http.StatusNoContent},
+		{name: "non-API routes stay open", token: "secret", path: "/index.html", want: http.StatusNoContent},
+		{name: "stream token in the query", token: "secret", path: "/api/stream?access_token=secret", want: http.StatusNoContent},
+		{name: "wrong stream token in the query", token: "secret", path: "/api/stream?access_token=wrong", want: http.StatusUnauthorized},
+		{name: "query token only on the stream", token: "secret", path: "/api/stats?access_token=secret", want: http.StatusUnauthorized},
+	}
+
+	for _, tt := range tests {
+		t.Setenv("API_TOKEN", tt.token)
+		handler := APIAuthMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusNoContent)
+		}))
+
+		req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+		if tt.header != "" {
+			req.Header.Set("Authorization", tt.header)
+		}
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		if rec.Code != tt.want {
+			t.Errorf("%s: status = %d, want %d", tt.name, rec.Code, tt.want)
+		}
+		if rec.Code == http.StatusUnauthorized && rec.Header().Get("WWW-Authenticate") == "" {
+			t.Errorf("%s: 401 without a WWW-Authenticate challenge", tt.name)
+		}
+	}
+}
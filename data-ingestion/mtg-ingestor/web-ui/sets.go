@@ -0,0 +1,172 @@
+package main
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// setInfo is a set returned by SetsHandler
+type setInfo struct {
+	Code        string `json:"code"`
+	Name        string `json:"name"`
+	Type        string `json:"type,omitempty"`
+	ReleaseDate string `json:"release_date,omitempty"`
+}
+
+// setIndex maps set codes to names, refreshed periodically from Postgres
+type setIndex struct {
+	mu       sync.RWMutex
+	db       *sql.DB
+	interval time.Duration
+	sets     []setInfo
+	byCode   map[string]string
+}
+
+// setNames is the shared set index used by SetsHandler and query enrichment
+var setNames *setIndex
+
+// newSetIndex loads the sets table; Run keeps it fresh
+func newSetIndex(db *sql.DB) (*setIndex, error) {
+	interval, err := time.ParseDuration(getEnv("SET_INDEX_REFRESH", "10m"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid SET_INDEX_REFRESH: %w", err)
+	}
+	idx := &setIndex{db: db, interval: interval, byCode: map[string]string{}}
+	if err := idx.refresh(); err != nil {
+		logger.Warnf("Set index empty until the next refresh: %v", err)
+	}
+	return idx, nil
+}
+
+// Run reloads the index every interval; a failed reload keeps the previous sets
+func (idx *setIndex) Run() {
+	ticker := time.NewTicker(idx.interval)
+	defer ticker.Stop()
+	for range ticker.C {
+		if err := idx.refresh(); err != nil {
+			logger.Errorf("Error refreshing set index: %v", err)
+		}
+	}
+}
+
+func (idx *setIndex) refresh() error {
+	rows, err := idx.db.Query(`SELECT code, name, COALESCE(type, ''), COALESCE(TO_CHAR(release_date, 'YYYY-MM-DD'), '')
+		FROM sets ORDER BY release_date, code`)
+	if err != nil {
+		return fmt.Errorf("failed to query sets: %w", err)
+	}
+	defer rows.Close()
+
+	var list []setInfo
+	byCode := map[string]string{}
+	for rows.Next() {
+		var s setInfo
+		if err := rows.Scan(&s.Code, &s.Name, &s.Type, &s.ReleaseDate); err != nil {
+			return fmt.Errorf("failed to scan set: %w", err)
+		}
+		list = append(list, s)
+		byCode[strings.ToUpper(s.Code)] = s.Name
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	idx.mu.Lock()
+	idx.sets, idx.byCode = list, byCode
+	idx.mu.Unlock()
+	return nil
+}
+
+// Name returns the name of the set with the given code
+func (idx *setIndex) Name(code string) (string, bool) {
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+	name, ok := idx.byCode[strings.ToUpper(code)]
+	return name, ok
+}
+
+// List returns all known sets
+func (idx *setIndex) List() []setInfo {
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+	return idx.sets
+}
+
+// enrich adds a set_name column to a query response that has a set column.
+// Rows may be raw KSQL column arrays or already decoded values.
+func (idx *setIndex) enrich(response map[string]interface{}) {
+	if idx == nil {
+		return
+	}
+	columns, _ := response["columns"].([]string)
+	setColumn := -1
+	for i, column := range columns {
+		if strings.EqualFold(column, "set") || strings.EqualFold(column, "set_code") {
+			setColumn = i
+			break
+		}
+	}
+	if setColumn < 0 {
+		return
+	}
+
+	switch rows := response["rows"].(type) {
+	case []json.RawMessage:
+		for i, raw := range rows {
+			var row []interface{}
+			if err := json.Unmarshal(raw, &row); err != nil {
+				continue
+			}
+			if encoded, err := json.Marshal(idx.appendSetName(row, setColumn)); err == nil {
+				rows[i] = encoded
+			}
+		}
+	case [][]interface{}:
+		for i, row := range rows {
+			rows[i] = idx.appendSetName(row, setColumn)
+		}
+	default:
+		return
+	}
+	response["columns"] = append(columns, "set_name")
+}
+
+// appendSetName appends the name for the row's set code, or null when unknown
+func (idx *setIndex) appendSetName(row []interface{}, setColumn int) []interface{} {
+	var name interface{}
+	if setColumn < len(row) {
+		if code, ok := row[setColumn].(string); ok {
+			if n, ok := idx.Name(code); ok {
+				name = n
+			}
+		}
+	}
+	return append(row, name)
+}
+
+// SetsHandler returns every known set with its code and name
+func SetsHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if setNames == nil {
+		http.Error(w, "Set data unavailable", http.StatusServiceUnavailable)
+		return
+	}
+
+	list := setNames.List()
+	if list == nil {
+		list = []setInfo{}
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"sets":  list,
+		"count": len(list),
+	})
+}
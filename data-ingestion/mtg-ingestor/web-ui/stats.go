@@ -0,0 +1,98 @@
+package main
+
+import (
+	"database/sql"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	_ "github.com/lib/pq"
+)
+
+// statsCache caches dashboard counts so polling doesn't hit Postgres on every request
+type statsCache struct {
+	mu        sync.Mutex
+	db        *sql.DB
+	ttl       time.Duration
+	counts    map[string]int64
+	fetchedAt time.Time
+}
+
+// openPostgres opens a Postgres handle configured from POSTGRES_* env vars
+func openPostgres() (*sql.DB, error) {
+	dsn := fmt.Sprintf("host=%s port=%s dbname=%s user=%s password=%s sslmode=%s",
+		getEnv("POSTGRES_HOST", "postgres"),
+		getEnv("POSTGRES_PORT", "5432"),
+		getEnv("POSTGRES_DB", "mtg"),
+		getEnv("POSTGRES_USER", "mtg_user"),
+		os.Getenv("POSTGRES_PASSWORD"),
+		getEnv("POSTGRES_SSLMODE", "disable"),
+	)
+
+	db, err := sql.Open("postgres", dsn)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open postgres: %w", err)
+	}
+	return db, nil
+}
+
+// newStatsCache creates a counts cache over the given database
+func newStatsCache(db *sql.DB) (*statsCache, error) {
+	ttl, err := time.ParseDuration(getEnv("STATS_CACHE_TTL", "30s"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid STATS_CACHE_TTL: %w", err)
+	}
+
+	return &statsCache{db: db, ttl: ttl}, nil
+}
+
+// Get returns the cached counts, refreshing them once the TTL has expired.
+// If the refresh fails the last known counts are returned with stale set.
+func (c *statsCache) Get() (counts map[string]int64, fetchedAt time.Time, stale bool, err error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.counts != nil && time.Since(c.fetchedAt) < c.ttl {
+		return c.counts, c.fetchedAt, false, nil
+	}
+
+	fresh, err := c.query()
+	if err != nil {
+		logger.Errorf("Error refreshing stats: %v", err)
+		if c.counts == nil {
+			return nil, time.Time{}, true, err
+		}
+		return c.counts, c.fetchedAt, true, nil
+	}
+
+	c.counts = fresh
+	c.fetchedAt = time.Now()
+	return c.counts, c.fetchedAt, false, nil
+}
+
+func (c *statsCache) query() (map[string]int64, error) {
+	tables := map[string]string{
+		"cards_count":  "cards",
+		"sets_count":   "sets",
+		"prices_count": "card_prices",
+		"decks_count":  "decks",
+	}
+
+	counts := make(map[string]int64, len(tables))
+	for key, table := range tables {
+		var n int64
+		if err := c.db.QueryRow("SELECT count(*) FROM " + table).Scan(&n); err != nil {
+			return nil, fmt.Errorf("failed to count %s: %w", table, err)
+		}
+		counts[key] = n
+	}
+	return counts, nil
+}
+
+func getEnv(key, defaultValue string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return defaultValue
+}
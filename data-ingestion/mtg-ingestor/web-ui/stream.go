@@ -0,0 +1,132 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"regexp"
+	"strings"
+)
+
+// emitChanges matches the EMIT CHANGES clause that makes a SELECT a push query
+var emitChanges = regexp.MustCompile(`(?i)\bEMIT\s+CHANGES\b`)
+
+// maxStreamLine bounds a single row relayed from a KSQL push query
+const maxStreamLine = 1024 * 1024
+
+// StreamHandler runs a KSQL push query and relays its results as Server-Sent
+// Events. The statement comes from the ksql query parameter, since browsers'
+// EventSource can only GET; "EMIT CHANGES" is appended when missing. The
+// header, each row and any final or error message are sent as separate
+// "header", "row" and "message" events. The upstream request is tied to the
+// client's, so a disconnect closes the KSQL connection. When API_TOKEN is set,
+// EventSource clients pass it as the access_token query parameter.
+func StreamHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "Streaming unsupported", http.StatusInternalServerError)
+		return
+	}
+
+	statement := strings.TrimSpace(r.URL.Query().Get("ksql"))
+	if statement == "" {
+		http.Error(w, "Missing ksql statement", http.StatusBadRequest)
+		return
+	}
+	if !isReadOnlyStatement(statement) || statementKeyword(stripSQLComments(statement)) != "SELECT" {
+		http.Error(w, "Only a single SELECT statement can be streamed", http.StatusForbidden)
+		return
+	}
+	// Comments are dropped first so a trailing "--" can't swallow EMIT CHANGES
+	statement = strings.TrimSpace(stripSQLComments(statement))
+	if !emitChanges.MatchString(statement) {
+		statement = strings.TrimSuffix(statement, ";") + " EMIT CHANGES;"
+	}
+
+	body, err := json.Marshal(map[string]interface{}{
+		"ksql":              statement,
+		"streamsProperties": map[string]string{},
+	})
+	if err != nil {
+		http.Error(w, "Failed to encode query request", http.StatusInternalServerError)
+		return
+	}
+	ksqlReq, err := http.NewRequestWithContext(r.Context(), http.MethodPost, ksqlBaseURL+"/query", bytes.NewReader(body))
+	if err != nil {
+		http.Error(w, "Failed to build KSQL request", http.StatusInternalServerError)
+		return
+	}
+	ksqlReq.Header.Set("Content-Type", "application/vnd.ksql.v1+json")
+	ksqlReq.Header.Set(requestIDHeader, requestID(r))
+
+	resp, err := http.DefaultClient.Do(ksqlReq)
+	if err != nil {
+		ksqlProxyErrorsTotal.Inc()
+		requestLogger(r).Errorf("Error opening KSQL stream: %v", err)
+		http.Error(w, "KSQL server unavailable", http.StatusBadGateway)
+		return
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		ksqlProxyErrorsTotal.Inc()
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(resp.StatusCode)
+		io.Copy(w, resp.Body)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	rows := 0
+	scanner := bufio.NewScanner(resp.Body)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxStreamLine)
+	for scanner.Scan() {
+		// The response is one JSON array written an element per line
+		chunk := strings.TrimSpace(scanner.Text())
+		chunk = strings.TrimSuffix(strings.TrimPrefix(chunk, "["), "]")
+		chunk = strings.TrimSpace(strings.TrimSuffix(chunk, ","))
+		if chunk == "" {
+			// KSQL writes blank lines as keepalives; pass them on as SSE comments
+			fmt.Fprint(w, ": keepalive\n\n")
+		} else {
+			event := streamEventType(chunk)
+			if event == "row" {
+				rows++
+			}
+			fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event, chunk)
+		}
+		flusher.Flush()
+	}
+
+	if err := scanner.Err(); err != nil && r.Context().Err() == nil {
+		ksqlProxyErrorsTotal.Inc()
+		requestLogger(r).Errorf("Error reading KSQL stream: %v", err)
+	}
+	requestLogger(r).Infof("KSQL stream closed after %d rows", rows)
+}
+
+// streamEventType names the SSE event for one element of a KSQL query response
+func streamEventType(chunk string) string {
+	var element map[string]json.RawMessage
+	if err := json.Unmarshal([]byte(chunk), &element); err == nil {
+		if _, ok := element["header"]; ok {
+			return "header"
+		}
+		if _, ok := element["row"]; ok {
+			return "row"
+		}
+	}
+	return "message"
+}
@@ -0,0 +1,146 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+// streamUpstream serves a KSQL push query that writes lines and, unless
+// hold is set, finishes. With hold it blocks until the client goes away and
+// reports that on closed. The statement it received is sent on statements.
+func streamUpstream(t *testing.T, lines []string, hold bool) (statements, closed chan string) {
+	t.Helper()
+	statements = make(chan string, 1)
+	closed = make(chan string, 1)
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			KSQL string `json:"ksql"`
+		}
+		json.NewDecoder(r.Body).Decode(&body)
+		statements <- r.URL.Path + " " + body.KSQL
+
+		for _, line := range lines {
+			io.WriteString(w, line+"\n")
+			w.(http.Flusher).Flush()
+		}
+		if hold {
+			<-r.Context().Done()
+			closed <- "closed"
+		}
+	}))
+	t.Cleanup(upstream.Close)
+
+	previous := ksqlBaseURL
+	ksqlBaseURL = upstream.URL
+	t.Cleanup(func() { ksqlBaseURL = previous })
+	return statements, closed
+}
+
+func streamURL(server *httptest.Server, stmt string) string {
+	return server.URL + "/api/stream?" + url.Values{"ksql": {stmt}}.Encode()
+}
+
+func TestStreamHandlerRelaysEvents(t *testing.T) {
+	statements, _ := streamUpstream(t, []string{
+		`[{"header":{"queryId":"q1","schema":"` + "`NAME`" + ` STRING"}},`,
+		`{"row":{"columns":["Lightning Bolt"]}},`,
+		``,
+		`{"row":{"columns":["Shock"]}},`,
+		`{"finalMessage":"Limit Reached"}]`,
+	}, false)
+	server := httptest.NewServer(http.HandlerFunc(StreamHandler))
+	defer server.Close()
+
+	resp, err := http.Get(streamURL(server, "SELECT name FROM cards -- newest first"))
+	if err != nil {
+		t.Fatalf("GET: %v", err)
+	}
+	defer resp.Body.Close()
+	if ct := resp.Header.Get("Content-Type"); ct != "text/event-stream" {
+		t.Errorf("Content-Type = %q, want text/event-stream", ct)
+	}
+	body, _ := io.ReadAll(resp.Body)
+
+	want := `event: header
+data: {"header":{"queryId":"q1","schema":"` + "`NAME`" + ` STRING"}}
+
+event: row
+data: {"row":{"columns":["Lightning Bolt"]}}
+
+: keepalive
+
+event: row
+data: {"row":{"columns":["Shock"]}}
+
+event: message
+data: {"finalMessage":"Limit Reached"}
+
+`
+	if string(body) != want {
+		t.Errorf("stream =\n%s\nwant\n%s", body, want)
+	}
+	if got := <-statements; got != "/query SELECT name FROM cards EMIT CHANGES;" {
+		t.Errorf("forwarded %q, want the comment dropped before EMIT CHANGES", got)
+	}
+}
+
+func TestStreamHandlerClosesUpstreamOnCancel(t *testing.T) {
+	_, closed := streamUpstream(t, []string{`[{"header":{"queryId":"q1","schema":"` + "`NAME`" + ` STRING"}},`}, true)
+	server := httptest.NewServer(http.HandlerFunc(StreamHandler))
+	defer server.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, streamURL(server, "SELECT name FROM cards EMIT CHANGES;"), nil)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("GET: %v", err)
+	}
+	defer resp.Body.Close()
+
+	line, err := bufio.NewReader(resp.Body).ReadString('\n')
+	if err != nil || line != "event: header\n" {
+		t.Fatalf("first line = %q (%v), want the header event", line, err)
+	}
+	cancel()
+
+	select {
+	case <-closed:
+	case <-time.After(5 * time.Second):
+		t.Fatal("upstream KSQL request still open after the client cancelled")
+	}
+}
+
+func TestStreamHandlerRejectsStatements(t *testing.T) {
+	tests := []struct {
+		stmt string
+		want int
+	}{
+		{"", http.StatusBadRequest},
+		{"SHOW STREAMS;", http.StatusForbidden},
+		{"SELECT 1; DROP STREAM cards;", http.StatusForbidden},
+		{"/* SELECT */ TERMINATE ALL;", http.StatusForbidden},
+	}
+
+	for _, tt := range tests {
+		req := httptest.NewRequest(http.MethodGet, "/api/stream?"+url.Values{"ksql": {tt.stmt}}.Encode(), nil)
+		rec := httptest.NewRecorder()
+		StreamHandler(rec, req)
+		if rec.Code != tt.want {
+			t.Errorf("%q: status = %d, want %d", tt.stmt, rec.Code, tt.want)
+		}
+	}
+
+	rec := httptest.NewRecorder()
+	StreamHandler(rec, httptest.NewRequest(http.MethodPost, "/api/stream", strings.NewReader("")))
+	if rec.Code != http.StatusMethodNotAllowed {
+		t.Errorf("POST: status = %d, want %d", rec.Code, http.StatusMethodNotAllowed)
+	}
+}
@@ -0,0 +1,235 @@
+package main
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+)
+
+// textSearchResult is a card returned by TextSearchHandler
+type textSearchResult struct {
+	Name   string  `json:"name"`
+	Type   string  `json:"type"`
+	Rarity string  `json:"rarity"`
+	Set    string  `json:"set"`
+	Text   string  `json:"text"`
+	Score  float64 `json:"score"`
+}
+
+// textDoc is an indexed card with its tokenized oracle text
+type textDoc struct {
+	textSearchResult
+	// normalized is the text's tokens joined by single spaces, for phrase matching
+	normalized string
+}
+
+// posting records how often a term occurs in one card's text
+type posting struct {
+	doc  int
+	freq int
+}
+
+// textIndex is an inverted index over card oracle text, rebuilt from
+// Postgres periodically
+type textIndex struct {
+	mu       sync.RWMutex
+	db       *sql.DB
+	interval time.Duration
+	docs     []textDoc
+	postings map[string][]posting
+}
+
+// textSearch is the shared index used by TextSearchHandler
+var textSearch *textIndex
+
+// quotedPhrase matches a "quoted phrase" in a text query
+var quotedPhrase = regexp.MustCompile(`"([^"]*)"`)
+
+// newTextIndex builds the index from the cards table; Run keeps it fresh
+func newTextIndex(db *sql.DB) (*textIndex, error) {
+	interval, err := time.ParseDuration(getEnv("TEXT_INDEX_REFRESH", "10m"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid TEXT_INDEX_REFRESH: %w", err)
+	}
+	idx := &textIndex{db: db, interval: interval, postings: map[string][]posting{}}
+	if err := idx.refresh(); err != nil {
+		logger.Warnf("Text search index empty until the next refresh: %v", err)
+	}
+	return idx, nil
+}
+
+// Run rebuilds the index every interval; a failed rebuild keeps the previous index
+func (idx *textIndex) Run() {
+	ticker := time.NewTicker(idx.interval)
+	defer ticker.Stop()
+	for range ticker.C {
+		if err := idx.refresh(); err != nil {
+			logger.Errorf("Error refreshing text search index: %v", err)
+		}
+	}
+}
+
+func (idx *textIndex) refresh() error {
+	rows, err := idx.db.Query(`SELECT DISTINCT ON (name) name, COALESCE(type, ''), COALESCE(rarity, ''), COALESCE(set_code, ''), text
+		FROM cards WHERE text IS NOT NULL AND text <> '' ORDER BY name, processed_at DESC`)
+	if err != nil {
+		return fmt.Errorf("failed to query card text: %w", err)
+	}
+	defer rows.Close()
+
+	var docs []textDoc
+	postings := map[string][]posting{}
+	for rows.Next() {
+		var d textDoc
+		if err := rows.Scan(&d.Name, &d.Type, &d.Rarity, &d.Set, &d.Text); err != nil {
+			return fmt.Errorf("failed to scan card: %w", err)
+		}
+		tokens := tokenize(d.Text)
+		d.normalized = strings.Join(tokens, " ")
+
+		freqs := map[string]int{}
+		for _, token := range tokens {
+			freqs[token]++
+		}
+		for term, freq := range freqs {
+			postings[term] = append(postings[term], posting{doc: len(docs), freq: freq})
+		}
+		docs = append(docs, d)
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	idx.mu.Lock()
+	idx.docs, idx.postings = docs, postings
+	idx.mu.Unlock()
+	logger.Infof("Indexed oracle text of %d cards (%d terms)", len(docs), len(postings))
+	return nil
+}
+
+// Search returns cards whose text contains every query term and quoted
+// phrase, ranked by term frequency weighted by how rare each term is
+func (idx *textIndex) Search(query string, limit int) []textSearchResult {
+	var phrases []string
+	for _, m := range quotedPhrase.FindAllStringSubmatch(query, -1) {
+		if phrase := strings.Join(tokenize(m[1]), " "); phrase != "" {
+			phrases = append(phrases, phrase)
+		}
+	}
+	terms := uniqueTerms(tokenize(strings.ReplaceAll(query, `"`, " ")))
+	if len(terms) == 0 {
+		return nil
+	}
+
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+
+	// Intersect postings, rarest term first, accumulating tf-idf scores
+	lists := make([][]posting, 0, len(terms))
+	for _, term := range terms {
+		list, ok := idx.postings[term]
+		if !ok {
+			return nil
+		}
+		lists = append(lists, list)
+	}
+	sort.Slice(lists, func(i, j int) bool { return len(lists[i]) < len(lists[j]) })
+
+	scores := map[int]float64{}
+	for n, list := range lists {
+		idf := math.Log(float64(len(idx.docs))/float64(len(list))) + 1
+		next := map[int]float64{}
+		for _, p := range list {
+			if score, ok := scores[p.doc]; ok || n == 0 {
+				next[p.doc] = score + float64(p.freq)*idf
+			}
+		}
+		scores = next
+	}
+
+	results := make([]textSearchResult, 0, len(scores))
+	for doc, score := range scores {
+		d := idx.docs[doc]
+		if !containsPhrases(d.normalized, phrases) {
+			continue
+		}
+		r := d.textSearchResult
+		r.Score = math.Round(score*1000) / 1000
+		results = append(results, r)
+	}
+
+	sort.Slice(results, func(i, j int) bool {
+		if results[i].Score != results[j].Score {
+			return results[i].Score > results[j].Score
+		}
+		return results[i].Name < results[j].Name
+	})
+	if len(results) > limit {
+		results = results[:limit]
+	}
+	return results
+}
+
+// tokenize lowercases text and splits it into runs of letters and digits
+func tokenize(text string) []string {
+	return strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+func uniqueTerms(tokens []string) []string {
+	seen := make(map[string]bool, len(tokens))
+	terms := tokens[:0]
+	for _, token := range tokens {
+		if !seen[token] {
+			seen[token] = true
+			terms = append(terms, token)
+		}
+	}
+	return terms
+}
+
+// containsPhrases reports whether normalized text holds each phrase on word boundaries
+func containsPhrases(normalized string, phrases []string) bool {
+	padded := " " + normalized + " "
+	for _, phrase := range phrases {
+		if !strings.Contains(padded, " "+phrase+" ") {
+			return false
+		}
+	}
+	return true
+}
+
+// TextSearchHandler finds cards by oracle text: every term must appear and
+// "quoted phrases" must appear verbatim, e.g. ?q="draw a card" flying
+func TextSearchHandler(w http.ResponseWriter, r *http.Request) {
+	query := strings.TrimSpace(r.URL.Query().Get("q"))
+	if query == "" {
+		http.Error(w, "Missing search query", http.StatusBadRequest)
+		return
+	}
+	limit, ok := searchLimit(r)
+	if !ok {
+		http.Error(w, "Invalid limit", http.StatusBadRequest)
+		return
+	}
+	if textSearch == nil {
+		http.Error(w, "Search backend not configured", http.StatusServiceUnavailable)
+		return
+	}
+
+	results := textSearch.Search(query, limit)
+	if results == nil {
+		results = []textSearchResult{}
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(results)
+}